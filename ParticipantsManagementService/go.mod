@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/text v0.23.0
 	gorm.io/datatypes v1.2.5
 	gorm.io/driver/postgres v1.5.11
 	gorm.io/gorm v1.25.12
@@ -37,5 +38,4 @@ require (
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 )
@@ -29,15 +29,33 @@ type SubmitRequest struct {
 	Answers []service.FinalAnswerInput `json:"answers"`
 }
 
+// DTO for Next Question Request Body
+type NextQuestionRequest struct {
+	CurrentQuestionID uint `json:"currentQuestionId"`
+}
+
+// localePreference reads the locale a participant chose (?locale=) and the ones their
+// browser prefers (Accept-Language)
+func localePreference(c *fiber.Ctx) service.LocalePreference {
+	return service.LocalePreference{
+		Locale:         c.Query("locale"),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+	}
+}
+
 // HandleStartOrResumeSurvey godoc
 // @Summary Start or Resume Survey Participation
-// @Description Finds an existing active session for the participant and survey, or creates a new one. Returns session details and any existing draft answers.
+// @Description Finds an existing active session for the participant and survey, or creates a new one. Returns session details and any existing draft answers. The session's locale is the one chosen with ?locale=, else the one it already has, else the best match for Accept-Language, else the survey's default; it is returned with the translations of the survey's strings.
 // @Tags Participant
 // @Accept json
 // @Produce json
 // @Param surveyId path int true "Survey ID"
+// @Param locale query string false "Locale chosen by the participant, e.g. pt-BR"
+// @Param Accept-Language header string false "Locales the participant prefers, used when none is chosen"
 // @Success 200 {object} service.StartResumeResponse
-// @Failure 400 {object} fiber.Map "Invalid Survey ID or Participant ID missing"
+// @Failure 400 {object} fiber.Map "Invalid Survey ID, Participant ID missing or unsupported locale"
+// @Failure 404 {object} fiber.Map "Survey not found"
+// @Failure 409 {object} fiber.Map "Survey is not open"
 // @Failure 500 {object} fiber.Map "Internal Server Error"
 // @Router /api/participant/surveys/{surveyId}/session [post]
 // @Security BearerAuth
@@ -56,8 +74,17 @@ func (h *ParticipantHandler) HandleStartOrResumeSurvey(c *fiber.Ctx) error {
 	}
 	// --- End Get Participant ID ---
 
-	response, err := h.service.StartOrResumeSurvey(c.Context(), uint(surveyID), participantID)
+	response, err := h.service.StartOrResumeSurvey(c.Context(), uint(surveyID), participantID, localePreference(c))
 	if err != nil {
+		if errors.Is(err, repository.ErrSurveyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Survey not found"})
+		}
+		if errors.Is(err, service.ErrUnsupportedLocale) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, service.ErrSurveyNotOpen) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		// Log error details (err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start or resume survey session"})
 	}
@@ -128,6 +155,7 @@ func (h *ParticipantHandler) HandleSaveDraft(c *fiber.Ctx) error {
 // @Failure 400 {object} fiber.Map "Invalid Session ID or request body"
 // @Failure 404 {object} fiber.Map "Session not found"
 // @Failure 409 {object} fiber.Map "Session not in progress"
+// @Failure 422 {object} fiber.Map "Answers do not fit their questions or mandatory questions are unanswered, as checked by the Survey Management Service; details lists question_id and message per problem"
 // @Failure 500 {object} fiber.Map "Internal Server Error"
 // @Router /api/participant/sessions/{sessionId}/submit [post]
 // @Security BearerAuth
@@ -150,11 +178,17 @@ func (h *ParticipantHandler) HandleSubmitSurvey(c *fiber.Ctx) error {
 		req.Answers = []service.FinalAnswerInput{}
 	}
 
-	err = h.service.SubmitSurvey(c.Context(), uint(sessionID), req.Answers)
+	ctx := service.WithAuthorization(c.Context(), c.Get(fiber.HeaderAuthorization))
+	err = h.service.SubmitSurvey(ctx, uint(sessionID), req.Answers)
 	if err != nil {
 		if errors.Is(err, repository.ErrSessionNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
 		}
+		var invalid *service.AnswerValidationError
+		if errors.As(err, &invalid) {
+			// One entry per invalid answer, for the participant UI to show inline
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Some answers are invalid", "details": invalid.Errors})
+		}
 		// Handle potential conflict error from service (e.g., "survey session is not in progress")
 		if err.Error() == "survey session is not in progress" {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
@@ -174,8 +208,10 @@ func (h *ParticipantHandler) HandleSubmitSurvey(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param surveyId path int true "Survey ID"
+// @Param locale query string false "Locale chosen by the participant, e.g. pt-BR"
+// @Param Accept-Language header string false "Locales the participant prefers, used when none is chosen"
 // @Success 200 {object} service.StartResumeResponse
-// @Failure 400 {object} fiber.Map "Invalid Survey ID or Participant ID missing"
+// @Failure 400 {object} fiber.Map "Invalid Survey ID, Participant ID missing or unsupported locale"
 // @Failure 404 {object} fiber.Map "Session not found"
 // @Failure 500 {object} fiber.Map "Internal Server Error"
 // @Router /api/participant/surveys/{surveyId}/session [get]
@@ -196,8 +232,17 @@ func (h *ParticipantHandler) HandleGetSession(c *fiber.Ctx) error {
 
 	// In development mode, we can just reuse the StartOrResumeSurvey logic
 	// since it will find or create a session
-	response, err := h.service.StartOrResumeSurvey(c.Context(), uint(surveyID), participantID)
+	response, err := h.service.StartOrResumeSurvey(c.Context(), uint(surveyID), participantID, localePreference(c))
 	if err != nil {
+		if errors.Is(err, repository.ErrSurveyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Survey not found"})
+		}
+		if errors.Is(err, service.ErrUnsupportedLocale) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, service.ErrSurveyNotOpen) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		// Log the error for debugging
 		log.Printf("Failed to get survey session for surveyID=%d, participantID=%d: %v", surveyID, participantID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get survey session", "details": err.Error()})
@@ -207,3 +252,93 @@ func (h *ParticipantHandler) HandleGetSession(c *fiber.Ctx) error {
 	log.Printf("Successfully served session for surveyID=%d, participantID=%d", surveyID, participantID)
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// HandleGetSessionQuestion godoc
+// @Summary Get a Session Question
+// @Description Gets a question of the survey version the session is pinned to, with earlier answers from the draft piped into its text and option texts (placeholders like {{q:language}}) and its options in the session's order.
+// @Tags Participant
+// @Accept json
+// @Produce json
+// @Param sessionId path int true "Session ID"
+// @Param questionId path int true "Question ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} fiber.Map "Invalid Session ID, Question ID or Participant ID missing"
+// @Failure 404 {object} fiber.Map "Session or question not found"
+// @Failure 500 {object} fiber.Map "Internal Server Error"
+// @Router /api/participant/sessions/{sessionId}/questions/{questionId} [get]
+// @Security BearerAuth
+func (h *ParticipantHandler) HandleGetSessionQuestion(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("sessionId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid session ID format"})
+	}
+	questionID, err := strconv.ParseUint(c.Params("questionId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid question ID format"})
+	}
+
+	participantID, ok := c.Locals("participantId").(uint)
+	if !ok || participantID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Participant ID missing or invalid"})
+	}
+
+	question, err := h.service.GetQuestion(c.Context(), uint(sessionID), participantID, uint(questionID))
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found", "details": err.Error()})
+		}
+		log.Printf("Failed to get question %d for session %d: %v", questionID, sessionID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get question"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(question)
+}
+
+// HandleNextQuestion godoc
+// @Summary Get the Next Question
+// @Description Evaluates the branching rules of the survey version the session is pinned to against the answers saved in the draft, and returns the question that follows currentQuestionId. A next_question_id of 0 with end_of_survey set means the survey ends.
+// @Tags Participant
+// @Accept json
+// @Produce json
+// @Param sessionId path int true "Session ID"
+// @Param request body NextQuestionRequest true "Question the participant is on"
+// @Success 200 {object} service.NextQuestionResult
+// @Failure 400 {object} fiber.Map "Invalid Session ID, request body or Participant ID missing"
+// @Failure 404 {object} fiber.Map "Session or question not found"
+// @Failure 500 {object} fiber.Map "Internal Server Error"
+// @Router /api/participant/sessions/{sessionId}/next-question [post]
+// @Security BearerAuth
+func (h *ParticipantHandler) HandleNextQuestion(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("sessionId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid session ID format"})
+	}
+
+	participantID, ok := c.Locals("participantId").(uint)
+	if !ok || participantID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Participant ID missing or invalid"})
+	}
+
+	var req NextQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body", "details": err.Error()})
+	}
+
+	ctx := service.WithAuthorization(c.Context(), c.Get(fiber.HeaderAuthorization))
+	next, err := h.service.NextQuestion(ctx, uint(sessionID), participantID, req.CurrentQuestionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found", "details": err.Error()})
+		}
+		log.Printf("Failed to get the question after %d for session %d: %v", req.CurrentQuestionID, sessionID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get next question"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(next)
+}
@@ -52,7 +52,11 @@ func main() {
 
 	// Initialize Layers
 	participantRepo := repository.NewGormParticipantRepository(db)
-	participantService := service.NewParticipantService(participantRepo)
+	surveyServiceURL := os.Getenv("SURVEY_SERVICE_URL")
+	if surveyServiceURL == "" {
+		surveyServiceURL = "http://localhost:3001"
+	}
+	participantService := service.NewParticipantService(participantRepo, service.NewSurveyClient(surveyServiceURL))
 	participantHandler := handler.NewParticipantHandler(participantService)
 
 	// Setup Routes
@@ -20,6 +20,11 @@ type SurveySession struct {
 	// to a participant defined in the AuthService.
 	ParticipantID uint `json:"participant_id" gorm:"column:participant_id;not null;index"` // Index useful for lookups
 
+	// SurveyVersionID pins the published survey version the session started on, so
+	// republishing the survey never changes it mid-session. 0 if the survey had no
+	// published version at the time.
+	SurveyVersionID uint `json:"survey_version_id" gorm:"column:survey_version_id;index"`
+
 	// LastQuestionID tracks the ID of the last question the participant was shown or answered.
 	// Useful for resuming. Pointer allows null. Refers to a question defined elsewhere.
 	LastQuestionID *uint `json:"last_question_id,omitempty" gorm:"column:last_question_id"`
@@ -28,6 +33,18 @@ type SurveySession struct {
 	// Examples: "IN_PROGRESS", "COMPLETED", "ABANDONED"
 	SessionStatus string `json:"session_status" gorm:"column:session_status;not null;index"`
 
+	// RandomSeed seeds the session's question and option shuffles, so a participant who
+	// pauses and resumes sees the same order.
+	RandomSeed int64 `json:"random_seed" gorm:"column:random_seed"`
+
+	// PresentationOrder records the order the participant was shown, as a
+	// PresentationOrder document. Set on the first start of a session with a pinned version.
+	PresentationOrder datatypes.JSON `json:"presentation_order,omitempty" gorm:"column:presentation_order;type:jsonb"`
+
+	// Locale is the BCP 47 locale the participant takes the survey in, negotiated when the
+	// session starts or resumes. Empty if the survey is not translated.
+	Locale string `json:"locale,omitempty" gorm:"column:locale;size:35"`
+
 	// CreatedAt timestamp for when the session was initiated.
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 
@@ -66,6 +83,9 @@ type Answer struct {
 	// is always simple text, change `datatypes.JSON` here to `string`. Assuming JSONB potential.
 	ResponseData datatypes.JSON `json:"response_data" gorm:"column:response_data;type:jsonb"` // Or string if SQL is text
 
+	// Locale is the locale the question was shown in when answered, the session's.
+	Locale string `json:"locale,omitempty" gorm:"column:locale;size:35"`
+
 	// Deprecated fields based on SQL schema (response can be handled by ResponseData)
 	// Response *string `json:"response,omitempty" gorm:"column:response"` // Consider removing if ResponseData is used
 
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Survey is a read-only view of the surveys table owned by the Survey Management
+// Service. Only the columns this service needs are mapped; it is never migrated here.
+type Survey struct {
+	// SurveyID is the survey's identifier in the Survey Management Service.
+	SurveyID uint `json:"id" gorm:"primaryKey;column:survey_id"`
+
+	// Status is the survey's lifecycle state: DRAFT, SCHEDULED, OPEN, PAUSED, CLOSED or ARCHIVED.
+	// Participants may only take OPEN surveys.
+	Status string `json:"status" gorm:"column:status"`
+
+	// ClosesAt is when the survey stops accepting participants, if scheduled.
+	ClosesAt *time.Time `json:"closes_at,omitempty" gorm:"column:closes_at"`
+
+	// CurrentVersionID is the latest published SurveyVersion, 0 if never published.
+	CurrentVersionID uint `json:"current_version_id" gorm:"column:current_version_id"`
+
+	// Title and Description are the survey's texts in its default locale.
+	Title       string `json:"title" gorm:"column:title"`
+	Description string `json:"description" gorm:"column:description"`
+
+	// DefaultLocale is the BCP 47 locale the survey is written in, empty if it is not
+	// translated.
+	DefaultLocale string `json:"default_locale,omitempty" gorm:"column:default_locale"`
+
+	// SupportedLocales lists the locales the survey can be taken in, as a JSON array
+	// that includes DefaultLocale.
+	SupportedLocales datatypes.JSON `json:"supported_locales,omitempty" gorm:"column:supported_locales;type:jsonb"`
+
+	// DeletedAt is set when the survey is soft-deleted; GORM then hides the row, so
+	// deleted surveys look not found here.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+// TableName specifies the corresponding database table name for GORM.
+func (Survey) TableName() string {
+	return "surveys"
+}
+
+// SurveyStatusOpen is the only status in which participants may take a survey.
+const SurveyStatusOpen = "OPEN"
+
+// IsOpen reports whether the survey accepts participants at the given time. The
+// Survey Management scheduler closes expired surveys periodically, so ClosesAt is
+// checked here as well.
+func (s *Survey) IsOpen(now time.Time) bool {
+	if s.Status != SurveyStatusOpen {
+		return false
+	}
+	return s.ClosesAt == nil || now.Before(*s.ClosesAt)
+}
+
+// --------------------------------------------------------------------------
+
+// SurveyVersion is a read-only view of an immutable published survey snapshot,
+// owned by the Survey Management Service. Sessions pin one when they start.
+type SurveyVersion struct {
+	// VersionID is the unique identifier of the version.
+	VersionID uint `json:"id" gorm:"primaryKey;column:version_id"`
+
+	// SurveyID identifies the survey the version belongs to.
+	SurveyID uint `json:"survey_id" gorm:"column:survey_id"`
+
+	// VersionNumber counts the survey's publishes, starting at 1.
+	VersionNumber int `json:"version_number" gorm:"column:version_number"`
+
+	// Snapshot holds the questions, options, media files and branching rules
+	// exactly as they were published.
+	Snapshot datatypes.JSON `json:"snapshot" gorm:"column:snapshot;type:jsonb"`
+
+	// PublishedAt timestamp for when the version was published.
+	PublishedAt time.Time `json:"published_at" gorm:"column:published_at"`
+}
+
+// TableName specifies the corresponding database table name for GORM.
+func (SurveyVersion) TableName() string {
+	return "survey_versions"
+}
+
+// Locales reads the survey's supported locales.
+func (s *Survey) Locales() []string {
+	var locales []string
+	if len(s.SupportedLocales) > 0 {
+		_ = json.Unmarshal(s.SupportedLocales, &locales)
+	}
+	return locales
+}
+
+// --------------------------------------------------------------------------
+
+// Translation is a read-only view of a survey string translated into one locale, owned
+// by the Survey Management Service.
+type Translation struct {
+	// SurveyID identifies the translated survey.
+	SurveyID uint `json:"survey_id" gorm:"column:survey_id"`
+
+	// Locale is the BCP 47 locale translated into.
+	Locale string `json:"locale" gorm:"column:locale"`
+
+	// ResourceID names the string, e.g. survey.title or question.<key>.option.<option_key>.text.
+	ResourceID string `json:"resource_id" gorm:"column:resource_id"`
+
+	// SourceText is the original text the translation was made from. A translation
+	// only applies to a string that still has this text.
+	SourceText string `json:"source_text" gorm:"column:source_text"`
+
+	// Text is the translated text.
+	Text string `json:"text" gorm:"column:text"`
+}
+
+// TableName specifies the corresponding database table name for GORM.
+func (Translation) TableName() string {
+	return "translations"
+}
+
+// --------------------------------------------------------------------------
+
+// SnapshotQuestion is a question as stored in SurveyVersion.Snapshot by the Survey
+// Management Service. Only the fields this service reads are mapped.
+type SnapshotQuestion struct {
+	// QuestionID identifies the question; answers refer to it.
+	QuestionID uint `json:"id"`
+
+	// QuestionKey is the question's stable key; piped text refers to it, e.g. {{q:language}}.
+	QuestionKey string `json:"question_key"`
+
+	// QuestionText is the question's text in the survey's default locale.
+	QuestionText string `json:"question_text"`
+
+	// QuestionType is the question's type, e.g. TEXT or MULTIPLE_CHOICE.
+	QuestionType string `json:"question_type"`
+
+	// Mandatory questions must be answered.
+	Mandatory bool `json:"mandatory"`
+
+	// PageID is the page the question is shown on, if any.
+	PageID *uint `json:"page_id,omitempty"`
+
+	// Options are the question's options in their authored order.
+	Options []SnapshotOption `json:"options,omitempty"`
+
+	// RandomizeOptions shuffles the options for each session.
+	RandomizeOptions bool `json:"randomize_options,omitempty"`
+}
+
+// SnapshotOption is an option of a SnapshotQuestion.
+type SnapshotOption struct {
+	// OptionID identifies the option; choice answers refer to it.
+	OptionID uint `json:"id"`
+
+	// OptionKey is the option's stable key within the question; translations refer to it.
+	OptionKey string `json:"option_key,omitempty"`
+
+	// OptionText is the option's label, piped in place of choice answers.
+	OptionText string `json:"option_text"`
+
+	// Position is the option's place within the question.
+	Position int `json:"position"`
+
+	// PinLast keeps the option at the end when options are shuffled, e.g. "Other".
+	PinLast bool `json:"pin_last,omitempty"`
+}
+
+// SnapshotSection is a section as stored in SurveyVersion.Snapshot, with its pages in order.
+type SnapshotSection struct {
+	// SectionID identifies the section.
+	SectionID uint `json:"id"`
+
+	// Pages are the section's pages in order.
+	Pages []SnapshotPage `json:"pages,omitempty"`
+
+	// RandomizeQuestions shuffles the questions within each of the section's pages.
+	RandomizeQuestions bool `json:"randomize_questions,omitempty"`
+}
+
+// SnapshotPage is a page of a SnapshotSection.
+type SnapshotPage struct {
+	// PageID identifies the page; questions name it in their PageID.
+	PageID uint `json:"id"`
+}
+
+// SurveySnapshot is the part of SurveyVersion.Snapshot this service reads.
+type SurveySnapshot struct {
+	// Questions are the version's questions in survey order.
+	Questions []SnapshotQuestion `json:"questions"`
+
+	// Sections are the version's sections in order.
+	Sections []SnapshotSection `json:"sections,omitempty"`
+}
+
+// PresentationOrder is the order a session shows a survey version in, as recorded in
+// SurveySession.PresentationOrder.
+type PresentationOrder struct {
+	// QuestionIDs lists every question in the order shown.
+	QuestionIDs []uint `json:"question_ids"`
+
+	// OptionIDs lists, by question ID, the order of options that were shuffled.
+	OptionIDs map[uint][]uint `json:"option_ids,omitempty"`
+}
+
+// Content reads the questions and sections out of the version's snapshot.
+func (v *SurveyVersion) Content() (*SurveySnapshot, error) {
+	var snapshot SurveySnapshot
+	if err := json.Unmarshal(v.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Questions reads the questions out of the version's snapshot.
+func (v *SurveyVersion) Questions() ([]SnapshotQuestion, error) {
+	snapshot, err := v.Content()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Questions, nil
+}
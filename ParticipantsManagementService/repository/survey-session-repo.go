@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"math/rand/v2"
 	"time"
 
 	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/models"
@@ -13,10 +14,18 @@ import (
 
 var ErrSessionNotFound = errors.New("session not found")
 var ErrDraftNotFound = errors.New("draft not found")
+var ErrSurveyVersionNotFound = errors.New("survey version not found")
+var ErrSurveyNotFound = errors.New("survey not found")
 
 type ParticipantRepository interface {
 	// Finds an IN_PROGRESS session or creates a new one. Returns the session.
 	FindOrCreateSession(ctx context.Context, surveyID, participantID uint) (*models.SurveySession, error)
+	// Gets the survey's lifecycle state.
+	GetSurvey(ctx context.Context, surveyID uint) (*models.Survey, error)
+	// Gets a published survey version snapshot.
+	GetSurveyVersion(ctx context.Context, versionID uint) (*models.SurveyVersion, error)
+	// Gets a survey's translations into a locale.
+	GetTranslations(ctx context.Context, surveyID uint, locale string) ([]models.Translation, error)
 	// Gets session details.
 	GetSessionByID(ctx context.Context, sessionID uint) (*models.SurveySession, error)
 	GetSessionBySurveyParticipant(ctx context.Context, surveyID, participantID uint) (*models.SurveySession, error) // Useful if sessionID isn't known upfront
@@ -69,11 +78,20 @@ func (r *gormParticipantRepository) FindOrCreateSession(ctx context.Context, sur
 		return nil, err
 	}
 
+	// Pin the version that is current right now; later publishes won't affect this session
+	var survey models.Survey
+	err = r.db.WithContext(ctx).Select("survey_id", "current_version_id").First(&survey, surveyID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
 	// Not found, create a new one
 	newSession := models.SurveySession{
-		SurveyID:      surveyID,
-		ParticipantID: participantID,
-		SessionStatus: "IN_PROGRESS", // Start as IN_PROGRESS
+		SurveyID:        surveyID,
+		ParticipantID:   participantID,
+		SurveyVersionID: survey.CurrentVersionID,
+		SessionStatus:   "IN_PROGRESS", // Start as IN_PROGRESS
+		RandomSeed:      rand.Int64(),  // Fixes the session's question and option shuffles
 		// LastQuestionID will be null initially
 	}
 
@@ -84,6 +102,30 @@ func (r *gormParticipantRepository) FindOrCreateSession(ctx context.Context, sur
 	return &newSession, nil
 }
 
+func (r *gormParticipantRepository) GetSurvey(ctx context.Context, surveyID uint) (*models.Survey, error) {
+	var survey models.Survey
+	err := r.db.WithContext(ctx).First(&survey, surveyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	return &survey, err
+}
+
+func (r *gormParticipantRepository) GetSurveyVersion(ctx context.Context, versionID uint) (*models.SurveyVersion, error) {
+	var version models.SurveyVersion
+	err := r.db.WithContext(ctx).First(&version, versionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyVersionNotFound
+	}
+	return &version, err
+}
+
+func (r *gormParticipantRepository) GetTranslations(ctx context.Context, surveyID uint, locale string) ([]models.Translation, error) {
+	var translations []models.Translation
+	err := r.db.WithContext(ctx).Where("survey_id = ? AND locale = ?", surveyID, locale).Find(&translations).Error
+	return translations, err
+}
+
 func (r *gormParticipantRepository) GetSessionByID(ctx context.Context, sessionID uint) (*models.SurveySession, error) {
 	var session models.SurveySession
 	err := r.db.WithContext(ctx).First(&session, sessionID).Error
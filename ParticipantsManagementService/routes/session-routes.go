@@ -28,6 +28,12 @@ func SetupParticipantRoutes(app *fiber.App, participantHandler *handler.Particip
 	// GET endpoint for session data
 	participantGroup.Get("/surveys/:surveyId/session", participantHandler.HandleGetSession)
 
+	// Route to get a question of a session, with earlier answers piped into its text
+	participantGroup.Get("/sessions/:sessionId/questions/:questionId", participantHandler.HandleGetSessionQuestion)
+
+	// Route to get the question that follows the current one, by the survey's branching rules
+	participantGroup.Post("/sessions/:sessionId/next-question", participantHandler.HandleNextQuestion)
+
 	// Route to save the draft for a specific session
 	participantGroup.Put("/sessions/:sessionId/draft", participantHandler.HandleSaveDraft)
 
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/models"
+)
+
+// pipePattern matches a placeholder for an earlier answer in question or option text,
+// e.g. {{q:language}}, where language is the answered question's key. It is the pattern
+// the Survey Management Service validates pipes with.
+var pipePattern = regexp.MustCompile(`\{\{\s*q:([^{}]*?)\s*\}\}`)
+
+// answerPiper fills pipe placeholders in with a session's draft answers
+type answerPiper struct {
+	questions map[string]models.SnapshotQuestion // By lower-cased question key
+	answers   map[string]interface{}             // Draft answers by question ID
+}
+
+func newAnswerPiper(questions []models.SnapshotQuestion, draft *models.ParticipantSurveyDraft) (*answerPiper, error) {
+	p := &answerPiper{
+		questions: make(map[string]models.SnapshotQuestion, len(questions)),
+		answers:   map[string]interface{}{},
+	}
+	for _, q := range questions {
+		if q.QuestionKey != "" {
+			p.questions[strings.ToLower(q.QuestionKey)] = q
+		}
+	}
+	if draft != nil && len(draft.DraftAnswersContent) > 0 {
+		if err := json.Unmarshal(draft.DraftAnswersContent, &p.answers); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// pipe replaces each placeholder in text with the answer it refers to. Placeholders for
+// unknown or unanswered questions become empty.
+func (p *answerPiper) pipe(text string) string {
+	return pipePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		key := strings.ToLower(pipePattern.FindStringSubmatch(placeholder)[1])
+		q, ok := p.questions[key]
+		if !ok {
+			return ""
+		}
+		return formatAnswer(p.answers[strconv.FormatUint(uint64(q.QuestionID), 10)], q.Options)
+	})
+}
+
+// formatAnswer renders an answer as text. Choice answers name options by ID, so an ID of
+// one of the question's options is shown as that option's text; lists are joined with
+// commas. Answers with no plain text form, like uploads or grids, are shown as nothing.
+func formatAnswer(answer interface{}, options []models.SnapshotOption) string {
+	switch v := answer.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v >= 0 && v == math.Trunc(v) {
+			for _, option := range options {
+				if float64(option.OptionID) == v {
+					return option.OptionText
+				}
+			}
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := formatAnswer(item, options); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/models"
+	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/repository"
+)
+
+// ErrUnsupportedLocale is returned when a participant explicitly chooses a locale the
+// survey is not translated into
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+// LocalePreference is what a participant asked for when starting or resuming a survey:
+// an explicit choice, e.g. from a language picker, and their Accept-Language header
+type LocalePreference struct {
+	Locale         string
+	AcceptLanguage string
+}
+
+// negotiateLocale picks the locale a session is shown in from the survey's supported
+// locales: the participant's explicit choice, else the locale the session already has,
+// else the best match for Accept-Language, else the survey's default. Surveys without
+// locales are shown as written, with an empty locale.
+func negotiateLocale(survey *models.Survey, session *models.SurveySession, pref LocalePreference) (string, error) {
+	supported := survey.Locales()
+
+	if strings.TrimSpace(pref.Locale) != "" {
+		tag, err := language.Parse(strings.TrimSpace(pref.Locale))
+		if err == nil {
+			for _, locale := range supported {
+				if locale == tag.String() {
+					return locale, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("%w: survey %d is not available in %q", ErrUnsupportedLocale, survey.SurveyID, pref.Locale)
+	}
+	if len(supported) == 0 {
+		return "", nil
+	}
+
+	for _, locale := range supported {
+		if locale == session.Locale {
+			return locale, nil
+		}
+	}
+
+	if desired, _, err := language.ParseAcceptLanguage(pref.AcceptLanguage); err == nil && len(desired) > 0 {
+		// The default comes first so it wins when nothing matches
+		candidates := append([]string{survey.DefaultLocale}, supported...)
+		tags := make([]language.Tag, 0, len(candidates))
+		for _, locale := range candidates {
+			tags = append(tags, language.Make(locale))
+		}
+		_, index, confidence := language.NewMatcher(tags).Match(desired...)
+		if confidence != language.No {
+			return candidates[index], nil
+		}
+	}
+
+	if survey.DefaultLocale != "" {
+		return survey.DefaultLocale, nil
+	}
+	return supported[0], nil
+}
+
+// localizer translates the strings of a survey version into a session's locale. A
+// translation applies only while the string still has the text it was translated from;
+// other strings are shown as written.
+type localizer struct {
+	translations map[string]models.Translation
+}
+
+// newLocalizer loads the survey's translations into locale; an empty locale, or the one
+// the survey is written in, has none
+func newLocalizer(ctx context.Context, repo repository.ParticipantRepository, surveyID uint, locale string) (*localizer, error) {
+	l := &localizer{translations: make(map[string]models.Translation)}
+	if locale == "" {
+		return l, nil
+	}
+	translations, err := repo.GetTranslations(ctx, surveyID, locale)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range translations {
+		l.translations[t.ResourceID] = t
+	}
+	return l, nil
+}
+
+// text returns the translation of the string resourceID, or source when it has none
+func (l *localizer) text(resourceID, source string) string {
+	if t, ok := l.translations[resourceID]; ok && t.SourceText == source && t.Text != "" {
+		return t.Text
+	}
+	return source
+}
+
+// questions returns copies of the questions with their text and option texts translated
+func (l *localizer) questions(questions []models.SnapshotQuestion) []models.SnapshotQuestion {
+	localized := make([]models.SnapshotQuestion, len(questions))
+	for i, q := range questions {
+		prefix := questionResourcePrefix(q)
+		q.QuestionText = l.text(prefix+".text", q.QuestionText)
+		options := make([]models.SnapshotOption, len(q.Options))
+		for j, option := range q.Options {
+			option.OptionText = l.text(optionResourceID(prefix, option), option.OptionText)
+			options[j] = option
+		}
+		q.Options = options
+		localized[i] = q
+	}
+	return localized
+}
+
+// translated maps the resource IDs of the survey's and the questions' strings to their
+// translations, leaving out strings without one
+func (l *localizer) translated(survey *models.Survey, questions []models.SnapshotQuestion) map[string]string {
+	result := make(map[string]string)
+	add := func(resourceID, source string) {
+		if text := l.text(resourceID, source); source != "" && text != source {
+			result[resourceID] = text
+		}
+	}
+	add("survey.title", survey.Title)
+	add("survey.description", survey.Description)
+	for _, q := range questions {
+		prefix := questionResourcePrefix(q)
+		add(prefix+".text", q.QuestionText)
+		for _, option := range q.Options {
+			add(optionResourceID(prefix, option), option.OptionText)
+		}
+	}
+	return result
+}
+
+// questionResourcePrefix names a question's strings as the Survey Management Service
+// does: by its key, or by ID for questions without one
+func questionResourcePrefix(q models.SnapshotQuestion) string {
+	if q.QuestionKey != "" {
+		return "question." + q.QuestionKey
+	}
+	return "question.ID" + strconv.FormatUint(uint64(q.QuestionID), 10)
+}
+
+// optionResourceID names an option's text by its key, or by ID for options without one
+func optionResourceID(prefix string, option models.SnapshotOption) string {
+	if option.OptionKey != "" {
+		return prefix + ".option." + option.OptionKey + ".text"
+	}
+	return prefix + ".option.ID" + strconv.FormatUint(uint64(option.OptionID), 10) + ".text"
+}
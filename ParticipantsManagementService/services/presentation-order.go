@@ -0,0 +1,91 @@
+package service
+
+import (
+	"math/rand/v2"
+
+	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/models"
+)
+
+// Shuffle streams, so each shuffle of a session draws its own sequence from the seed
+const (
+	pageStream   uint64 = 1
+	optionStream uint64 = 2
+)
+
+// presentationOrder works out the order a session shows a version in. Questions are
+// shuffled within each page of a section with RandomizeQuestions; pages and sections
+// keep their order. Options of a question with RandomizeOptions are shuffled, with
+// pinned options kept at the end in their authored order. The same seed always gives
+// the same order.
+func presentationOrder(version *models.SurveyVersion, seed int64) (*models.PresentationOrder, error) {
+	snapshot, err := version.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	shuffledPages := make(map[uint]bool)
+	for _, section := range snapshot.Sections {
+		if !section.RandomizeQuestions {
+			continue
+		}
+		for _, page := range section.Pages {
+			shuffledPages[page.PageID] = true
+		}
+	}
+
+	order := &models.PresentationOrder{QuestionIDs: make([]uint, 0, len(snapshot.Questions))}
+
+	// Questions come grouped by page, so each page is a run of questions
+	questions := snapshot.Questions
+	for start := 0; start < len(questions); {
+		end := start + 1
+		for end < len(questions) && samePage(questions[start].PageID, questions[end].PageID) {
+			end++
+		}
+		run := make([]uint, 0, end-start)
+		for _, q := range questions[start:end] {
+			run = append(run, q.QuestionID)
+		}
+		if page := questions[start].PageID; page != nil && shuffledPages[*page] {
+			shuffle(seed, pageStream, *page, run)
+		}
+		order.QuestionIDs = append(order.QuestionIDs, run...)
+		start = end
+	}
+
+	for _, q := range questions {
+		if !q.RandomizeOptions || len(q.Options) == 0 {
+			continue
+		}
+		var free, pinned []uint
+		for _, option := range q.Options {
+			if option.PinLast {
+				pinned = append(pinned, option.OptionID)
+			} else {
+				free = append(free, option.OptionID)
+			}
+		}
+		shuffle(seed, optionStream, q.QuestionID, free)
+		if order.OptionIDs == nil {
+			order.OptionIDs = make(map[uint][]uint)
+		}
+		order.OptionIDs[q.QuestionID] = append(free, pinned...)
+	}
+	return order, nil
+}
+
+func samePage(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// shuffle shuffles ids with a generator drawn from the seed, the stream and the ID of
+// what is being shuffled
+func shuffle(seed int64, stream uint64, id uint, ids []uint) {
+	rng := rand.New(rand.NewPCG(uint64(seed), stream<<32|uint64(id)))
+	rng.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rovin99/Survey-Platform/ParticipantsManagementService/models"
+	"gorm.io/datatypes"
+)
+
+// testVersion has a shuffled section with pages 1 and 2 and a fixed section with page 3.
+// Question 1 shuffles its options and pins option 105 last.
+func testVersion(t *testing.T) *models.SurveyVersion {
+	t.Helper()
+	page := func(id uint) *uint { return &id }
+	snapshot := models.SurveySnapshot{
+		Sections: []models.SnapshotSection{
+			{SectionID: 1, RandomizeQuestions: true, Pages: []models.SnapshotPage{{PageID: 1}, {PageID: 2}}},
+			{SectionID: 2, Pages: []models.SnapshotPage{{PageID: 3}}},
+		},
+	}
+	for id := uint(1); id <= 12; id++ {
+		q := models.SnapshotQuestion{QuestionID: id, PageID: page((id-1)/4 + 1)}
+		if id == 1 {
+			q.RandomizeOptions = true
+			for option := uint(101); option <= 105; option++ {
+				q.Options = append(q.Options, models.SnapshotOption{OptionID: option, PinLast: option == 105})
+			}
+		}
+		snapshot.Questions = append(snapshot.Questions, q)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.SurveyVersion{VersionID: 1, Snapshot: datatypes.JSON(data)}
+}
+
+func TestPresentationOrderPerSeed(t *testing.T) {
+	version := testVersion(t)
+
+	tests := []struct {
+		name string
+		seed int64
+	}{
+		{name: "zero", seed: 0},
+		{name: "small", seed: 42},
+		{name: "negative", seed: -7},
+		{name: "large", seed: 1<<62 + 12345},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := presentationOrder(version, tt.seed)
+			if err != nil {
+				t.Fatalf("presentationOrder: %v", err)
+			}
+			again, err := presentationOrder(version, tt.seed)
+			if err != nil {
+				t.Fatalf("presentationOrder: %v", err)
+			}
+			if !reflect.DeepEqual(first, again) {
+				t.Fatalf("seed %d gave %v, then %v", tt.seed, first, again)
+			}
+
+			// Questions stay on their page, and pages keep their order
+			pages := [][]uint{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}
+			for i, want := range pages {
+				got := append([]uint(nil), first.QuestionIDs[i*4:i*4+4]...)
+				if i == 2 && !reflect.DeepEqual(got, want) {
+					t.Errorf("unshuffled page 3 = %v, want %v", got, want)
+				}
+				sort.Slice(got, func(a, b int) bool { return got[a] < got[b] })
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("page %d holds %v, want %v", i+1, got, want)
+				}
+			}
+
+			options := first.OptionIDs[1]
+			if len(options) != 5 || options[4] != 105 {
+				t.Errorf("options of question 1 = %v, want option 105 pinned last", options)
+			}
+			if len(first.OptionIDs) != 1 {
+				t.Errorf("OptionIDs = %v, want only question 1", first.OptionIDs)
+			}
+		})
+	}
+}
+
+func TestPresentationOrderVariesBySeed(t *testing.T) {
+	version := testVersion(t)
+	seen := make(map[string]bool)
+	for seed := int64(1); seed <= 20; seed++ {
+		order, err := presentationOrder(version, seed)
+		if err != nil {
+			t.Fatalf("presentationOrder: %v", err)
+		}
+		key, _ := json.Marshal(order)
+		seen[string(key)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("20 seeds gave %d distinct orders, want several", len(seen))
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json" // Needed for draft content handling
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
 
 	"errors"
 
@@ -17,7 +20,18 @@ import (
 type StartResumeResponse struct {
 	Session *models.SurveySession          `json:"session"`
 	Draft   *models.ParticipantSurveyDraft `json:"draft"` // Include existing draft content
-	Survey  *MockSurvey                    `json:"survey"`
+	Survey  *SessionSurvey                 `json:"survey"`
+	// SurveyVersionID and VersionNumber name the published version the session is pinned
+	// to, if any. The snapshot itself holds correct answers and branching rules, so it is
+	// not sent.
+	SurveyVersionID uint `json:"survey_version_id,omitempty"`
+	VersionNumber   int  `json:"version_number,omitempty"`
+	// Locale is the one negotiated for the session, and SupportedLocales the ones the
+	// participant may switch to. Translations maps the resource IDs of the survey's and the
+	// version's strings to their text in Locale; strings without one are shown as written.
+	Locale           string            `json:"locale,omitempty"`
+	SupportedLocales []string          `json:"supported_locales,omitempty"`
+	Translations     map[string]string `json:"translations,omitempty"`
 }
 
 // DTO for submitting final answers
@@ -27,23 +41,40 @@ type FinalAnswerInput struct {
 }
 
 type ParticipantService interface {
-	StartOrResumeSurvey(ctx context.Context, surveyID, participantID uint) (*StartResumeResponse, error)
+	StartOrResumeSurvey(ctx context.Context, surveyID, participantID uint, pref LocalePreference) (*StartResumeResponse, error)
 	SaveDraft(ctx context.Context, sessionID uint, lastQuestionID *uint, draftContent map[string]interface{}) error
 	SubmitSurvey(ctx context.Context, sessionID uint, finalAnswers []FinalAnswerInput) error
 	GetSession(ctx context.Context, surveyID, participantID uint) (*models.SurveySession, error)
 	GetDraft(ctx context.Context, sessionID uint) (*models.ParticipantSurveyDraft, error)
+	GetQuestion(ctx context.Context, sessionID, participantID, questionID uint) (map[string]interface{}, error)
+	NextQuestion(ctx context.Context, sessionID, participantID, currentQuestionID uint) (*NextQuestionResult, error)
 }
 
 type participantServiceImpl struct {
-	repo repository.ParticipantRepository
-	// Potentially add clients for other services (e.g., survey service client) if needed
+	repo   repository.ParticipantRepository
+	survey SurveyClient // Evaluates branching and checks answers over the session's pinned version
 }
 
-func NewParticipantService(repo repository.ParticipantRepository) ParticipantService {
-	return &participantServiceImpl{repo: repo}
+func NewParticipantService(repo repository.ParticipantRepository, survey SurveyClient) ParticipantService {
+	return &participantServiceImpl{repo: repo, survey: survey}
 }
 
-func (s *participantServiceImpl) StartOrResumeSurvey(ctx context.Context, surveyID, participantID uint) (*StartResumeResponse, error) {
+// ErrSurveyNotOpen is returned when a participant tries to take a survey that is not OPEN
+var ErrSurveyNotOpen = errors.New("survey is not open")
+
+// ErrQuestionNotFound is returned when the survey version a session is pinned to has no
+// such question
+var ErrQuestionNotFound = errors.New("question not found")
+
+func (s *participantServiceImpl) StartOrResumeSurvey(ctx context.Context, surveyID, participantID uint, pref LocalePreference) (*StartResumeResponse, error) {
+	survey, err := s.repo.GetSurvey(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if !survey.IsOpen(time.Now()) {
+		return nil, fmt.Errorf("%w: survey %d is %s", ErrSurveyNotOpen, surveyID, survey.Status)
+	}
+
 	session, err := s.repo.FindOrCreateSession(ctx, surveyID, participantID)
 	if err != nil {
 		return nil, err
@@ -62,58 +93,172 @@ func (s *participantServiceImpl) StartOrResumeSurvey(ctx context.Context, survey
 		// We could log this but it's normal behavior
 	}
 
-	// Create mock survey data for development/testing
-	// In a real implementation, this would be fetched from the Survey Management Service
-	mockSurvey := &MockSurvey{
-		ID:          surveyID,
-		Title:       "Sample Survey " + fmt.Sprintf("%d", surveyID),
-		Description: "This is a sample survey for testing",
-		Questions: []MockQuestion{
-			{
-				ID:             1,
-				QuestionText:   "What is your favorite color?",
-				QuestionType:   "multiple-choice",
-				Mandatory:      true,
-				CorrectAnswers: "Red,Blue,Green,Yellow",
-			},
-			{
-				ID:           2,
-				QuestionText: "Please provide some feedback",
-				QuestionType: "text",
-				Mandatory:    false,
-			},
-			{
-				ID:           3,
-				QuestionText: "Rate your experience from 1-5",
-				QuestionType: "rating",
-				Mandatory:    true,
-			},
-		},
-	}
-
-	return &StartResumeResponse{
+	var version *models.SurveyVersion
+	if session.SurveyVersionID != 0 {
+		version, err = s.repo.GetSurveyVersion(ctx, session.SurveyVersionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	locale, err := negotiateLocale(survey, session, pref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the order on the first start; resuming shows the recorded order
+	changed := false // Whether the session needs saving
+	if version != nil && len(session.PresentationOrder) == 0 {
+		// Sessions created before seeds were recorded have none; without one every such
+		// session would get the same order
+		for session.RandomSeed == 0 {
+			session.RandomSeed = rand.Int64()
+		}
+		order, err := presentationOrder(version, session.RandomSeed)
+		if err != nil {
+			return nil, err
+		}
+		orderJSON, err := json.Marshal(order)
+		if err != nil {
+			return nil, err
+		}
+		session.PresentationOrder = datatypes.JSON(orderJSON)
+		changed = true
+	}
+	if session.Locale != locale {
+		session.Locale = locale
+		changed = true
+	}
+	if changed {
+		if err := s.repo.UpdateSession(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+
+	localizer, err := newLocalizer(ctx, s.repo, surveyID, locale)
+	if err != nil {
+		return nil, err
+	}
+	var questions []models.SnapshotQuestion
+	if version != nil {
+		if questions, err = version.Questions(); err != nil {
+			return nil, err
+		}
+	}
+	translations := localizer.translated(survey, questions)
+	if len(translations) == 0 {
+		translations = nil
+	}
+
+	presented, err := presentSurvey(survey, session, localizer.questions(questions))
+	if err != nil {
+		return nil, err
+	}
+	presented.Title = localizer.text("survey.title", survey.Title)
+	presented.Description = localizer.text("survey.description", survey.Description)
+
+	response := &StartResumeResponse{
 		Session: session,
 		Draft:   draft,
-		Survey:  mockSurvey,
-	}, nil
+		Survey:  presented,
+
+		Locale:           locale,
+		SupportedLocales: survey.Locales(),
+		Translations:     translations,
+	}
+	if version != nil {
+		response.SurveyVersionID = version.VersionID
+		response.VersionNumber = version.VersionNumber
+	}
+	return response, nil
 }
 
-// Mock survey types for development/testing
-type MockSurvey struct {
-	ID                uint           `json:"id"`
-	Title             string         `json:"title"`
-	Description       string         `json:"description"`
-	Questions         []MockQuestion `json:"questions"`
-	IsSelfRecruitment bool           `json:"is_self_recruitment"`
-	Status            string         `json:"status"`
+// SessionSurvey is a survey as a session shows it: its questions in the order recorded
+// for the session, translated into the session's locale. Answers are piped into their
+// text when each question is fetched.
+type SessionSurvey struct {
+	ID          uint              `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Status      string            `json:"status"`
+	Questions   []SessionQuestion `json:"questions"`
 }
 
-type MockQuestion struct {
-	ID             uint   `json:"id"`
-	QuestionText   string `json:"question_text"`
-	QuestionType   string `json:"question_type"`
-	Mandatory      bool   `json:"mandatory"`
-	CorrectAnswers string `json:"correct_answers,omitempty"`
+// SessionQuestion is a question of a SessionSurvey, with its options in the session's
+// order. Correct answers and code tests are left out.
+type SessionQuestion struct {
+	ID           uint                    `json:"id"`
+	QuestionKey  string                  `json:"question_key,omitempty"`
+	QuestionText string                  `json:"question_text"`
+	QuestionType string                  `json:"question_type"`
+	Mandatory    bool                    `json:"mandatory"`
+	PageID       *uint                   `json:"page_id,omitempty"`
+	Options      []models.SnapshotOption `json:"options,omitempty"`
+}
+
+// presentSurvey lays out the survey's version questions in the session's recorded
+// order. Sessions without a pinned version have no questions.
+func presentSurvey(survey *models.Survey, session *models.SurveySession, questions []models.SnapshotQuestion) (*SessionSurvey, error) {
+	presented := &SessionSurvey{
+		ID:          survey.SurveyID,
+		Title:       survey.Title,
+		Description: survey.Description,
+		Status:      survey.Status,
+		Questions:   make([]SessionQuestion, 0, len(questions)),
+	}
+
+	var order models.PresentationOrder
+	if len(session.PresentationOrder) > 0 {
+		if err := json.Unmarshal(session.PresentationOrder, &order); err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]models.SnapshotQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.QuestionID] = q
+	}
+	ordered := make([]models.SnapshotQuestion, 0, len(questions))
+	for _, id := range order.QuestionIDs {
+		if q, ok := byID[id]; ok {
+			ordered = append(ordered, q)
+			delete(byID, id)
+		}
+	}
+	// Anything the recorded order lacks keeps its authored place at the end
+	for _, q := range questions {
+		if _, ok := byID[q.QuestionID]; ok {
+			ordered = append(ordered, q)
+		}
+	}
+
+	for _, q := range ordered {
+		options := q.Options
+		if ids, ok := order.OptionIDs[q.QuestionID]; ok && len(ids) == len(q.Options) {
+			byOptionID := make(map[uint]models.SnapshotOption, len(q.Options))
+			for _, option := range q.Options {
+				byOptionID[option.OptionID] = option
+			}
+			options = make([]models.SnapshotOption, 0, len(ids))
+			for _, id := range ids {
+				if option, ok := byOptionID[id]; ok {
+					options = append(options, option)
+				}
+			}
+			if len(options) != len(q.Options) {
+				options = q.Options
+			}
+		}
+		presented.Questions = append(presented.Questions, SessionQuestion{
+			ID:           q.QuestionID,
+			QuestionKey:  q.QuestionKey,
+			QuestionText: q.QuestionText,
+			QuestionType: q.QuestionType,
+			Mandatory:    q.Mandatory,
+			PageID:       q.PageID,
+			Options:      options,
+		})
+	}
+	return presented, nil
 }
 
 func (s *participantServiceImpl) SaveDraft(ctx context.Context, sessionID uint, lastQuestionID *uint, draftAnswers map[string]interface{}) error {
@@ -146,7 +291,14 @@ func (s *participantServiceImpl) SubmitSurvey(ctx context.Context, sessionID uin
 		return errors.New("survey session is not in progress")
 	}
 
-	// 2. Prepare final answers for batch creation
+	// The Survey Management Service checks the answers against the version the session is
+	// pinned to, with the rules it applies to answers it stores itself
+	if err := s.survey.ValidateSubmission(ctx, sessionID, finalAnswersInput); err != nil {
+		return err
+	}
+
+	// 2. Prepare final answers for batch creation. CODE answers are stored unscored; the
+	// Survey Management Service runs them against their tests in the background.
 	answersToCreate := make([]models.Answer, 0, len(finalAnswersInput))
 	for _, input := range finalAnswersInput {
 		// Marshal individual response data to JSON for the DB
@@ -163,6 +315,7 @@ func (s *participantServiceImpl) SubmitSurvey(ctx context.Context, sessionID uin
 			SessionID:    sessionID,
 			QuestionID:   input.QuestionID,
 			ResponseData: datatypes.JSON(responseDataJSON),
+			Locale:       session.Locale, // The locale the questions were shown in
 		})
 	}
 
@@ -226,3 +379,139 @@ func (s *participantServiceImpl) GetDraft(ctx context.Context, sessionID uint) (
 	}
 	return draft, nil
 }
+
+// GetQuestion serves a question of the session's survey version as the participant sees
+// it: translated into the session's locale, with earlier answers saved in the draft piped
+// into its text and option texts, its options in the session's order, and correct answers
+// left out. Snapshots hold no code tests. Sessions of other participants look not found.
+func (s *participantServiceImpl) GetQuestion(ctx context.Context, sessionID, participantID, questionID uint) (map[string]interface{}, error) {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ParticipantID != participantID {
+		return nil, repository.ErrSessionNotFound
+	}
+	if session.SurveyVersionID == 0 {
+		return nil, fmt.Errorf("%w: session %d has no published survey version", ErrQuestionNotFound, sessionID)
+	}
+
+	version, err := s.repo.GetSurveyVersion(ctx, session.SurveyVersionID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := version.Content()
+	if err != nil {
+		return nil, err
+	}
+	// The question is served as published, so read it untyped alongside the typed view
+	var raw struct {
+		Questions []map[string]interface{} `json:"questions"`
+	}
+	if err := json.Unmarshal(version.Snapshot, &raw); err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, q := range snapshot.Questions {
+		if q.QuestionID == questionID {
+			index = i
+			break
+		}
+	}
+	if index < 0 || index >= len(raw.Questions) {
+		return nil, fmt.Errorf("%w: question %d is not in survey version %d", ErrQuestionNotFound, questionID, version.VersionID)
+	}
+	question := raw.Questions[index]
+
+	// Translate into the session's locale before piping, so piped choices are translated too
+	localizer, err := newLocalizer(ctx, s.repo, session.SurveyID, session.Locale)
+	if err != nil {
+		return nil, err
+	}
+	questions := localizer.questions(snapshot.Questions)
+
+	draft, err := s.repo.GetDraftBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	piper, err := newAnswerPiper(questions, draft)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := question["question_text"].(string); ok {
+		question["question_text"] = piper.pipe(questions[index].QuestionText)
+	}
+	options, _ := question["options"].([]interface{})
+	for i, option := range options {
+		if o, ok := option.(map[string]interface{}); ok {
+			if text, ok := o["option_text"].(string); ok {
+				if len(options) == len(questions[index].Options) {
+					text = questions[index].Options[i].OptionText
+				}
+				o["option_text"] = piper.pipe(text)
+			}
+		}
+	}
+	if session.Locale != "" {
+		question["locale"] = session.Locale
+	}
+
+	// Show the options in the order recorded when the session started
+	if len(session.PresentationOrder) > 0 && len(options) == len(snapshot.Questions[index].Options) {
+		var order models.PresentationOrder
+		if err := json.Unmarshal(session.PresentationOrder, &order); err != nil {
+			return nil, err
+		}
+		if ids, ok := order.OptionIDs[questionID]; ok {
+			byID := make(map[uint]interface{}, len(options))
+			for i, option := range snapshot.Questions[index].Options {
+				byID[option.OptionID] = options[i]
+			}
+			ordered := make([]interface{}, 0, len(options))
+			for _, id := range ids {
+				if option, ok := byID[id]; ok {
+					ordered = append(ordered, option)
+				}
+			}
+			if len(ordered) == len(options) {
+				question["options"] = ordered
+			}
+		}
+	}
+
+	delete(question, "correct_answers")
+	return question, nil
+}
+
+// NextQuestion asks the Survey Management Service which question follows
+// currentQuestionID, evaluating the branching rules of the session's pinned version
+// against the answers saved in the draft. Sessions of other participants look not found.
+func (s *participantServiceImpl) NextQuestion(ctx context.Context, sessionID, participantID, currentQuestionID uint) (*NextQuestionResult, error) {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ParticipantID != participantID {
+		return nil, repository.ErrSessionNotFound
+	}
+
+	answers := map[string]interface{}{}
+	draft, err := s.repo.GetDraftBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if draft != nil && len(draft.DraftAnswersContent) > 0 {
+		if err := json.Unmarshal(draft.DraftAnswersContent, &answers); err != nil {
+			return nil, fmt.Errorf("reading draft of session %d: %w", sessionID, err)
+		}
+	}
+
+	next, err := s.survey.NextQuestion(ctx, session.SurveyID, sessionID, currentQuestionID, answers)
+	var serviceErr *SurveyServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %s", ErrQuestionNotFound, serviceErr.Message)
+	}
+	return next, err
+}
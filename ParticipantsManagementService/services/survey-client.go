@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SurveyClient calls the Survey Management Service, which owns the branching rules and
+// answer checks of the survey versions sessions are pinned to
+type SurveyClient interface {
+	NextQuestion(ctx context.Context, surveyID, sessionID, currentQuestionID uint, answers map[string]interface{}) (*NextQuestionResult, error)
+	ValidateSubmission(ctx context.Context, sessionID uint, answers []FinalAnswerInput) error
+}
+
+// NextQuestionResult is the question that follows the current one on the participant's
+// path. NextQuestionID is 0 when the survey ends.
+type NextQuestionResult struct {
+	NextQuestionID uint `json:"next_question_id"`
+	EndOfSurvey    bool `json:"end_of_survey"`
+}
+
+// AnswerError is an answer the Survey Management Service rejected, with a message meant
+// to be shown next to the question
+type AnswerError struct {
+	QuestionID uint   `json:"question_id"`
+	Message    string `json:"message"`
+}
+
+// AnswerValidationError is returned by SubmitSurvey when answers do not fit their
+// questions, or mandatory questions are left unanswered. Nothing is saved.
+type AnswerValidationError struct {
+	Errors []AnswerError
+}
+
+func (e *AnswerValidationError) Error() string {
+	if len(e.Errors) == 0 {
+		return "invalid answers"
+	}
+	first := e.Errors[0]
+	msg := fmt.Sprintf("invalid answer to question %d: %s", first.QuestionID, first.Message)
+	if len(e.Errors) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Errors)-1)
+	}
+	return msg
+}
+
+// SurveyServiceError is an error response from the Survey Management Service
+type SurveyServiceError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    json.RawMessage
+}
+
+func (e *SurveyServiceError) Error() string {
+	return fmt.Sprintf("survey service returned %d: %s", e.StatusCode, e.Message)
+}
+
+type authorizationKey struct{}
+
+// WithAuthorization stores the participant's Authorization header in ctx, so calls to the
+// Survey Management Service are made on their behalf
+func WithAuthorization(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, authorizationKey{}, header)
+}
+
+type httpSurveyClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewSurveyClient returns a SurveyClient for the Survey Management Service at baseURL,
+// e.g. http://localhost:3001
+func NewSurveyClient(baseURL string) SurveyClient {
+	return &httpSurveyClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// surveyServiceResponse is the envelope every Survey Management Service response is sent in
+type surveyServiceResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Message string          `json:"message"`
+		Code    string          `json:"code"`
+		Details json.RawMessage `json:"details"`
+	} `json:"error"`
+}
+
+func (c *httpSurveyClient) NextQuestion(ctx context.Context, surveyID, sessionID, currentQuestionID uint, answers map[string]interface{}) (*NextQuestionResult, error) {
+	body := map[string]interface{}{
+		"session_id":          sessionID,
+		"current_question_id": currentQuestionID,
+		"answers":             answers,
+	}
+	var result NextQuestionResult
+	if err := c.post(ctx, fmt.Sprintf("/api/surveys/%d/next-question", surveyID), body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateSubmission checks a session's final answers with the same rules the Survey
+// Management Service applies to answers it stores, returning *AnswerValidationError for
+// answers it rejects
+func (c *httpSurveyClient) ValidateSubmission(ctx context.Context, sessionID uint, answers []FinalAnswerInput) error {
+	type answer struct {
+		QuestionID   uint        `json:"question_id"`
+		ResponseData interface{} `json:"response_data"`
+	}
+	body := struct {
+		SessionID uint     `json:"session_id"`
+		Answers   []answer `json:"answers"`
+	}{SessionID: sessionID, Answers: make([]answer, 0, len(answers))}
+	for _, a := range answers {
+		body.Answers = append(body.Answers, answer{QuestionID: a.QuestionID, ResponseData: a.ResponseData})
+	}
+
+	err := c.post(ctx, "/api/answers/validate", body, nil)
+	var serviceErr *SurveyServiceError
+	if errors.As(err, &serviceErr) && serviceErr.Code == "INVALID_ANSWER" {
+		invalid := &AnswerValidationError{}
+		if err := json.Unmarshal(serviceErr.Details, &invalid.Errors); err != nil {
+			return fmt.Errorf("reading rejected answers: %w", err)
+		}
+		return invalid
+	}
+	return err
+}
+
+// post sends body as JSON and decodes the data of the response into out. Error responses
+// are returned as *SurveyServiceError.
+func (c *httpSurveyClient) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth, _ := ctx.Value(authorizationKey{}).(string); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling survey service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope surveyServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("reading survey service response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 || !envelope.Success {
+		e := &SurveyServiceError{StatusCode: resp.StatusCode, Message: envelope.Message}
+		if envelope.Error != nil {
+			e.Code = envelope.Error.Code
+			e.Message = envelope.Error.Message
+			e.Details = envelope.Error.Details
+		}
+		return e
+	}
+	if out == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -23,6 +23,10 @@ func AuthMiddleware() fiber.Handler {
 		}
 
 		authHeader := c.Get("Authorization")
+		if authHeader == "" && isWebSocketUpgrade(c) && c.Query("access_token") != "" {
+			// Browsers cannot set headers on a WebSocket handshake
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			return response.Unauthorized(c, "Missing or malformed JWT")
 		}
@@ -84,6 +88,10 @@ func AuthMiddleware() fiber.Handler {
 	}
 }
 
+func isWebSocketUpgrade(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket")
+}
+
 // ConductorRoleMiddleware ensures the user has "Conducting" role
 func ConductorRoleMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
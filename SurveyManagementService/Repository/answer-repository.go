@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 	"gorm.io/gorm"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 )
@@ -10,6 +11,10 @@ type AnswerRepository interface {
 	Create(ctx context.Context, answer *models.Answer) error
 	GetBySessionID(ctx context.Context, sessionID uint) ([]models.Answer, error)
 	GetByQuestionID(ctx context.Context, questionID uint) ([]models.Answer, error)
+	GetByLineageID(ctx context.Context, lineageID uint) ([]models.Answer, error)
+	ClaimUnevaluatedCodeAnswers(ctx context.Context, claim string, staleBefore time.Time, limit int) ([]models.Answer, error)
+	SetEvaluation(ctx context.Context, answerID uint, evaluation string) (bool, error)
+	ReleaseClaim(ctx context.Context, answerID uint) error
 }
 
 type answerRepository struct {
@@ -35,3 +40,51 @@ func (r *answerRepository) GetByQuestionID(ctx context.Context, questionID uint)
 	err := r.db.WithContext(ctx).Where("question_id = ?", questionID).Find(&answers).Error
 	return answers, err
 }
+
+// GetByLineageID returns the answers to every published version of a question
+func (r *answerRepository) GetByLineageID(ctx context.Context, lineageID uint) ([]models.Answer, error) {
+	var answers []models.Answer
+	err := r.db.WithContext(ctx).
+		Joins("JOIN questions ON questions.question_id = answers.question_id").
+		Where("questions.lineage_id = ?", lineageID).
+		Order("answers.created_at").
+		Find(&answers).Error
+	return answers, err
+}
+
+// pendingEvaluation matches evaluations that are a scorer's claim; keep in sync with
+// service.CodeEvaluationPending
+const pendingEvaluation = "NULLIF(answers.evaluation, '')::jsonb ->> 'status' = 'PENDING'"
+
+// ClaimUnevaluatedCodeAnswers stores claim as the evaluation of up to limit answers, in
+// ID order, that submit code to a CODE question and have no evaluation yet, or a claim
+// made before staleBefore, and returns them. Rows another scorer is claiming at the same
+// time are skipped, so each answer goes to one scorer.
+func (r *answerRepository) ClaimUnevaluatedCodeAnswers(ctx context.Context, claim string, staleBefore time.Time, limit int) ([]models.Answer, error) {
+	var answers []models.Answer
+	err := r.db.WithContext(ctx).Raw(`UPDATE answers SET evaluation = ? WHERE answer_id IN (
+		SELECT answers.answer_id FROM answers JOIN questions ON questions.question_id = answers.question_id
+		WHERE questions.question_type = 'CODE'
+		AND COALESCE(answers.response_data::jsonb ->> 'code', '') <> ''
+		AND (COALESCE(answers.evaluation, '') = '' OR (`+pendingEvaluation+` AND (NULLIF(answers.evaluation, '')::jsonb ->> 'claimed_at')::timestamptz < ?))
+		ORDER BY answers.answer_id LIMIT ?
+		FOR UPDATE OF answers SKIP LOCKED
+	) RETURNING *`, claim, staleBefore, limit).Scan(&answers).Error
+	return answers, err
+}
+
+// SetEvaluation replaces a scorer's claim on an answer with its evaluation, and reports
+// whether the answer was still claimed
+func (r *answerRepository) SetEvaluation(ctx context.Context, answerID uint, evaluation string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Answer{}).
+		Where("answer_id = ? AND "+pendingEvaluation, answerID).
+		Update("evaluation", evaluation)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseClaim clears a scorer's claim on an answer, so the next claim picks it up again
+func (r *answerRepository) ReleaseClaim(ctx context.Context, answerID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Answer{}).
+		Where("answer_id = ? AND "+pendingEvaluation, answerID).
+		Update("evaluation", "").Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+)
+
+type BranchingRuleRepository interface {
+	GetBySurveyID(ctx context.Context, surveyID uint) ([]models.BranchingRule, error)
+	GetBySourceQuestionID(ctx context.Context, questionID uint) ([]models.BranchingRule, error)
+	ReplaceForQuestion(ctx context.Context, questionID uint, rules []models.BranchingRule) error
+	DeleteBySurveyIDWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+	CreateWithTx(ctx context.Context, tx *gorm.DB, rule *models.BranchingRule) error
+}
+
+type branchingRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewBranchingRuleRepository(db *gorm.DB) BranchingRuleRepository {
+	return &branchingRuleRepository{db: db}
+}
+
+// GetBySurveyID returns every rule of a survey in evaluation order
+func (r *branchingRuleRepository) GetBySurveyID(ctx context.Context, surveyID uint) ([]models.BranchingRule, error) {
+	var rules []models.BranchingRule
+	err := r.db.WithContext(ctx).
+		Where("survey_id = ?", surveyID).
+		Order("source_question_id, priority, rule_id").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *branchingRuleRepository) GetBySourceQuestionID(ctx context.Context, questionID uint) ([]models.BranchingRule, error) {
+	var rules []models.BranchingRule
+	err := r.db.WithContext(ctx).
+		Where("source_question_id = ?", questionID).
+		Order("priority, rule_id").
+		Find(&rules).Error
+	return rules, err
+}
+
+// ReplaceForQuestion swaps all rules leaving a question for the given set in one transaction
+func (r *branchingRuleRepository) ReplaceForQuestion(ctx context.Context, questionID uint, rules []models.BranchingRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.BranchingRule{}, "source_question_id = ?", questionID).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+func (r *branchingRuleRepository) DeleteBySurveyIDWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	return tx.WithContext(ctx).Delete(&models.BranchingRule{}, "survey_id = ?", surveyID).Error
+}
+
+func (r *branchingRuleRepository) CreateWithTx(ctx context.Context, tx *gorm.DB, rule *models.BranchingRule) error {
+	return tx.WithContext(ctx).Create(rule).Error
+}
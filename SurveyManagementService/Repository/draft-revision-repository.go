@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+)
+
+type DraftRevisionRepository interface {
+	CreateWithTx(ctx context.Context, tx *gorm.DB, revision *models.SurveyDraftRevision) error
+	LatestWithTx(ctx context.Context, tx *gorm.DB, draftID uint) (*models.SurveyDraftRevision, error)
+	ListByDraftID(ctx context.Context, draftID uint) ([]models.SurveyDraftRevision, error)
+	GetByNumber(ctx context.Context, draftID uint, number int) (*models.SurveyDraftRevision, error)
+}
+
+type draftRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewDraftRevisionRepository(db *gorm.DB) DraftRevisionRepository {
+	return &draftRevisionRepository{db: db}
+}
+
+func (r *draftRevisionRepository) CreateWithTx(ctx context.Context, tx *gorm.DB, revision *models.SurveyDraftRevision) error {
+	return tx.WithContext(ctx).Create(revision).Error
+}
+
+// LatestWithTx returns the draft's newest revision, or gorm.ErrRecordNotFound if it has none
+func (r *draftRevisionRepository) LatestWithTx(ctx context.Context, tx *gorm.DB, draftID uint) (*models.SurveyDraftRevision, error) {
+	var revision models.SurveyDraftRevision
+	err := tx.WithContext(ctx).
+		Where("draft_id = ?", draftID).
+		Order("revision_number DESC").
+		First(&revision).Error
+	return &revision, err
+}
+
+// ListByDraftID returns the draft's revisions newest first, without their content
+func (r *draftRevisionRepository) ListByDraftID(ctx context.Context, draftID uint) ([]models.SurveyDraftRevision, error) {
+	var revisions []models.SurveyDraftRevision
+	err := r.db.WithContext(ctx).
+		Select("revision_id", "draft_id", "revision_number", "author_id", "content_hash", "last_edited_question", "created_at").
+		Where("draft_id = ?", draftID).
+		Order("revision_number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *draftRevisionRepository) GetByNumber(ctx context.Context, draftID uint, number int) (*models.SurveyDraftRevision, error) {
+	var revision models.SurveyDraftRevision
+	err := r.db.WithContext(ctx).
+		Where("draft_id = ? AND revision_number = ?", draftID, number).
+		First(&revision).Error
+	return &revision, err
+}
@@ -14,6 +14,8 @@ type OptionRepository interface {
 	Update(ctx context.Context, option *models.Option) error
 	Delete(ctx context.Context, id uint) error
 	BatchCreate(ctx context.Context, options []models.Option) error
+	Reorder(ctx context.Context, questionID uint, optionIDs []uint) error
+	GetMediaFiles(ctx context.Context, ids []uint) ([]models.SurveyMediaFile, error)
 }
 
 type optionRepository struct {
@@ -24,30 +26,94 @@ func NewOptionRepository(db *gorm.DB) OptionRepository {
 	return &optionRepository{db: db}
 }
 
+// Create adds the option after the question's other options
 func (r *optionRepository) Create(ctx context.Context, option *models.Option) error {
-	return r.db.WithContext(ctx).Create(option).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		position, err := nextOptionPositionWithTx(tx, option.QuestionID)
+		if err != nil {
+			return err
+		}
+		option.Position = position
+		return tx.Create(option).Error
+	})
 }
 
 func (r *optionRepository) GetByID(ctx context.Context, id uint) (*models.Option, error) {
 	var option models.Option
 	err := r.db.WithContext(ctx).First(&option, id).Error
-	return &option, err
+	if err != nil {
+		return &option, err
+	}
+	return &option, loadOptionMedia(r.db.WithContext(ctx), []*models.Option{&option})
 }
 
 func (r *optionRepository) GetByQuestionID(ctx context.Context, questionID uint) ([]models.Option, error) {
 	var options []models.Option
-	err := r.db.WithContext(ctx).Where("question_id = ?", questionID).Find(&options).Error
-	return options, err
+	err := r.db.WithContext(ctx).Where("question_id = ?", questionID).Order("position, option_id").Find(&options).Error
+	if err != nil {
+		return options, err
+	}
+	loaded := make([]*models.Option, len(options))
+	for i := range options {
+		loaded[i] = &options[i]
+	}
+	return options, loadOptionMedia(r.db.WithContext(ctx), loaded)
 }
 
+// Update saves the option; its position only changes through Reorder, and its key never
 func (r *optionRepository) Update(ctx context.Context, option *models.Option) error {
-	return r.db.WithContext(ctx).Save(option).Error
+	return r.db.WithContext(ctx).Omit("position", "option_key").Save(option).Error
 }
 
 func (r *optionRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.Option{}, id).Error
 }
 
+// BatchCreate adds the options after their questions' other options, in the order given
 func (r *optionRepository) BatchCreate(ctx context.Context, options []models.Option) error {
-	return r.db.WithContext(ctx).CreateInBatches(options, len(options)).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		next := make(map[uint]int)
+		for i := range options {
+			position, ok := next[options[i].QuestionID]
+			if !ok {
+				var err error
+				if position, err = nextOptionPositionWithTx(tx, options[i].QuestionID); err != nil {
+					return err
+				}
+			}
+			options[i].Position = position
+			next[options[i].QuestionID] = position + 1
+		}
+		return tx.CreateInBatches(options, len(options)).Error
+	})
+}
+
+// Reorder gives the question's options the positions of their IDs in optionIDs, which
+// must list each of them once
+func (r *optionRepository) Reorder(ctx context.Context, questionID uint, optionIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range optionIDs {
+			if err := tx.Model(&models.Option{}).Where("option_id = ? AND question_id = ?", id, questionID).UpdateColumn("position", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func nextOptionPositionWithTx(tx *gorm.DB, questionID uint) (int, error) {
+	var position int
+	err := tx.Model(&models.Option{}).Where("question_id = ?", questionID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error
+	return position, err
+}
+
+// GetMediaFiles returns the media files with the given IDs that exist, for checking the
+// media options refer to
+func (r *optionRepository) GetMediaFiles(ctx context.Context, ids []uint) ([]models.SurveyMediaFile, error) {
+	var mediaFiles []models.SurveyMediaFile
+	if len(ids) == 0 {
+		return mediaFiles, nil
+	}
+	err := r.db.WithContext(ctx).Where("media_id IN ?", ids).Find(&mediaFiles).Error
+	return mediaFiles, err
 }
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+)
+
+// BankQuestionSearchVector is the full-text document of a bank question. The GIN index
+// created at migration time uses the same expression, so the two must stay in sync.
+const BankQuestionSearchVector = "to_tsvector('english', coalesce(question_text, ''))"
+
+// BankQuestionFilter selects the bank questions a conductor can use
+type BankQuestionFilter struct {
+	ConductorID  uint
+	Scope        string   // PERSONAL or ORGANISATION; empty for both
+	Tags         []string // Bank questions must carry all of them
+	QuestionType string
+	Search       string
+	Limit        int
+	Offset       int
+}
+
+type QuestionBankRepository interface {
+	Create(ctx context.Context, question *models.BankQuestion) error
+	GetByID(ctx context.Context, id uint) (*models.BankQuestion, error)
+	Update(ctx context.Context, question *models.BankQuestion) error
+	Delete(ctx context.Context, id uint) error
+	Search(ctx context.Context, filter BankQuestionFilter) ([]models.BankQuestion, error)
+	GetInsertedQuestions(ctx context.Context, id, conductorID uint) ([]models.Question, error)
+}
+
+type questionBankRepository struct {
+	db *gorm.DB
+}
+
+func NewQuestionBankRepository(db *gorm.DB) QuestionBankRepository {
+	return &questionBankRepository{db: db}
+}
+
+func (r *questionBankRepository) Create(ctx context.Context, question *models.BankQuestion) error {
+	return r.db.WithContext(ctx).Create(question).Error
+}
+
+func (r *questionBankRepository) GetByID(ctx context.Context, id uint) (*models.BankQuestion, error) {
+	var question models.BankQuestion
+	err := r.db.WithContext(ctx).First(&question, id).Error
+	return &question, err
+}
+
+// Update saves the bank question's scope, tags and content; its owner and origin are fixed
+func (r *questionBankRepository) Update(ctx context.Context, question *models.BankQuestion) error {
+	return r.db.WithContext(ctx).Model(question).
+		Select("scope", "question_text", "question_type", "tags", "content", "updated_at").
+		Updates(question).Error
+}
+
+// Delete soft-deletes the bank question; questions inserted from it keep their origin
+func (r *questionBankRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.BankQuestion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Search returns the conductor's personal bank questions and every organisation one that
+// match filter, most recently updated first
+func (r *questionBankRepository) Search(ctx context.Context, filter BankQuestionFilter) ([]models.BankQuestion, error) {
+	query := r.db.WithContext(ctx).Model(&models.BankQuestion{})
+
+	switch filter.Scope {
+	case models.TemplateScopePersonal:
+		query = query.Where("scope = ? AND conductor_id = ?", models.TemplateScopePersonal, filter.ConductorID)
+	case models.TemplateScopeOrganisation:
+		query = query.Where("scope = ?", models.TemplateScopeOrganisation)
+	default:
+		query = query.Where("(scope = ? AND conductor_id = ?) OR scope = ?",
+			models.TemplateScopePersonal, filter.ConductorID, models.TemplateScopeOrganisation)
+	}
+
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(tags))
+	}
+	if filter.QuestionType != "" {
+		query = query.Where("question_type = ?", filter.QuestionType)
+	}
+	if filter.Search != "" {
+		query = query.Where(BankQuestionSearchVector+" @@ websearch_to_tsquery('english', ?)", filter.Search)
+	}
+
+	var questions []models.BankQuestion
+	err := query.
+		Order("updated_at DESC, bank_question_id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&questions).Error
+	return questions, err
+}
+
+// GetInsertedQuestions returns every published question inserted from the bank question,
+// retired versions included, for the conductor's surveys that are not deleted
+func (r *questionBankRepository) GetInsertedQuestions(ctx context.Context, id, conductorID uint) ([]models.Question, error) {
+	var questions []models.Question
+	err := r.db.WithContext(ctx).
+		Joins("JOIN surveys ON surveys.survey_id = questions.survey_id AND surveys.deleted_at IS NULL AND surveys.conductor_id = ?", conductorID).
+		Where("questions.bank_question_id = ?", id).
+		Order("questions.survey_id, questions.survey_version_id, questions.question_id").
+		Find(&questions).Error
+	return questions, err
+}
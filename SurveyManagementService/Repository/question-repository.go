@@ -2,15 +2,32 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// QuestionKeyIndex is the partial unique index that keeps question keys unique among a
+// survey's current questions, so concurrent writers cannot both take a key. It is created
+// at migration time.
+const QuestionKeyIndex = "idx_questions_current_key"
+
+// QuestionKeyIndexDefinition is the column list and predicate of QuestionKeyIndex; keys
+// are empty only on questions from before keys.
+const QuestionKeyIndexDefinition = "(survey_id, question_key) WHERE retired_at IS NULL AND question_key <> ''"
+
+// ErrDuplicateQuestionKey is returned when a write would give two current questions of a
+// survey the same key
+var ErrDuplicateQuestionKey = errors.New("question key is already used")
+
 type QuestionRepository interface {
 	Create(ctx context.Context, question *models.Question) error
 	GetByID(ctx context.Context, id uint) (*models.Question, error)
 	GetBySurveyID(ctx context.Context, surveyID uint) ([]models.Question, error)
+	GetByKey(ctx context.Context, surveyID uint, key string) (*models.Question, error)
 	Update(ctx context.Context, question *models.Question) error
 	Delete(ctx context.Context, id uint) error
 }
@@ -23,26 +40,273 @@ func NewQuestionRepository(db *gorm.DB) QuestionRepository {
 	return &questionRepository{db: db}
 }
 
+// Create adds the question at the end of its survey, or of its page when it has one
 func (r *questionRepository) Create(ctx context.Context, question *models.Question) error {
-	return r.db.WithContext(ctx).Create(question).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		position, err := nextQuestionPositionWithTx(tx, question.SurveyID)
+		if err != nil {
+			return err
+		}
+		question.Position = position
+		if err := tx.Create(question).Error; err != nil {
+			return QuestionKeyError(err)
+		}
+		if question.PageID == nil {
+			return nil
+		}
+		return renumberQuestionsWithTx(tx, question.SurveyID)
+	})
 }
 
 func (r *questionRepository) GetBySurveyID(ctx context.Context, surveyID uint) ([]models.Question, error) {
 	var questions []models.Question
-	err := r.db.WithContext(ctx).Where("survey_id = ?", surveyID).Order("order_index").Find(&questions).Error
-	return questions, err
+	err := r.db.WithContext(ctx).Scopes(preloadOptions(""), preloadMatrix(""), preloadCodeTests("")).Where("survey_id = ? AND retired_at IS NULL", surveyID).Order("position, question_id").Find(&questions).Error
+	if err != nil {
+		return questions, err
+	}
+	loaded := make([]*models.Question, len(questions))
+	for i := range questions {
+		loaded[i] = &questions[i]
+	}
+	return questions, loadOptionMedia(r.db.WithContext(ctx), questionOptions(loaded...))
 }
 
+// Update saves the question and makes its matrix rows and columns match the given ones:
+// items with an ID are updated, new ones created and missing ones deleted
 func (r *questionRepository) Update(ctx context.Context, question *models.Question) error {
-	return r.db.WithContext(ctx).Save(question).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(question).Error; err != nil {
+			return QuestionKeyError(err)
+		}
+		if err := syncMatrixWithTx(tx, question); err != nil {
+			return err
+		}
+		return syncCodeTestsWithTx(tx, question)
+	})
 }
 
 func (r *questionRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Question{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", id).Delete(&models.MatrixRow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", id).Delete(&models.MatrixColumn{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", id).Delete(&models.CodeTest{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Question{}, id).Error
+	})
 }
 
 func (r *questionRepository) GetByID(ctx context.Context, id uint) (*models.Question, error) {
 	var question models.Question
-	err := r.db.WithContext(ctx).First(&question, id).Error
-	return &question, err
+	err := r.db.WithContext(ctx).Scopes(preloadOptions(""), preloadMatrix(""), preloadCodeTests("")).First(&question, id).Error
+	if err != nil {
+		return &question, err
+	}
+	return &question, loadOptionMedia(r.db.WithContext(ctx), questionOptions(&question))
+}
+
+// GetByKey returns the current (non-retired) question of a survey with the given key
+func (r *questionRepository) GetByKey(ctx context.Context, surveyID uint, key string) (*models.Question, error) {
+	var question models.Question
+	err := r.db.WithContext(ctx).Scopes(preloadOptions(""), preloadMatrix(""), preloadCodeTests("")).
+		Where("survey_id = ? AND question_key = ? AND retired_at IS NULL", surveyID, key).
+		First(&question).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := loadOptionMedia(r.db.WithContext(ctx), questionOptions(&question)); err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+// currentQuestions limits preloaded questions to the current (non-retired) ones, in
+// survey order
+func currentQuestions(db *gorm.DB) *gorm.DB {
+	return db.Where("retired_at IS NULL").Order("position, question_id")
+}
+
+// preloadOptions is a scope loading options in display order. prefix is the path to the
+// questions being loaded, as for preloadMatrix. Their media are loaded separately, with
+// loadOptionMedia.
+func preloadOptions(prefix string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(prefix+"Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position, option_id")
+		})
+	}
+}
+
+// loadOptionMedia fills in the media shown with each option, found by its MediaID
+func loadOptionMedia(db *gorm.DB, options []*models.Option) error {
+	var mediaIDs []uint
+	for _, option := range options {
+		if option.MediaID != nil {
+			mediaIDs = append(mediaIDs, *option.MediaID)
+		}
+	}
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	var mediaFiles []models.SurveyMediaFile
+	if err := db.Where("media_id IN ?", mediaIDs).Find(&mediaFiles).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]*models.SurveyMediaFile, len(mediaFiles))
+	for i := range mediaFiles {
+		byID[mediaFiles[i].MediaID] = &mediaFiles[i]
+	}
+	for _, option := range options {
+		option.Media = nil
+		if option.MediaID != nil {
+			option.Media = byID[*option.MediaID]
+		}
+	}
+	return nil
+}
+
+// questionOptions lists the options of the questions, for loadOptionMedia
+func questionOptions(questions ...*models.Question) []*models.Option {
+	var options []*models.Option
+	for _, question := range questions {
+		for i := range question.Options {
+			options = append(options, &question.Options[i])
+		}
+	}
+	return options
+}
+
+// surveyOptions lists the options of a survey's loaded questions, for loadOptionMedia
+func surveyOptions(survey *models.Survey) []*models.Option {
+	questions := make([]*models.Question, len(survey.Questions))
+	for i := range survey.Questions {
+		questions[i] = &survey.Questions[i]
+	}
+	return questionOptions(questions...)
+}
+
+// preloadMatrix is a scope loading matrix rows and columns in display order. prefix is
+// the path to the questions being loaded, e.g. "Questions." when loading a survey.
+func preloadMatrix(prefix string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload(prefix+"MatrixRows", func(db *gorm.DB) *gorm.DB {
+				return db.Order("position, row_id")
+			}).
+			Preload(prefix+"MatrixColumns", func(db *gorm.DB) *gorm.DB {
+				return db.Order("position, column_id")
+			})
+	}
+}
+
+// preloadCodeTests is a scope loading a CODE question's tests in run order, hidden ones
+// included. prefix is the path to the questions being loaded, as for preloadMatrix.
+func preloadCodeTests(prefix string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(prefix+"CodeTests", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position, test_id")
+		})
+	}
+}
+
+func syncMatrixWithTx(tx *gorm.DB, question *models.Question) error {
+	// Only the question's own items can be kept; any other ID is treated as a new item
+	var existingRows, existingColumns []uint
+	if err := tx.Model(&models.MatrixRow{}).Where("question_id = ?", question.QuestionID).Pluck("row_id", &existingRows).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.MatrixColumn{}).Where("question_id = ?", question.QuestionID).Pluck("column_id", &existingColumns).Error; err != nil {
+		return err
+	}
+	ownRow := make(map[uint]bool, len(existingRows))
+	for _, id := range existingRows {
+		ownRow[id] = true
+	}
+	ownColumn := make(map[uint]bool, len(existingColumns))
+	for _, id := range existingColumns {
+		ownColumn[id] = true
+	}
+
+	rowIDs := []uint{0}
+	for i := range question.MatrixRows {
+		row := &question.MatrixRows[i]
+		if !ownRow[row.RowID] {
+			row.RowID = 0
+		}
+		row.QuestionID = question.QuestionID
+		row.UpdatedAt = question.UpdatedAt
+		if row.RowID == 0 {
+			row.CreatedAt = question.UpdatedAt
+		}
+		if err := tx.Save(row).Error; err != nil {
+			return err
+		}
+		rowIDs = append(rowIDs, row.RowID)
+	}
+	if err := tx.Where("question_id = ? AND row_id NOT IN ?", question.QuestionID, rowIDs).Delete(&models.MatrixRow{}).Error; err != nil {
+		return err
+	}
+
+	columnIDs := []uint{0}
+	for i := range question.MatrixColumns {
+		column := &question.MatrixColumns[i]
+		if !ownColumn[column.ColumnID] {
+			column.ColumnID = 0
+		}
+		column.QuestionID = question.QuestionID
+		column.UpdatedAt = question.UpdatedAt
+		if column.ColumnID == 0 {
+			column.CreatedAt = question.UpdatedAt
+		}
+		if err := tx.Save(column).Error; err != nil {
+			return err
+		}
+		columnIDs = append(columnIDs, column.ColumnID)
+	}
+	return tx.Where("question_id = ? AND column_id NOT IN ?", question.QuestionID, columnIDs).Delete(&models.MatrixColumn{}).Error
+}
+
+func syncCodeTestsWithTx(tx *gorm.DB, question *models.Question) error {
+	// As for matrix items, only the question's own tests can be kept
+	var existing []uint
+	if err := tx.Model(&models.CodeTest{}).Where("question_id = ?", question.QuestionID).Pluck("test_id", &existing).Error; err != nil {
+		return err
+	}
+	own := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		own[id] = true
+	}
+
+	testIDs := []uint{0}
+	for i := range question.CodeTests {
+		test := &question.CodeTests[i]
+		if !own[test.TestID] {
+			test.TestID = 0
+		}
+		test.QuestionID = question.QuestionID
+		test.UpdatedAt = question.UpdatedAt
+		if test.TestID == 0 {
+			test.CreatedAt = question.UpdatedAt
+		}
+		if err := tx.Save(test).Error; err != nil {
+			return err
+		}
+		testIDs = append(testIDs, test.TestID)
+	}
+	return tx.Where("question_id = ? AND test_id NOT IN ?", question.QuestionID, testIDs).Delete(&models.CodeTest{}).Error
+}
+
+// questionKeyError reports a violation of QuestionKeyIndex as ErrDuplicateQuestionKey,
+// the race the service's own check can lose
+func QuestionKeyError(err error) error {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == "23505" && strings.Contains(err.Error(), QuestionKeyIndex) {
+		return ErrDuplicateQuestionKey
+	}
+	return err
 }
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+)
+
+type SectionRepository interface {
+	CreateSection(ctx context.Context, section *models.Section) error
+	GetSection(ctx context.Context, id uint) (*models.Section, error)
+	UpdateSection(ctx context.Context, section *models.Section) error
+	DeleteSection(ctx context.Context, id uint) error
+	CreatePage(ctx context.Context, page *models.Page) error
+	GetPage(ctx context.Context, id uint) (*models.Page, error)
+	UpdatePage(ctx context.Context, page *models.Page) error
+	DeletePage(ctx context.Context, id uint) error
+	GetBySurveyID(ctx context.Context, surveyID uint) ([]models.Section, error)
+
+	// Transaction-aware methods
+	GetBySurveyIDWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) ([]models.Section, error)
+	CreateSectionWithTx(ctx context.Context, tx *gorm.DB, section *models.Section) error
+	CreatePageWithTx(ctx context.Context, tx *gorm.DB, page *models.Page) error
+	SaveLayoutWithTx(ctx context.Context, tx *gorm.DB, sections []models.Section, questions []models.Question) error
+	NextQuestionPositionWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int, error)
+	RenumberQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+}
+
+type sectionRepository struct {
+	db *gorm.DB
+}
+
+func NewSectionRepository(db *gorm.DB) SectionRepository {
+	return &sectionRepository{db: db}
+}
+
+// CreateSection adds the section after the survey's other sections
+func (r *sectionRepository) CreateSection(ctx context.Context, section *models.Section) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var position int
+		if err := tx.Model(&models.Section{}).Where("survey_id = ?", section.SurveyID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error; err != nil {
+			return err
+		}
+		section.Position = position
+		return tx.Omit("Pages").Create(section).Error
+	})
+}
+
+func (r *sectionRepository) GetSection(ctx context.Context, id uint) (*models.Section, error) {
+	var section models.Section
+	err := r.db.WithContext(ctx).Preload("Pages", orderedPages).First(&section, id).Error
+	return &section, err
+}
+
+// UpdateSection saves the section's title, description and shuffle setting; its place
+// only changes through SaveLayoutWithTx
+func (r *sectionRepository) UpdateSection(ctx context.Context, section *models.Section) error {
+	return r.db.WithContext(ctx).Model(section).Select("title", "description", "randomize_questions", "updated_at").Updates(section).Error
+}
+
+// DeleteSection deletes the section and its pages. Their questions stay in the survey,
+// on no page.
+func (r *sectionRepository) DeleteSection(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var section models.Section
+		if err := tx.First(&section, id).Error; err != nil {
+			return err
+		}
+		pages := tx.Model(&models.Page{}).Select("page_id").Where("section_id = ?", id)
+		if err := tx.Model(&models.Question{}).Where("page_id IN (?)", pages).UpdateColumn("page_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("section_id = ?", id).Delete(&models.Page{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&section).Error; err != nil {
+			return err
+		}
+		return renumberQuestionsWithTx(tx, section.SurveyID)
+	})
+}
+
+// CreatePage adds the page after the section's other pages
+func (r *sectionRepository) CreatePage(ctx context.Context, page *models.Page) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var position int
+		if err := tx.Model(&models.Page{}).Where("section_id = ?", page.SectionID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error; err != nil {
+			return err
+		}
+		page.Position = position
+		return tx.Create(page).Error
+	})
+}
+
+func (r *sectionRepository) GetPage(ctx context.Context, id uint) (*models.Page, error) {
+	var page models.Page
+	err := r.db.WithContext(ctx).First(&page, id).Error
+	return &page, err
+}
+
+// UpdatePage saves the page's title; its place only changes through SaveLayoutWithTx
+func (r *sectionRepository) UpdatePage(ctx context.Context, page *models.Page) error {
+	return r.db.WithContext(ctx).Model(page).Select("title", "updated_at").Updates(page).Error
+}
+
+// DeletePage deletes the page. Its questions stay in the survey, on no page.
+func (r *sectionRepository) DeletePage(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var page models.Page
+		if err := tx.First(&page, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Question{}).Where("page_id = ?", id).UpdateColumn("page_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&page).Error; err != nil {
+			return err
+		}
+		return renumberQuestionsWithTx(tx, page.SurveyID)
+	})
+}
+
+// GetBySurveyID returns the survey's sections with their pages, in order
+func (r *sectionRepository) GetBySurveyID(ctx context.Context, surveyID uint) ([]models.Section, error) {
+	return r.GetBySurveyIDWithTx(ctx, r.db, surveyID)
+}
+
+func (r *sectionRepository) GetBySurveyIDWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) ([]models.Section, error) {
+	var sections []models.Section
+	err := tx.WithContext(ctx).
+		Preload("Pages", orderedPages).
+		Where("survey_id = ?", surveyID).
+		Order("position, section_id").
+		Find(&sections).Error
+	return sections, err
+}
+
+func (r *sectionRepository) CreateSectionWithTx(ctx context.Context, tx *gorm.DB, section *models.Section) error {
+	return tx.WithContext(ctx).Omit("Pages").Create(section).Error
+}
+
+func (r *sectionRepository) CreatePageWithTx(ctx context.Context, tx *gorm.DB, page *models.Page) error {
+	return tx.WithContext(ctx).Create(page).Error
+}
+
+// SaveLayoutWithTx writes the positions of sections, the section and position of their
+// pages, and the page and position of questions. Nothing else is changed.
+func (r *sectionRepository) SaveLayoutWithTx(ctx context.Context, tx *gorm.DB, sections []models.Section, questions []models.Question) error {
+	tx = tx.WithContext(ctx)
+	for _, section := range sections {
+		if err := tx.Model(&models.Section{}).Where("section_id = ?", section.SectionID).UpdateColumn("position", section.Position).Error; err != nil {
+			return err
+		}
+		for _, page := range section.Pages {
+			err := tx.Model(&models.Page{}).Where("page_id = ?", page.PageID).
+				UpdateColumns(map[string]interface{}{"section_id": section.SectionID, "position": page.Position}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, question := range questions {
+		err := tx.Model(&models.Question{}).Where("question_id = ?", question.QuestionID).
+			UpdateColumns(map[string]interface{}{"page_id": question.PageID, "position": question.Position}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *sectionRepository) NextQuestionPositionWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int, error) {
+	return nextQuestionPositionWithTx(tx.WithContext(ctx), surveyID)
+}
+
+func (r *sectionRepository) RenumberQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	return renumberQuestionsWithTx(tx.WithContext(ctx), surveyID)
+}
+
+// orderedPages loads pages in order within their section
+func orderedPages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, page_id")
+}
+
+// nextQuestionPositionWithTx is the position after the survey's current questions
+func nextQuestionPositionWithTx(tx *gorm.DB, surveyID uint) (int, error) {
+	var position int
+	err := tx.Model(&models.Question{}).
+		Where("survey_id = ? AND retired_at IS NULL", surveyID).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Scan(&position).Error
+	return position, err
+}
+
+// renumberQuestionsWithTx numbers the survey's current questions from 0 in survey order:
+// questions on no page first, then page by page in section and page order. Questions
+// keep their relative order within a page.
+func renumberQuestionsWithTx(tx *gorm.DB, surveyID uint) error {
+	var pageIDs []uint
+	err := tx.Model(&models.Page{}).
+		Joins("JOIN sections ON sections.section_id = pages.section_id").
+		Where("pages.survey_id = ?", surveyID).
+		Order("sections.position, sections.section_id, pages.position, pages.page_id").
+		Pluck("pages.page_id", &pageIDs).Error
+	if err != nil {
+		return err
+	}
+	pageRank := make(map[uint]int, len(pageIDs))
+	for i, id := range pageIDs {
+		pageRank[id] = i + 1
+	}
+
+	var questions []models.Question
+	err = tx.Select("question_id", "page_id", "position").
+		Where("survey_id = ? AND retired_at IS NULL", surveyID).
+		Order("position, question_id").
+		Find(&questions).Error
+	if err != nil {
+		return err
+	}
+	rank := func(q models.Question) int {
+		if q.PageID == nil {
+			return 0
+		}
+		return pageRank[*q.PageID]
+	}
+	sort.SliceStable(questions, func(i, j int) bool {
+		return rank(questions[i]) < rank(questions[j])
+	})
+
+	for i, q := range questions {
+		if q.Position == i {
+			continue
+		}
+		if err := tx.Model(&models.Question{}).Where("question_id = ?", q.QuestionID).UpdateColumn("position", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
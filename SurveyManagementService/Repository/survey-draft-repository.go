@@ -1,13 +1,12 @@
 package repository
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"log"
 
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SurveyDraftRepository interface {
@@ -18,6 +17,9 @@ type SurveyDraftRepository interface {
 	GetLatestDraft(ctx context.Context, surveyID uint) (*models.SurveyDraft, error)
 	ListDrafts(ctx context.Context, surveyID uint) ([]models.SurveyDraft, error)
 	DeleteAllForSurveyWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+	CreateDraftWithTx(ctx context.Context, tx *gorm.DB, draft *models.SurveyDraft) error
+	GetByIDForUpdateWithTx(ctx context.Context, tx *gorm.DB, draftID uint) (*models.SurveyDraft, error)
+	UpdateWithTx(ctx context.Context, tx *gorm.DB, draft *models.SurveyDraft) error
 }
 
 type surveyDraftRepository struct {
@@ -34,13 +36,7 @@ func (r *surveyDraftRepository) SaveDraft(ctx context.Context, draft *models.Sur
 
 // CreateDraft creates a new draft and returns it with the generated ID
 func (r *surveyDraftRepository) CreateDraft(ctx context.Context, draft *models.SurveyDraft) (*models.SurveyDraft, error) {
-	// Log the draft content before saving
-	var prettyContent bytes.Buffer
-	if len(draft.DraftContent) > 0 {
-		if err := json.Indent(&prettyContent, []byte(draft.DraftContent), "", "  "); err == nil {
-			log.Printf("Repository creating draft with content: %s", prettyContent.String())
-		}
-	}
+	log.Printf("Repository creating draft for survey %d (%d bytes)", draft.SurveyID, len(draft.DraftContent))
 
 	if err := r.db.WithContext(ctx).Create(draft).Error; err != nil {
 		return nil, err
@@ -78,13 +74,7 @@ func (r *surveyDraftRepository) GetByID(ctx context.Context, draftID uint) (*mod
 
 // Update updates an existing draft
 func (r *surveyDraftRepository) Update(ctx context.Context, draft *models.SurveyDraft) (*models.SurveyDraft, error) {
-	// Log the draft content before updating
-	var prettyContent bytes.Buffer
-	if len(draft.DraftContent) > 0 {
-		if err := json.Indent(&prettyContent, []byte(draft.DraftContent), "", "  "); err == nil {
-			log.Printf("Repository updating draft %d with content: %s", draft.DraftID, prettyContent.String())
-		}
-	}
+	log.Printf("Repository updating draft %d (%d bytes)", draft.DraftID, len(draft.DraftContent))
 
 	err := r.db.WithContext(ctx).Save(draft).Error
 	if err != nil {
@@ -93,7 +83,31 @@ func (r *surveyDraftRepository) Update(ctx context.Context, draft *models.Survey
 	return draft, nil
 }
 
-// DeleteAllForSurveyWithTx deletes all drafts for a specific survey within a transaction
+// DeleteAllForSurveyWithTx deletes all drafts for a specific survey, and their revisions, within a transaction
 func (r *surveyDraftRepository) DeleteAllForSurveyWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	err := tx.WithContext(ctx).
+		Delete(&models.SurveyDraftRevision{}, "draft_id IN (SELECT draft_id FROM survey_drafts WHERE survey_id = ?)", surveyID).Error
+	if err != nil {
+		return err
+	}
 	return tx.WithContext(ctx).Delete(&models.SurveyDraft{}, "survey_id = ?", surveyID).Error
 }
+
+func (r *surveyDraftRepository) CreateDraftWithTx(ctx context.Context, tx *gorm.DB, draft *models.SurveyDraft) error {
+	return tx.WithContext(ctx).Create(draft).Error
+}
+
+// GetByIDForUpdateWithTx loads a draft and locks its row until the transaction ends,
+// so concurrent saves are numbered one after the other
+func (r *surveyDraftRepository) GetByIDForUpdateWithTx(ctx context.Context, tx *gorm.DB, draftID uint) (*models.SurveyDraft, error) {
+	var draft models.SurveyDraft
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&draft, draftID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+func (r *surveyDraftRepository) UpdateWithTx(ctx context.Context, tx *gorm.DB, draft *models.SurveyDraft) error {
+	return tx.WithContext(ctx).Save(draft).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"gorm.io/gorm"
@@ -13,6 +14,7 @@ type SurveyRepository interface {
 	Update(ctx context.Context, survey *models.Survey) error
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, conductorID uint) ([]models.Survey, error)
+	Search(ctx context.Context, filter SurveyFilter) ([]models.Survey, error)
 	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
 	TransactionWithResult(ctx context.Context, fn func(tx *gorm.DB) (uint, error)) (uint, error)
 	GetByIDWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error)
@@ -23,6 +25,26 @@ type SurveyRepository interface {
 	CreateQuestionWithTx(ctx context.Context, tx *gorm.DB, question *models.Question) error
 	CreateMediaFileWithTx(ctx context.Context, tx *gorm.DB, mediaFile *models.SurveyMediaFile) error
 	DeleteOptionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+	AssignMissingQuestionKeysWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, newKey func() string) error
+	UpdateStatus(ctx context.Context, surveyID uint, from, to string) (bool, error)
+	UpdateSchedule(ctx context.Context, surveyID uint, opensAt, closesAt *time.Time) error
+	OpenDue(ctx context.Context, now time.Time) (int64, error)
+	CloseDue(ctx context.Context, now time.Time) (int64, error)
+	RenameStatus(ctx context.Context, from, to string) (int64, error)
+	GetWithContentWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error)
+	GetMediaFilesByQuestionIDsWithTx(ctx context.Context, tx *gorm.DB, questionIDs []uint) ([]models.SurveyMediaFile, error)
+	GetMediaFilesByIDsWithTx(ctx context.Context, tx *gorm.DB, ids []uint) ([]models.SurveyMediaFile, error)
+	CreateRequirementWithTx(ctx context.Context, tx *gorm.DB, requirement *models.SurveyRequirement) error
+	DeleteRequirementsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+	SetTemplateScope(ctx context.Context, surveyID uint, scope string) error
+	SetLocales(ctx context.Context, surveyID uint, defaultLocale string, locales models.JSONContent) error
+	ListTemplates(ctx context.Context, conductorID uint) ([]models.Survey, error)
+	GetDeleted(ctx context.Context, id uint) (*models.Survey, error)
+	ListDeleted(ctx context.Context, conductorID uint) ([]models.Survey, error)
+	Restore(ctx context.Context, id uint) error
+	GetIncludingDeletedWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error)
+	CountCompletedSessionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int64, error)
+	HardDeleteWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
 }
 
 type Transaction interface {
@@ -49,8 +71,11 @@ func (r *surveyRepository) Create(ctx context.Context, survey *models.Survey) er
 
 func (r *surveyRepository) GetByID(ctx context.Context, id uint) (*models.Survey, error) {
 	var survey models.Survey
-	err := r.db.WithContext(ctx).Preload("Questions").First(&survey, id).Error
-	return &survey, err
+	err := r.db.WithContext(ctx).Preload("Questions", currentQuestions).Scopes(preloadOptions("Questions."), preloadMatrix("Questions.")).First(&survey, id).Error
+	if err != nil {
+		return &survey, err
+	}
+	return &survey, loadOptionMedia(r.db.WithContext(ctx), surveyOptions(&survey))
 }
 
 func (r *surveyRepository) Update(ctx context.Context, survey *models.Survey) error {
@@ -79,8 +104,11 @@ func (r *surveyRepository) TransactionWithResult(ctx context.Context, fn func(tx
 
 func (r *surveyRepository) GetByIDWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error) {
 	var survey models.Survey
-	err := tx.WithContext(ctx).Preload("Questions").First(&survey, id).Error
-	return &survey, err
+	err := tx.WithContext(ctx).Preload("Questions", currentQuestions).Scopes(preloadOptions("Questions."), preloadMatrix("Questions.")).First(&survey, id).Error
+	if err != nil {
+		return &survey, err
+	}
+	return &survey, loadOptionMedia(tx.WithContext(ctx), surveyOptions(&survey))
 }
 
 func (r *surveyRepository) UpdateWithTx(ctx context.Context, tx *gorm.DB, survey *models.Survey) error {
@@ -116,3 +144,218 @@ func (r *surveyRepository) DeleteOptionsWithTx(ctx context.Context, tx *gorm.DB,
 			WHERE survey_id = ?
 		)`, surveyID).Error
 }
+
+// AssignMissingQuestionKeysWithTx gives every current question without a key a new one
+func (r *surveyRepository) AssignMissingQuestionKeysWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, newKey func() string) error {
+	var ids []uint
+	err := tx.WithContext(ctx).Model(&models.Question{}).
+		Where("survey_id = ? AND retired_at IS NULL AND (question_key = '' OR question_key IS NULL)", surveyID).
+		Pluck("question_id", &ids).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := tx.WithContext(ctx).Model(&models.Question{}).Where("question_id = ?", id).Update("question_key", newKey()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateStatus moves a survey from one status to another. It reports false, without an
+// error, when the survey is no longer in the from status.
+func (r *surveyRepository) UpdateStatus(ctx context.Context, surveyID uint, from, to string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("survey_id = ? AND status = ?", surveyID, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *surveyRepository) UpdateSchedule(ctx context.Context, surveyID uint, opensAt, closesAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("survey_id = ?", surveyID).
+		Updates(map[string]interface{}{"opens_at": opensAt, "closes_at": closesAt, "updated_at": time.Now()}).Error
+}
+
+// OpenDue opens every scheduled survey whose opening time has passed
+func (r *surveyRepository) OpenDue(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("status = ? AND opens_at IS NOT NULL AND opens_at <= ?", models.StatusScheduled, now).
+		Updates(map[string]interface{}{"status": models.StatusOpen, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// CloseDue closes every open or paused survey whose closing time has passed
+func (r *surveyRepository) CloseDue(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("status IN ? AND closes_at IS NOT NULL AND closes_at <= ?", []string{models.StatusOpen, models.StatusPaused}, now).
+		Updates(map[string]interface{}{"status": models.StatusClosed, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (r *surveyRepository) RenameStatus(ctx context.Context, from, to string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("status = ?", from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// GetWithContentWithTx loads a survey with its current questions, their options and its
+// requirements, everything needed to copy it
+func (r *surveyRepository) GetWithContentWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error) {
+	var survey models.Survey
+	err := tx.WithContext(ctx).
+		Preload("Questions", currentQuestions).
+		Scopes(preloadOptions("Questions."), preloadMatrix("Questions."), preloadCodeTests("Questions.")).
+		Preload("Requirements").
+		First(&survey, id).Error
+	if err != nil {
+		return &survey, err
+	}
+	return &survey, loadOptionMedia(tx.WithContext(ctx), surveyOptions(&survey))
+}
+
+// GetMediaFilesByQuestionIDsWithTx returns the conductor-attached media of the given
+// questions, leaving out participant uploads
+func (r *surveyRepository) GetMediaFilesByQuestionIDsWithTx(ctx context.Context, tx *gorm.DB, questionIDs []uint) ([]models.SurveyMediaFile, error) {
+	var mediaFiles []models.SurveyMediaFile
+	if len(questionIDs) == 0 {
+		return mediaFiles, nil
+	}
+	err := tx.WithContext(ctx).
+		Where("question_id IN ? AND session_id = 0", questionIDs).
+		Order("media_id").
+		Find(&mediaFiles).Error
+	return mediaFiles, err
+}
+
+// GetMediaFilesByIDsWithTx returns the media files with the given IDs that exist
+func (r *surveyRepository) GetMediaFilesByIDsWithTx(ctx context.Context, tx *gorm.DB, ids []uint) ([]models.SurveyMediaFile, error) {
+	var mediaFiles []models.SurveyMediaFile
+	if len(ids) == 0 {
+		return mediaFiles, nil
+	}
+	err := tx.WithContext(ctx).Where("media_id IN ?", ids).Find(&mediaFiles).Error
+	return mediaFiles, err
+}
+
+func (r *surveyRepository) CreateRequirementWithTx(ctx context.Context, tx *gorm.DB, requirement *models.SurveyRequirement) error {
+	return tx.WithContext(ctx).Create(requirement).Error
+}
+
+func (r *surveyRepository) DeleteRequirementsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	return tx.WithContext(ctx).Where("survey_id = ?", surveyID).Delete(&models.SurveyRequirement{}).Error
+}
+
+func (r *surveyRepository) SetTemplateScope(ctx context.Context, surveyID uint, scope string) error {
+	return r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("survey_id = ?", surveyID).
+		Updates(map[string]interface{}{"template_scope": scope, "updated_at": time.Now()}).Error
+}
+
+// SetLocales saves the survey's default and supported locales
+func (r *surveyRepository) SetLocales(ctx context.Context, surveyID uint, defaultLocale string, locales models.JSONContent) error {
+	return r.db.WithContext(ctx).Model(&models.Survey{}).
+		Where("survey_id = ?", surveyID).
+		Updates(map[string]interface{}{"default_locale": defaultLocale, "supported_locales": locales, "updated_at": time.Now()}).Error
+}
+
+// ListTemplates returns the conductor's personal templates and every organisation template
+func (r *surveyRepository) ListTemplates(ctx context.Context, conductorID uint) ([]models.Survey, error) {
+	var surveys []models.Survey
+	err := r.db.WithContext(ctx).
+		Where("(template_scope = ? AND conductor_id = ?) OR template_scope = ?",
+			models.TemplateScopePersonal, conductorID, models.TemplateScopeOrganisation).
+		Order("title, survey_id").
+		Find(&surveys).Error
+	return surveys, err
+}
+
+// GetDeleted returns a soft-deleted survey, or gorm.ErrRecordNotFound if the survey
+// does not exist or is not deleted
+func (r *surveyRepository) GetDeleted(ctx context.Context, id uint) (*models.Survey, error) {
+	var survey models.Survey
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("survey_id = ? AND deleted_at IS NOT NULL", id).
+		First(&survey).Error
+	return &survey, err
+}
+
+func (r *surveyRepository) ListDeleted(ctx context.Context, conductorID uint) ([]models.Survey, error) {
+	var surveys []models.Survey
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("conductor_id = ? AND deleted_at IS NOT NULL", conductorID).
+		Order("deleted_at DESC").
+		Find(&surveys).Error
+	return surveys, err
+}
+
+func (r *surveyRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Survey{}).
+		Where("survey_id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now()}).Error
+}
+
+func (r *surveyRepository) GetIncludingDeletedWithTx(ctx context.Context, tx *gorm.DB, id uint) (*models.Survey, error) {
+	var survey models.Survey
+	err := tx.WithContext(ctx).Unscoped().First(&survey, id).Error
+	return &survey, err
+}
+
+func (r *surveyRepository) CountCompletedSessionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int64, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&models.SurveySession{}).
+		Where("survey_id = ? AND session_status = ?", surveyID, "COMPLETED").
+		Count(&count).Error
+	return count, err
+}
+
+// HardDeleteWithTx removes a survey and every row that depends on it: answers and
+// participant drafts of its sessions, sessions, media, options, branching rules,
+// questions of every version, pages, sections, requirements, translations, builder drafts
+// with their revisions and versions
+func (r *surveyRepository) HardDeleteWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	const (
+		sessions  = "SELECT session_id FROM survey_sessions WHERE survey_id = ?"
+		questions = "SELECT question_id FROM questions WHERE survey_id = ?"
+	)
+	tx = tx.WithContext(ctx)
+
+	if err := tx.Where("session_id IN ("+sessions+") OR question_id IN ("+questions+")", surveyID, surveyID).Delete(&models.Answer{}).Error; err != nil {
+		return err
+	}
+	// Owned by the Participants service, which may not have created it yet
+	if tx.Migrator().HasTable("participant_survey_drafts") {
+		if err := tx.Exec("DELETE FROM participant_survey_drafts WHERE session_id IN ("+sessions+")", surveyID).Error; err != nil {
+			return err
+		}
+	}
+
+	steps := []struct {
+		model interface{}
+		query string
+	}{
+		{&models.SurveyMediaFile{}, "survey_id = ?"},
+		{&models.SurveySession{}, "survey_id = ?"},
+		{&models.Option{}, "question_id IN (" + questions + ")"},
+		{&models.MatrixRow{}, "question_id IN (" + questions + ")"},
+		{&models.MatrixColumn{}, "question_id IN (" + questions + ")"},
+		{&models.CodeTest{}, "question_id IN (" + questions + ")"},
+		{&models.BranchingRule{}, "survey_id = ?"},
+		{&models.Question{}, "survey_id = ?"},
+		{&models.Page{}, "survey_id = ?"},
+		{&models.Section{}, "survey_id = ?"},
+		{&models.SurveyRequirement{}, "survey_id = ?"},
+		{&models.Translation{}, "survey_id = ?"},
+		{&models.SurveyDraftRevision{}, "draft_id IN (SELECT draft_id FROM survey_drafts WHERE survey_id = ?)"},
+		{&models.SurveyDraft{}, "survey_id = ?"},
+		{&models.SurveyVersion{}, "survey_id = ?"},
+	}
+	for _, step := range steps {
+		if err := tx.Where(step.query, surveyID).Delete(step.model).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Delete(&models.Survey{}, surveyID).Error
+}
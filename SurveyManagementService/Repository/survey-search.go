@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// Sortable survey columns
+const (
+	SurveySortCreatedAt = "created_at"
+	SurveySortUpdatedAt = "updated_at"
+	SurveySortTitle     = "title"
+)
+
+// SurveySearchVector is the full-text document of a survey. The GIN index created at
+// migration time uses the same expression, so the two must stay in sync.
+const SurveySearchVector = "to_tsvector('english', coalesce(title, '') || ' ' || coalesce(description, ''))"
+
+// SurveyFilter selects one page of a conductor's surveys. Pages are keyset-based:
+// After holds the sort value and ID of the last survey of the previous page.
+type SurveyFilter struct {
+	ConductorID   uint
+	Statuses      []string
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Sort          string
+	Descending    bool
+	After         *SurveyCursor
+	Limit         int
+}
+
+// SurveyCursor is the position of a survey in a sorted listing
+type SurveyCursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// Search returns the surveys matching filter in sort order, up to filter.Limit
+func (r *surveyRepository) Search(ctx context.Context, filter SurveyFilter) ([]models.Survey, error) {
+	query := r.db.WithContext(ctx).Model(&models.Survey{}).Where("conductor_id = ?", filter.ConductorID)
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Search != "" {
+		query = query.Where(SurveySearchVector+" @@ websearch_to_tsquery('english', ?)", filter.Search)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+
+	column := filter.Sort
+	switch column {
+	case SurveySortCreatedAt, SurveySortUpdatedAt, SurveySortTitle:
+	default:
+		return nil, fmt.Errorf("unsupported sort column %q", column)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		// Row comparison keeps the order total when several surveys share a sort value
+		query = query.Where(fmt.Sprintf("(%s, survey_id) %s (?, ?)", column, comparison), filter.After.Value, filter.After.ID)
+	}
+
+	var surveys []models.Survey
+	err := query.
+		Order(fmt.Sprintf("%s %s, survey_id %s", column, direction, direction)).
+		Limit(filter.Limit).
+		Find(&surveys).Error
+	return surveys, err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+)
+
+type SurveyVersionRepository interface {
+	CreateWithTx(ctx context.Context, tx *gorm.DB, version *models.SurveyVersion) error
+	GetByID(ctx context.Context, versionID uint) (*models.SurveyVersion, error)
+	GetByNumber(ctx context.Context, surveyID uint, versionNumber int) (*models.SurveyVersion, error)
+	ListBySurveyID(ctx context.Context, surveyID uint) ([]models.SurveyVersion, error)
+	NextVersionNumberWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int, error)
+	LoadSnapshotWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (*models.SurveySnapshot, error)
+	AssignLineageWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error
+	StampQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, versionID uint) error
+	RetireQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, at time.Time) error
+	SetCurrentVersionWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, versionID uint) error
+}
+
+type surveyVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewSurveyVersionRepository(db *gorm.DB) SurveyVersionRepository {
+	return &surveyVersionRepository{db: db}
+}
+
+func (r *surveyVersionRepository) CreateWithTx(ctx context.Context, tx *gorm.DB, version *models.SurveyVersion) error {
+	return tx.WithContext(ctx).Create(version).Error
+}
+
+func (r *surveyVersionRepository) GetByID(ctx context.Context, versionID uint) (*models.SurveyVersion, error) {
+	var version models.SurveyVersion
+	err := r.db.WithContext(ctx).First(&version, versionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *surveyVersionRepository) GetByNumber(ctx context.Context, surveyID uint, versionNumber int) (*models.SurveyVersion, error) {
+	var version models.SurveyVersion
+	err := r.db.WithContext(ctx).
+		Where("survey_id = ? AND version_number = ?", surveyID, versionNumber).
+		First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ListBySurveyID returns version metadata, newest first, without the snapshot bodies
+func (r *surveyVersionRepository) ListBySurveyID(ctx context.Context, surveyID uint) ([]models.SurveyVersion, error) {
+	var versions []models.SurveyVersion
+	err := r.db.WithContext(ctx).
+		Select("version_id, survey_id, version_number, published_at, created_at").
+		Where("survey_id = ?", surveyID).
+		Order("version_number DESC").
+		Find(&versions).Error
+	return versions, err
+}
+
+func (r *surveyVersionRepository) NextVersionNumberWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (int, error) {
+	var latest int
+	err := tx.WithContext(ctx).Model(&models.SurveyVersion{}).
+		Where("survey_id = ?", surveyID).
+		Select("COALESCE(MAX(version_number), 0)").
+		Scan(&latest).Error
+	return latest + 1, err
+}
+
+// LoadSnapshotWithTx reads the current (non-retired) state of a survey with everything attached to it
+func (r *surveyVersionRepository) LoadSnapshotWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (*models.SurveySnapshot, error) {
+	var survey models.Survey
+	err := tx.WithContext(ctx).
+		Preload("Questions", currentQuestions).
+		Scopes(preloadOptions("Questions."), preloadMatrix("Questions.")).
+		Preload("Requirements").
+		First(&survey, surveyID).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := loadOptionMedia(tx.WithContext(ctx), surveyOptions(&survey)); err != nil {
+		return nil, err
+	}
+
+	questionIDs := make([]uint, len(survey.Questions))
+	for i, q := range survey.Questions {
+		questionIDs[i] = q.QuestionID
+	}
+
+	var mediaFiles []models.SurveyMediaFile
+	if len(questionIDs) > 0 {
+		if err := tx.WithContext(ctx).Where("question_id IN ? AND session_id = 0", questionIDs).Find(&mediaFiles).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var rules []models.BranchingRule
+	if err := tx.WithContext(ctx).
+		Where("survey_id = ?", surveyID).
+		Order("source_question_id, priority, rule_id").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	var sections []models.Section
+	if err := tx.WithContext(ctx).
+		Preload("Pages", orderedPages).
+		Where("survey_id = ?", surveyID).
+		Order("position, section_id").
+		Find(&sections).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.SurveySnapshot{
+		SurveyID:          survey.SurveyID,
+		Title:             survey.Title,
+		Description:       survey.Description,
+		IsSelfRecruitment: survey.IsSelfRecruitment,
+		Questions:         survey.Questions,
+		Requirements:      survey.Requirements,
+		MediaFiles:        mediaFiles,
+		BranchingRules:    rules,
+		Sections:          sections,
+	}, nil
+}
+
+// AssignLineageWithTx starts a lineage, keyed by the question's own ID, for every current
+// question that does not continue an earlier one
+func (r *surveyVersionRepository) AssignLineageWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) error {
+	return tx.WithContext(ctx).Model(&models.Question{}).
+		Where("survey_id = ? AND retired_at IS NULL AND lineage_id = 0", surveyID).
+		Update("lineage_id", gorm.Expr("question_id")).Error
+}
+
+// StampQuestionsWithTx marks the survey's current questions as belonging to a version
+func (r *surveyVersionRepository) StampQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, versionID uint) error {
+	return tx.WithContext(ctx).Model(&models.Question{}).
+		Where("survey_id = ? AND retired_at IS NULL", surveyID).
+		Update("survey_version_id", versionID).Error
+}
+
+// RetireQuestionsWithTx supersedes the survey's current questions without deleting them
+func (r *surveyVersionRepository) RetireQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, at time.Time) error {
+	return tx.WithContext(ctx).Model(&models.Question{}).
+		Where("survey_id = ? AND retired_at IS NULL", surveyID).
+		Update("retired_at", at).Error
+}
+
+func (r *surveyVersionRepository) SetCurrentVersionWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, versionID uint) error {
+	return tx.WithContext(ctx).Model(&models.Survey{}).
+		Where("survey_id = ?", surveyID).
+		Update("current_version_id", versionID).Error
+}
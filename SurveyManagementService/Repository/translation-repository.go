@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TranslationRepository interface {
+	GetBySurveyLocale(ctx context.Context, surveyID uint, locale string) ([]models.Translation, error)
+	Save(ctx context.Context, translations []models.Translation) error
+
+	// Transaction-aware methods
+	CopyWithTx(ctx context.Context, tx *gorm.DB, fromSurveyID, toSurveyID uint) error
+}
+
+type translationRepository struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+func (r *translationRepository) GetBySurveyLocale(ctx context.Context, surveyID uint, locale string) ([]models.Translation, error) {
+	var translations []models.Translation
+	err := r.db.WithContext(ctx).
+		Where("survey_id = ? AND locale = ?", surveyID, locale).
+		Order("resource_id").
+		Find(&translations).Error
+	return translations, err
+}
+
+// Save inserts translations, replacing the text and source of any a survey already has
+// for the same locale and resource
+func (r *translationRepository) Save(ctx context.Context, translations []models.Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "survey_id"}, {Name: "locale"}, {Name: "resource_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source_text", "text", "updated_at"}),
+	}).Create(&translations).Error
+}
+
+// CopyWithTx copies one survey's translations to another, e.g. a clone; resource IDs
+// carry over because clones keep question keys
+func (r *translationRepository) CopyWithTx(ctx context.Context, tx *gorm.DB, fromSurveyID, toSurveyID uint) error {
+	var translations []models.Translation
+	if err := tx.WithContext(ctx).Where("survey_id = ?", fromSurveyID).Find(&translations).Error; err != nil {
+		return err
+	}
+	if len(translations) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range translations {
+		translations[i].TranslationID = 0
+		translations[i].SurveyID = toSurveyID
+		translations[i].CreatedAt = now
+		translations[i].UpdatedAt = now
+	}
+	return tx.WithContext(ctx).Create(&translations).Error
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// pipePattern matches a placeholder for an earlier answer in question or option text,
+// e.g. {{q:language}}, where language is the answered question's key. The Participants
+// service fills these in with the same pattern.
+var pipePattern = regexp.MustCompile(`\{\{\s*q:([^{}]*?)\s*\}\}`)
+
+// pipedKeys returns the question keys a text pipes answers from, lower-cased as keys
+// are, in order of first use
+func pipedKeys(text string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, match := range pipePattern.FindAllStringSubmatch(text, -1) {
+		key := strings.ToLower(match[1])
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// questionPipes returns the question keys a question's text and option texts pipe
+// answers from
+func questionPipes(question models.Question) []string {
+	keys := pipedKeys(question.QuestionText)
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for _, option := range question.Options {
+		for _, key := range pipedKeys(option.OptionText) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"gorm.io/gorm"
+
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
 )
@@ -15,23 +19,33 @@ type AnswerService interface {
 	GetAnswerByID(ctx context.Context, id uint) (*models.Answer, error)
 	GetAnswersBySession(ctx context.Context, sessionID uint) ([]models.Answer, error)
 	GetAnswersByQuestion(ctx context.Context, questionID uint) ([]models.Answer, error)
+	GetAnswersAcrossVersions(ctx context.Context, questionID uint) ([]models.Answer, error)
 	UpdateAnswer(ctx context.Context, answer *models.Answer) error
 	DeleteAnswer(ctx context.Context, id uint) error
 	SubmitBulkAnswers(ctx context.Context, sessionID uint, answers []models.Answer) error
 	ValidateAnswer(ctx context.Context, answer *models.Answer) error
+	ValidateSubmission(ctx context.Context, sessionID uint, answers []models.Answer) error
+	GetQuestionResults(ctx context.Context, questionID uint) (*QuestionResults, error)
 }
 
 type answerService struct {
 	answerRepo   repository.AnswerRepository
 	questionRepo repository.QuestionRepository
 	sessionRepo  repository.SurveySessionRepository
+	branching    *BranchingService
+	evaluator    CodeEvaluator
 }
 
-func NewAnswerService(answerRepo repository.AnswerRepository, questionRepo repository.QuestionRepository, sessionRepo repository.SurveySessionRepository) AnswerService {
+// ErrSessionNotFound is returned when an answer names a session that does not exist
+var ErrSessionNotFound = errors.New("survey session not found")
+
+func NewAnswerService(answerRepo repository.AnswerRepository, questionRepo repository.QuestionRepository, sessionRepo repository.SurveySessionRepository, branching *BranchingService, evaluator CodeEvaluator) AnswerService {
 	return &answerService{
 		answerRepo:   answerRepo,
 		questionRepo: questionRepo,
 		sessionRepo:  sessionRepo,
+		branching:    branching,
+		evaluator:    evaluator,
 	}
 }
 
@@ -53,6 +67,21 @@ func (s *answerService) CreateAnswer(ctx context.Context, answer *models.Answer)
 	if err := s.ValidateAnswer(ctx, answer); err != nil {
 		return err
 	}
+	if err := s.evaluate(ctx, answer); err != nil {
+		return err
+	}
+
+	// Record the locale the question was shown in, which is the session's
+	if answer.Locale == "" {
+		session, err := s.sessionRepo.GetByID(ctx, answer.SessionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %d", ErrSessionNotFound, answer.SessionID)
+		}
+		if err != nil {
+			return err
+		}
+		answer.Locale = session.Locale
+	}
 
 	answer.CreatedAt = time.Now()
 	answer.UpdatedAt = time.Now()
@@ -85,6 +114,24 @@ func (s *answerService) GetAnswersByQuestion(ctx context.Context, questionID uin
 	return s.answerRepo.GetByQuestionID(ctx, questionID)
 }
 
+// GetAnswersAcrossVersions returns the answers to a question and to all of its earlier
+// and later published versions
+func (s *answerService) GetAnswersAcrossVersions(ctx context.Context, questionID uint) ([]models.Answer, error) {
+	if questionID == 0 {
+		return nil, errors.New("invalid question ID")
+	}
+
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	if question.LineageID == 0 {
+		// Never published, so there is only this version
+		return s.answerRepo.GetByQuestionID(ctx, questionID)
+	}
+	return s.answerRepo.GetByLineageID(ctx, question.LineageID)
+}
+
 func (s *answerService) UpdateAnswer(ctx context.Context, answer *models.Answer) error {
 	if answer == nil || answer.AnswerID == 0 {
 		return errors.New("invalid answer provided")
@@ -129,17 +176,164 @@ func (s *answerService) ValidateAnswer(ctx context.Context, answer *models.Answe
 		return errors.New("nil answer provided")
 	}
 
-	// Check if the question exists
-	// This would require a method to get question by ID
-
-	// Validate the response data format based on question type
-	// For example, for multiple choice, ensure the selected option exists
-
 	// Validate response data is valid JSON
 	var jsonData interface{}
 	if err := json.Unmarshal([]byte(answer.ResponseData), &jsonData); err != nil {
 		return errors.New("invalid response data format: " + err.Error())
 	}
 
+	// Validate the response data format based on question type, then the conductor's rules
+	question, err := s.questionRepo.GetByID(ctx, answer.QuestionID)
+	if err != nil {
+		return err
+	}
+	return checkAnswer(question, []byte(answer.ResponseData))
+}
+
+// checkAnswer checks answer data against its question's type and then the conductor's
+// rules. Answers that do not fit are returned as *AnswerError.
+func checkAnswer(question *models.Question, data []byte) error {
+	err := validateResponse(question, data)
+	if err == nil {
+		err = checkValidationRules(question.ValidationRules, data)
+	}
+	if errors.Is(err, ErrInvalidAnswer) {
+		return &AnswerError{
+			QuestionID: question.QuestionID,
+			Message:    strings.TrimPrefix(err.Error(), ErrInvalidAnswer.Error()+": "),
+		}
+	}
+	return err
+}
+
+// SubmissionError is returned by ValidateSubmission with one entry per answer that does
+// not fit its question and per mandatory question the participant's path left unanswered
+type SubmissionError struct {
+	Errors []*AnswerError
+}
+
+func (e *SubmissionError) Error() string {
+	if len(e.Errors) == 0 {
+		return ErrInvalidAnswer.Error()
+	}
+	msg := e.Errors[0].Error()
+	if len(e.Errors) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Errors)-1)
+	}
+	return msg
+}
+
+func (e *SubmissionError) Unwrap() error {
+	return ErrInvalidAnswer
+}
+
+// ValidateSubmission checks a session's final answers against the survey version the
+// session is pinned to, the way CreateAnswer checks a single answer, and requires an
+// answer to every mandatory question on the path those answers take through the
+// survey's branching. Nothing is stored. The Participants service calls it before it
+// stores a submission.
+func (s *answerService) ValidateSubmission(ctx context.Context, sessionID uint, answers []models.Answer) error {
+	if sessionID == 0 {
+		return errors.New("invalid session ID")
+	}
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %d", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return err
+	}
+	engine, err := s.branching.SessionEngine(ctx, session.SurveyID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	var errs []*AnswerError
+	failed := make(map[uint]bool)
+	given := make(SessionAnswers, len(answers))
+	for _, answer := range answers {
+		question, ok := engine.questions[answer.QuestionID]
+		if !ok {
+			errs = append(errs, &AnswerError{QuestionID: answer.QuestionID, Message: "is not a question of this survey"})
+			failed[answer.QuestionID] = true
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(answer.ResponseData), &value); err != nil {
+			errs = append(errs, &AnswerError{QuestionID: answer.QuestionID, Message: "the answer is not valid JSON"})
+			failed[answer.QuestionID] = true
+			continue
+		}
+		given[answer.QuestionID] = value
+
+		err := checkAnswer(&question, []byte(answer.ResponseData))
+		var invalid *AnswerError
+		if errors.As(err, &invalid) {
+			errs = append(errs, invalid)
+			failed[answer.QuestionID] = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	path, err := engine.Simulate(given)
+	if err != nil {
+		return err
+	}
+	for _, questionID := range path.UnansweredMandatory {
+		if !failed[questionID] {
+			errs = append(errs, &AnswerError{QuestionID: questionID, Message: "is required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &SubmissionError{Errors: errs}
+	}
 	return nil
 }
+
+// evaluate runs a CODE answer against its question's tests and records the results in
+// answer.Evaluation. Other answers are left as they are.
+func (s *answerService) evaluate(ctx context.Context, answer *models.Answer) error {
+	question, err := s.questionRepo.GetByID(ctx, answer.QuestionID)
+	if err != nil {
+		return err
+	}
+	evaluation, err := evaluateCode(ctx, s.evaluator, question, []byte(answer.ResponseData))
+	if err != nil {
+		return err
+	}
+	if evaluation != "" {
+		answer.Evaluation = evaluation
+	}
+	return nil
+}
+
+// evaluateCode runs a CODE answer against its question's tests and returns the results
+// as stored in Answer.Evaluation: "" for other questions and skipped optional ones
+func evaluateCode(ctx context.Context, evaluator CodeEvaluator, question *models.Question, responseData []byte) (string, error) {
+	if question.QuestionType != QuestionTypeCode {
+		return "", nil
+	}
+
+	code, err := parseCodeAnswer(question, responseData)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(code) == "" {
+		// Skipped optional question: nothing to run
+		return "", nil
+	}
+
+	evaluation, err := evaluator.Evaluate(ctx, question, code)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(evaluation)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
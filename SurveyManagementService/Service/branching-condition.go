@@ -0,0 +1,389 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported condition operators. Conditions are stored on BranchingRule.Condition
+// as JSON documents, for example:
+//
+//	{"op": "equals", "question_id": 3, "value": "Yes"}
+//	{"op": "and", "conditions": [{"op": "answered", "question_id": 4}, {"op": "greater_than", "question_id": 5, "value": 3}]}
+const (
+	OpAlways      = "always"
+	OpEquals      = "equals"
+	OpNotEquals   = "not_equals"
+	OpContains    = "contains"
+	OpGreaterThan = "greater_than"
+	OpLessThan    = "less_than"
+	OpIn          = "in"
+	OpAnswered    = "answered"
+	OpSkipped     = "skipped"
+	OpAnd         = "and"
+	OpOr          = "or"
+)
+
+var ErrInvalidCondition = errors.New("invalid branching condition")
+
+// SessionAnswers holds the answers given so far in a session, keyed by question ID.
+// Values are decoded JSON: strings, float64 numbers, bools or []interface{}.
+type SessionAnswers map[uint]interface{}
+
+// SessionAnswersFromDraft converts a participant draft (question IDs as string keys)
+// into SessionAnswers, ignoring keys that are not numeric question IDs.
+func SessionAnswersFromDraft(draft map[string]interface{}) SessionAnswers {
+	answers := make(SessionAnswers, len(draft))
+	for key, value := range draft {
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		answers[uint(id)] = value
+	}
+	return answers
+}
+
+// Condition is a node of a parsed branching condition tree.
+type Condition interface {
+	Evaluate(answers SessionAnswers) bool
+	// QuestionIDs returns the questions whose answers the condition reads.
+	QuestionIDs() []uint
+	String() string
+}
+
+// AlwaysCondition matches unconditionally. It is used for default routes.
+type AlwaysCondition struct{}
+
+// ComparisonCondition compares the answer to a question against a constant value.
+type ComparisonCondition struct {
+	Operator   string
+	QuestionID uint
+	Value      interface{}
+}
+
+// PresenceCondition checks whether a question was answered or skipped.
+type PresenceCondition struct {
+	Operator   string
+	QuestionID uint
+}
+
+// GroupCondition combines child conditions with AND or OR.
+type GroupCondition struct {
+	Operator   string
+	Conditions []Condition
+}
+
+// conditionNode is the JSON representation of a condition.
+type conditionNode struct {
+	Op         string          `json:"op"`
+	QuestionID uint            `json:"question_id,omitempty"`
+	Value      interface{}     `json:"value,omitempty"`
+	Conditions []conditionNode `json:"conditions,omitempty"`
+}
+
+// ParseCondition parses a JSON condition document. An empty string parses to AlwaysCondition.
+func ParseCondition(raw string) (Condition, error) {
+	if strings.TrimSpace(raw) == "" {
+		return AlwaysCondition{}, nil
+	}
+
+	var node conditionNode
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCondition, err)
+	}
+	return buildCondition(node)
+}
+
+// MarshalCondition encodes a condition back into its canonical JSON form.
+func MarshalCondition(cond Condition) (string, error) {
+	data, err := json.Marshal(toNode(cond))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func buildCondition(node conditionNode) (Condition, error) {
+	switch node.Op {
+	case OpAlways:
+		return AlwaysCondition{}, nil
+	case OpEquals, OpNotEquals, OpContains, OpGreaterThan, OpLessThan, OpIn:
+		if node.QuestionID == 0 {
+			return nil, fmt.Errorf("%w: %q requires question_id", ErrInvalidCondition, node.Op)
+		}
+		if node.Value == nil {
+			return nil, fmt.Errorf("%w: %q requires value", ErrInvalidCondition, node.Op)
+		}
+		switch node.Op {
+		case OpGreaterThan, OpLessThan:
+			if _, ok := toNumber(node.Value); !ok {
+				return nil, fmt.Errorf("%w: %q requires a numeric value", ErrInvalidCondition, node.Op)
+			}
+		case OpIn:
+			if _, ok := node.Value.([]interface{}); !ok {
+				return nil, fmt.Errorf("%w: %q requires an array value", ErrInvalidCondition, node.Op)
+			}
+		}
+		return ComparisonCondition{Operator: node.Op, QuestionID: node.QuestionID, Value: node.Value}, nil
+	case OpAnswered, OpSkipped:
+		if node.QuestionID == 0 {
+			return nil, fmt.Errorf("%w: %q requires question_id", ErrInvalidCondition, node.Op)
+		}
+		return PresenceCondition{Operator: node.Op, QuestionID: node.QuestionID}, nil
+	case OpAnd, OpOr:
+		if len(node.Conditions) == 0 {
+			return nil, fmt.Errorf("%w: %q requires at least one condition", ErrInvalidCondition, node.Op)
+		}
+		children := make([]Condition, 0, len(node.Conditions))
+		for _, child := range node.Conditions {
+			cond, err := buildCondition(child)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, cond)
+		}
+		return GroupCondition{Operator: node.Op, Conditions: children}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidCondition, node.Op)
+	}
+}
+
+func toNode(cond Condition) conditionNode {
+	switch c := cond.(type) {
+	case ComparisonCondition:
+		return conditionNode{Op: c.Operator, QuestionID: c.QuestionID, Value: c.Value}
+	case PresenceCondition:
+		return conditionNode{Op: c.Operator, QuestionID: c.QuestionID}
+	case GroupCondition:
+		node := conditionNode{Op: c.Operator}
+		for _, child := range c.Conditions {
+			node.Conditions = append(node.Conditions, toNode(child))
+		}
+		return node
+	default:
+		return conditionNode{Op: OpAlways}
+	}
+}
+
+func (AlwaysCondition) Evaluate(answers SessionAnswers) bool { return true }
+func (AlwaysCondition) QuestionIDs() []uint                  { return nil }
+func (AlwaysCondition) String() string                       { return "always" }
+
+func (c ComparisonCondition) Evaluate(answers SessionAnswers) bool {
+	answer, ok := answers[c.QuestionID]
+	if !ok || !isAnswered(answer) {
+		// Only not_equals can hold for a question that was never answered
+		return c.Operator == OpNotEquals
+	}
+
+	switch c.Operator {
+	case OpEquals:
+		return answerEquals(answer, c.Value)
+	case OpNotEquals:
+		return !answerEquals(answer, c.Value)
+	case OpContains:
+		return answerContains(answer, c.Value)
+	case OpGreaterThan, OpLessThan:
+		got, ok := toNumber(answer)
+		if !ok {
+			return false
+		}
+		want, _ := toNumber(c.Value)
+		if c.Operator == OpGreaterThan {
+			return got > want
+		}
+		return got < want
+	case OpIn:
+		set, _ := c.Value.([]interface{})
+		for _, item := range answerValues(answer) {
+			for _, candidate := range set {
+				if scalarEquals(item, candidate) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func (c ComparisonCondition) QuestionIDs() []uint { return []uint{c.QuestionID} }
+
+func (c ComparisonCondition) String() string {
+	value, _ := json.Marshal(c.Value)
+	return fmt.Sprintf("Q%d %s %s", c.QuestionID, c.Operator, value)
+}
+
+func (c PresenceCondition) Evaluate(answers SessionAnswers) bool {
+	answer, ok := answers[c.QuestionID]
+	answered := ok && isAnswered(answer)
+	if c.Operator == OpAnswered {
+		return answered
+	}
+	return !answered
+}
+
+func (c PresenceCondition) QuestionIDs() []uint { return []uint{c.QuestionID} }
+
+func (c PresenceCondition) String() string {
+	return fmt.Sprintf("Q%d %s", c.QuestionID, c.Operator)
+}
+
+func (c GroupCondition) Evaluate(answers SessionAnswers) bool {
+	for _, child := range c.Conditions {
+		matched := child.Evaluate(answers)
+		if c.Operator == OpAnd && !matched {
+			return false
+		}
+		if c.Operator == OpOr && matched {
+			return true
+		}
+	}
+	return c.Operator == OpAnd
+}
+
+func (c GroupCondition) QuestionIDs() []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, child := range c.Conditions {
+		for _, id := range child.QuestionIDs() {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (c GroupCondition) String() string {
+	parts := make([]string, len(c.Conditions))
+	for i, child := range c.Conditions {
+		parts[i] = child.String()
+	}
+	return "(" + strings.Join(parts, " "+strings.ToUpper(c.Operator)+" ") + ")"
+}
+
+// isAnswered reports whether an answer value carries a response.
+func isAnswered(answer interface{}) bool {
+	switch v := answer.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(v) != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// answerValues flattens an answer into its individual selected values.
+func answerValues(answer interface{}) []interface{} {
+	if list, ok := answer.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{answer}
+}
+
+// answerEquals compares scalars directly and treats arrays as unordered sets.
+func answerEquals(answer, value interface{}) bool {
+	got := answerValues(answer)
+	want := answerValues(value)
+	if len(got) != len(want) {
+		return false
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if scalarEquals(g, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func answerContains(answer, value interface{}) bool {
+	if text, ok := answer.(string); ok {
+		return strings.Contains(strings.ToLower(text), strings.ToLower(scalarString(value)))
+	}
+	for _, item := range answerValues(answer) {
+		if scalarEquals(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarEquals compares two scalars numerically when both are numbers, otherwise by string form.
+func scalarEquals(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	return scalarString(a) == scalarString(b)
+}
+
+func scalarString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// RemapCondition rewrites the question IDs a condition reads through mapping. It reports
+// false when a referenced question has no entry in mapping.
+func RemapCondition(cond Condition, mapping map[uint]uint) (Condition, bool) {
+	switch c := cond.(type) {
+	case ComparisonCondition:
+		id, ok := mapping[c.QuestionID]
+		c.QuestionID = id
+		return c, ok
+	case PresenceCondition:
+		id, ok := mapping[c.QuestionID]
+		c.QuestionID = id
+		return c, ok
+	case GroupCondition:
+		children := make([]Condition, len(c.Conditions))
+		for i, child := range c.Conditions {
+			remapped, ok := RemapCondition(child, mapping)
+			if !ok {
+				return nil, false
+			}
+			children[i] = remapped
+		}
+		return GroupCondition{Operator: c.Operator, Conditions: children}, true
+	}
+	return cond, true
+}
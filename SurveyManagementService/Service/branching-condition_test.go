@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		answers SessionAnswers
+		want    bool
+		wantErr bool
+	}{
+		{name: "empty is always", raw: "", want: true},
+		{name: "always", raw: `{"op": "always"}`, want: true},
+		{name: "equals string", raw: `{"op": "equals", "question_id": 1, "value": "Yes"}`, answers: SessionAnswers{1: "Yes"}, want: true},
+		{name: "equals other string", raw: `{"op": "equals", "question_id": 1, "value": "Yes"}`, answers: SessionAnswers{1: "No"}, want: false},
+		{name: "equals unanswered", raw: `{"op": "equals", "question_id": 1, "value": "Yes"}`, want: false},
+		{name: "not_equals unanswered", raw: `{"op": "not_equals", "question_id": 1, "value": "Yes"}`, want: true},
+		{name: "contains in list", raw: `{"op": "contains", "question_id": 1, "value": "Go"}`, answers: SessionAnswers{1: []interface{}{"Rust", "Go"}}, want: true},
+		{name: "greater_than", raw: `{"op": "greater_than", "question_id": 1, "value": 17}`, answers: SessionAnswers{1: float64(18)}, want: true},
+		{name: "less_than", raw: `{"op": "less_than", "question_id": 1, "value": 17}`, answers: SessionAnswers{1: float64(18)}, want: false},
+		{name: "in", raw: `{"op": "in", "question_id": 1, "value": ["a", "b"]}`, answers: SessionAnswers{1: "b"}, want: true},
+		{name: "answered", raw: `{"op": "answered", "question_id": 1}`, answers: SessionAnswers{1: "x"}, want: true},
+		{name: "skipped empty string", raw: `{"op": "skipped", "question_id": 1}`, answers: SessionAnswers{1: ""}, want: true},
+		{
+			name:    "and",
+			raw:     `{"op": "and", "conditions": [{"op": "answered", "question_id": 1}, {"op": "equals", "question_id": 2, "value": "x"}]}`,
+			answers: SessionAnswers{1: "y", 2: "z"},
+			want:    false,
+		},
+		{
+			name:    "or",
+			raw:     `{"op": "or", "conditions": [{"op": "answered", "question_id": 1}, {"op": "equals", "question_id": 2, "value": "x"}]}`,
+			answers: SessionAnswers{2: "x"},
+			want:    true,
+		},
+		{name: "not JSON", raw: `{"op":`, wantErr: true},
+		{name: "unknown operator", raw: `{"op": "between", "question_id": 1}`, wantErr: true},
+		{name: "missing question", raw: `{"op": "equals", "value": "Yes"}`, wantErr: true},
+		{name: "missing value", raw: `{"op": "equals", "question_id": 1}`, wantErr: true},
+		{name: "non-numeric bound", raw: `{"op": "greater_than", "question_id": 1, "value": "ten"}`, wantErr: true},
+		{name: "in without array", raw: `{"op": "in", "question_id": 1, "value": "a"}`, wantErr: true},
+		{name: "empty group", raw: `{"op": "and", "conditions": []}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := ParseCondition(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCondition) {
+					t.Fatalf("ParseCondition(%s) error = %v, want ErrInvalidCondition", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCondition(%s) error = %v", tt.raw, err)
+			}
+			if got := cond.Evaluate(tt.answers); got != tt.want {
+				t.Errorf("Evaluate(%v) = %v, want %v", tt.answers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchingEngineNext(t *testing.T) {
+	questions := []models.Question{
+		{QuestionID: 10, Position: 1},
+		{QuestionID: 20, Position: 2},
+		{QuestionID: 30, Position: 3},
+		{QuestionID: 40, Position: 4},
+	}
+	rules := []models.BranchingRule{
+		// Fallback checked after the more specific rule below
+		{RuleID: 1, SourceQuestionID: 10, TargetQuestionID: 30, Condition: `{"op": "answered", "question_id": 10}`, Priority: 2},
+		{RuleID: 2, SourceQuestionID: 10, TargetQuestionID: 40, Condition: `{"op": "equals", "question_id": 10, "value": "skip"}`, Priority: 1},
+		{RuleID: 3, SourceQuestionID: 30, TargetQuestionID: 0, Condition: `{"op": "equals", "question_id": 30, "value": "stop"}`},
+	}
+	engine, err := NewBranchingEngine(questions, rules)
+	if err != nil {
+		t.Fatalf("NewBranchingEngine: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		answers  SessionAnswers
+		current  uint
+		wantNext uint
+		wantEnd  bool
+		wantRule uint
+	}{
+		{name: "start", current: 0, wantNext: 10},
+		{name: "no rule matches", current: 10, wantNext: 20},
+		{name: "lower priority first", answers: SessionAnswers{10: "skip"}, current: 10, wantNext: 40, wantRule: 2},
+		{name: "next rule when first fails", answers: SessionAnswers{10: "other"}, current: 10, wantNext: 30, wantRule: 1},
+		{name: "rule ends survey", answers: SessionAnswers{30: "stop"}, current: 30, wantEnd: true, wantRule: 3},
+		{name: "falls through in order", answers: SessionAnswers{30: "go"}, current: 30, wantNext: 40},
+		{name: "last question ends survey", current: 40, wantEnd: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Next(tt.answers, tt.current)
+			if err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+			if got.NextQuestionID != tt.wantNext || got.EndOfSurvey != tt.wantEnd {
+				t.Errorf("Next = (%d, end %v), want (%d, end %v)", got.NextQuestionID, got.EndOfSurvey, tt.wantNext, tt.wantEnd)
+			}
+			var rule uint
+			if got.Rule != nil {
+				rule = got.Rule.RuleID
+			}
+			if rule != tt.wantRule {
+				t.Errorf("Next fired rule %d, want %d", rule, tt.wantRule)
+			}
+		})
+	}
+
+	if _, err := engine.Next(nil, 99); !errors.Is(err, ErrQuestionNotInSurvey) {
+		t.Errorf("Next from unknown question error = %v, want ErrQuestionNotInSurvey", err)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Supported question flow export formats
+const (
+	FlowFormatDOT     = "dot"
+	FlowFormatMermaid = "mermaid"
+)
+
+var ErrUnsupportedFlowFormat = errors.New("unsupported flow format")
+
+// maxFlowLabelLength keeps question texts readable inside diagram nodes
+const maxFlowLabelLength = 60
+
+// Edges returns every transition of the survey flow in evaluation order.
+func (e *BranchingEngine) Edges() []FlowEdge {
+	return pathEdges(e.order, e.rules)
+}
+
+// RenderFlow renders the survey flow in the requested format.
+func (e *BranchingEngine) RenderFlow(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FlowFormatDOT:
+		return e.RenderDOT(), nil
+	case FlowFormatMermaid:
+		return e.RenderMermaid(), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFlowFormat, format)
+}
+
+// RenderDOT renders the survey flow as a Graphviz digraph. Rule edges are labelled with
+// their priority and condition; default in-order routes are dashed.
+func (e *BranchingEngine) RenderDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph survey {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, id := range e.order {
+		q := e.questions[id]
+		attrs := ""
+		if q.Mandatory {
+			attrs = ", penwidth=2"
+		}
+		fmt.Fprintf(&b, "  q%d [label=\"%s\"%s];\n", id, dotEscape(flowNodeLabel(id, q.QuestionText, q.Mandatory)), attrs)
+	}
+	b.WriteString("  end [label=\"End\", shape=doublecircle];\n")
+
+	for _, edge := range e.Edges() {
+		if edge.Rule == nil {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed, label=\"otherwise\"];\n", dotNode(edge.From), dotNode(edge.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=\"%s\"];\n", dotNode(edge.From), dotNode(edge.To), dotEscape(flowEdgeLabel(edge)))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// RenderMermaid renders the survey flow as a Mermaid flowchart
+func (e *BranchingEngine) RenderMermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	for _, id := range e.order {
+		q := e.questions[id]
+		fmt.Fprintf(&b, "  q%d[\"%s\"]\n", id, mermaidEscape(flowNodeLabel(id, q.QuestionText, q.Mandatory)))
+	}
+	b.WriteString("  end_node((End))\n")
+
+	for _, edge := range e.Edges() {
+		if edge.Rule == nil {
+			fmt.Fprintf(&b, "  %s -.->|otherwise| %s\n", mermaidNode(edge.From), mermaidNode(edge.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", mermaidNode(edge.From), mermaidEscape(flowEdgeLabel(edge)), mermaidNode(edge.To))
+	}
+	return b.String()
+}
+
+func flowNodeLabel(id uint, text string, mandatory bool) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxFlowLabelLength {
+		text = string(runes[:maxFlowLabelLength-1]) + "…"
+	}
+	label := fmt.Sprintf("Q%d: %s", id, text)
+	if mandatory {
+		label += " *"
+	}
+	return label
+}
+
+func flowEdgeLabel(edge FlowEdge) string {
+	return fmt.Sprintf("(%d) %s", edge.Rule.Priority, edge.Condition.String())
+}
+
+func dotNode(id uint) string {
+	if id == endNode {
+		return "end"
+	}
+	return fmt.Sprintf("q%d", id)
+}
+
+func mermaidNode(id uint) string {
+	if id == endNode {
+		return "end_node"
+	}
+	return fmt.Sprintf("q%d", id)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s)
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "\n", " ").Replace(s)
+}
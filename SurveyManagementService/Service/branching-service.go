@@ -1,42 +1,537 @@
 package service
 
 import (
-    "context"
-    "errors"
-    
-    "github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
 )
 
+var ErrQuestionNotInSurvey = errors.New("question does not belong to this survey")
 
 type BranchingService struct {
-    questionRepo repository.QuestionRepository
+	questionRepo repository.QuestionRepository
+	surveyRepo   repository.SurveyRepository
+	ruleRepo     repository.BranchingRuleRepository
+	sessionRepo  repository.SurveySessionRepository
+	versionRepo  repository.SurveyVersionRepository
 }
 
-func NewBranchingService(repo repository.QuestionRepository) *BranchingService {
-    return &BranchingService{
-        questionRepo: repo,
-    }
+func NewBranchingService(questionRepo repository.QuestionRepository, surveyRepo repository.SurveyRepository, ruleRepo repository.BranchingRuleRepository, sessionRepo repository.SurveySessionRepository, versionRepo repository.SurveyVersionRepository) *BranchingService {
+	return &BranchingService{
+		questionRepo: questionRepo,
+		surveyRepo:   surveyRepo,
+		ruleRepo:     ruleRepo,
+		sessionRepo:  sessionRepo,
+		versionRepo:  versionRepo,
+	}
 }
 
+// BranchingRule is the builder-facing form of a rule, also stored as a JSON array in Question.BranchingLogic.
+// A DestinationQuestionID of 0 ends the survey. When DestinationQuestionKey is set it takes
+// precedence over DestinationQuestionID, so the rule survives republishing.
 type BranchingRule struct {
-	SourceQuestionID      uint        `json:"source_question_id"`
-	DestinationQuestionID uint        `json:"destination_question_id"`
-	Condition            string      `json:"condition"`
-	Value                interface{} `json:"value"`
+	SourceQuestionID       uint            `json:"source_question_id"`
+	DestinationQuestionID  uint            `json:"destination_question_id"`
+	DestinationQuestionKey string          `json:"destination_question_key,omitempty"`
+	Condition              json.RawMessage `json:"condition,omitempty"`
+	Priority               int             `json:"priority"`
+}
+
+// Destination resolves the rule's target question, looking DestinationQuestionKey up in keys
+func (r BranchingRule) Destination(keys map[string]uint) (uint, error) {
+	if r.DestinationQuestionKey == "" {
+		return r.DestinationQuestionID, nil
+	}
+	key, err := NormalizeQuestionKey(r.DestinationQuestionKey)
+	if err != nil {
+		return 0, err
+	}
+	id, ok := keys[key]
+	if !ok {
+		return 0, fmt.Errorf("%w: no question has key %q", ErrInvalidCondition, key)
+	}
+	return id, nil
+}
+
+// ConditionString returns the condition document, accepting both a JSON object and a JSON-encoded string.
+func (r BranchingRule) ConditionString() (string, error) {
+	raw := strings.TrimSpace(string(r.Condition))
+	if raw == "" || raw == "null" {
+		return "", nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidCondition, err)
+		}
+		return s, nil
+	}
+	return raw, nil
+}
+
+// ParseBranchingLogic decodes the JSON array stored in Question.BranchingLogic. An empty string means no rules.
+func ParseBranchingLogic(logic string) ([]BranchingRule, error) {
+	if strings.TrimSpace(logic) == "" {
+		return nil, nil
+	}
+	var rules []BranchingRule
+	if err := json.Unmarshal([]byte(logic), &rules); err != nil {
+		return nil, fmt.Errorf("%w: branching logic must be a JSON array of rules: %v", ErrInvalidCondition, err)
+	}
+	return rules, nil
+}
+
+// NextQuestionResult describes where a participant goes after a question.
+// Rule is nil when no rule matched and the survey continued in question order.
+type NextQuestionResult struct {
+	NextQuestionID uint                  `json:"next_question_id"`
+	EndOfSurvey    bool                  `json:"end_of_survey"`
+	Rule           *models.BranchingRule `json:"rule,omitempty"`
+}
+
+type compiledRule struct {
+	rule      models.BranchingRule
+	condition Condition
+}
+
+// BranchingEngine evaluates a survey's branching rules. It is built once from the
+// questions and rules of a survey and holds no other state, so the builder and the
+// participant runtime always compute the same path for the same answers.
+type BranchingEngine struct {
+	order     []uint
+	index     map[uint]int
+	questions map[uint]models.Question
+	rules     map[uint][]compiledRule
+}
+
+// NewBranchingEngine parses every rule condition up front and fails on the first invalid one.
+func NewBranchingEngine(questions []models.Question, rules []models.BranchingRule) (*BranchingEngine, error) {
+	ordered := make([]models.Question, len(questions))
+	copy(ordered, questions)
+	sortQuestions(ordered)
+
+	engine := &BranchingEngine{
+		order:     make([]uint, len(ordered)),
+		index:     make(map[uint]int, len(ordered)),
+		questions: make(map[uint]models.Question, len(ordered)),
+		rules:     make(map[uint][]compiledRule),
+	}
+	for i, q := range ordered {
+		engine.order[i] = q.QuestionID
+		engine.index[q.QuestionID] = i
+		engine.questions[q.QuestionID] = q
+	}
+
+	for _, rule := range rules {
+		cond, err := ParseCondition(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rule.RuleID, err)
+		}
+		engine.rules[rule.SourceQuestionID] = append(engine.rules[rule.SourceQuestionID], compiledRule{rule: rule, condition: cond})
+	}
+	for source := range engine.rules {
+		list := engine.rules[source]
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].rule.Priority != list[j].rule.Priority {
+				return list[i].rule.Priority < list[j].rule.Priority
+			}
+			return list[i].rule.RuleID < list[j].rule.RuleID
+		})
+	}
+
+	return engine, nil
+}
+
+// sortQuestions puts questions in survey order: by position, then by creation for
+// questions from before positions were kept
+func sortQuestions(questions []models.Question) {
+	sort.SliceStable(questions, func(i, j int) bool {
+		if questions[i].Position != questions[j].Position {
+			return questions[i].Position < questions[j].Position
+		}
+		return questions[i].QuestionID < questions[j].QuestionID
+	})
 }
 
-type BranchingCondition struct {
-    Operator    string      `json:"operator"`
-    Value       interface{} `json:"value"`
-    NextQuestionID uint     `json:"next_question_id"`
+// Questions returns the question IDs in survey order.
+func (e *BranchingEngine) Questions() []uint {
+	return e.order
 }
 
+// Next returns the question that follows currentQuestionID. A currentQuestionID of 0 means
+// the participant has not started yet, so the first question is returned.
+func (e *BranchingEngine) Next(answers SessionAnswers, currentQuestionID uint) (*NextQuestionResult, error) {
+	if currentQuestionID == 0 {
+		if len(e.order) == 0 {
+			return &NextQuestionResult{EndOfSurvey: true}, nil
+		}
+		return &NextQuestionResult{NextQuestionID: e.order[0]}, nil
+	}
+
+	pos, ok := e.index[currentQuestionID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrQuestionNotInSurvey, currentQuestionID)
+	}
+
+	for _, compiled := range e.rules[currentQuestionID] {
+		if !compiled.condition.Evaluate(answers) {
+			continue
+		}
+		rule := compiled.rule
+		return &NextQuestionResult{
+			NextQuestionID: rule.TargetQuestionID,
+			EndOfSurvey:    rule.TargetQuestionID == 0,
+			Rule:           &rule,
+		}, nil
+	}
+
+	if pos+1 >= len(e.order) {
+		return &NextQuestionResult{EndOfSurvey: true}, nil
+	}
+	return &NextQuestionResult{NextQuestionID: e.order[pos+1]}, nil
+}
+
+// Simulation outcomes
+const (
+	SimulationCompleted = "COMPLETED"
+	SimulationLooped    = "LOOP_DETECTED"
+)
+
+// SimulationStep is one question shown during a simulated run, together with the
+// rule that decided where the participant went next.
+type SimulationStep struct {
+	Step           int                   `json:"step"`
+	QuestionID     uint                  `json:"question_id"`
+	QuestionText   string                `json:"question_text"`
+	Mandatory      bool                  `json:"mandatory"`
+	Answer         interface{}           `json:"answer"`
+	Answered       bool                  `json:"answered"`
+	Rule           *models.BranchingRule `json:"rule,omitempty"`
+	RuleSummary    string                `json:"rule_summary"`
+	NextQuestionID uint                  `json:"next_question_id"`
+}
+
+// SimulationResult is the full path a participant with the given answers would take.
+type SimulationResult struct {
+	Outcome             string           `json:"outcome"`
+	Steps               []SimulationStep `json:"steps"`
+	UnansweredMandatory []uint           `json:"unanswered_mandatory"`
+}
+
+// Simulate walks the survey from the first question using Next, exactly as the
+// participant runtime would, and records every step. A question seen twice stops
+// the walk, since the same answers would loop forever.
+func (e *BranchingEngine) Simulate(answers SessionAnswers) (*SimulationResult, error) {
+	result := &SimulationResult{Outcome: SimulationCompleted, Steps: []SimulationStep{}, UnansweredMandatory: []uint{}}
+	visited := make(map[uint]bool, len(e.order))
+
+	next, err := e.Next(answers, 0)
+	if err != nil {
+		return nil, err
+	}
+	for !next.EndOfSurvey {
+		current := next.NextQuestionID
+		if visited[current] {
+			result.Outcome = SimulationLooped
+			break
+		}
+		visited[current] = true
+
+		question := e.questions[current]
+		answer, ok := answers[current]
+		answered := ok && isAnswered(answer)
+		if question.Mandatory && !answered {
+			result.UnansweredMandatory = append(result.UnansweredMandatory, current)
+		}
+
+		next, err = e.Next(answers, current)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := "no rule matched, continued in question order"
+		if next.Rule != nil {
+			summary = fmt.Sprintf("rule %d matched", next.Rule.RuleID)
+			if cond, err := ParseCondition(next.Rule.Condition); err == nil {
+				summary += ": " + cond.String()
+			}
+		}
+
+		result.Steps = append(result.Steps, SimulationStep{
+			Step:           len(result.Steps) + 1,
+			QuestionID:     current,
+			QuestionText:   question.QuestionText,
+			Mandatory:      question.Mandatory,
+			Answer:         answer,
+			Answered:       answered,
+			Rule:           next.Rule,
+			RuleSummary:    summary,
+			NextQuestionID: next.NextQuestionID,
+		})
+	}
+
+	return result, nil
+}
+
+// SetBranchingLogic validates and replaces all rules leaving a question, and mirrors
+// them into Question.BranchingLogic in canonical form.
 func (s *BranchingService) SetBranchingLogic(ctx context.Context, questionID uint, rules []BranchingRule) error {
-    if questionID == 0 {
-        return errors.New("invalid question ID")
-    }
-    
-    // Convert rules to JSON string and update question
-    // Implementation depends on your JSON handling preferences
-    return nil
-}
\ No newline at end of file
+	if questionID == 0 {
+		return errors.New("invalid question ID")
+	}
+
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		return err
+	}
+
+	survey, err := s.surveyRepo.GetByID(ctx, question.SurveyID)
+	if err != nil {
+		return err
+	}
+	surveyQuestions := make(map[uint]bool, len(survey.Questions))
+	keys := make(map[string]uint, len(survey.Questions))
+	for _, q := range survey.Questions {
+		surveyQuestions[q.QuestionID] = true
+		if q.QuestionKey != "" {
+			keys[q.QuestionKey] = q.QuestionID
+		}
+	}
+
+	now := time.Now()
+	records := make([]models.BranchingRule, 0, len(rules))
+	canonical := make([]BranchingRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.SourceQuestionID != 0 && rule.SourceQuestionID != questionID {
+			return fmt.Errorf("%w: rule %d has source question %d, expected %d", ErrInvalidCondition, i, rule.SourceQuestionID, questionID)
+		}
+		target, err := rule.Destination(keys)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		if target == questionID {
+			return fmt.Errorf("%w: rule %d routes question %d to itself", ErrInvalidCondition, i, questionID)
+		}
+		if target != 0 && !surveyQuestions[target] {
+			return fmt.Errorf("%w: rule %d targets question %d outside this survey", ErrInvalidCondition, i, target)
+		}
+
+		raw, err := rule.ConditionString()
+		if err != nil {
+			return err
+		}
+		cond, err := ParseCondition(raw)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		for _, ref := range cond.QuestionIDs() {
+			if !surveyQuestions[ref] {
+				return fmt.Errorf("%w: rule %d reads question %d outside this survey", ErrInvalidCondition, i, ref)
+			}
+		}
+		condJSON, err := MarshalCondition(cond)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, models.BranchingRule{
+			SurveyID:         question.SurveyID,
+			SourceQuestionID: questionID,
+			TargetQuestionID: target,
+			Condition:        condJSON,
+			Priority:         rule.Priority,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+		canonical = append(canonical, BranchingRule{
+			SourceQuestionID:       questionID,
+			DestinationQuestionID:  target,
+			DestinationQuestionKey: rule.DestinationQuestionKey,
+			Condition:              json.RawMessage(condJSON),
+			Priority:               rule.Priority,
+		})
+	}
+
+	if err := s.ruleRepo.ReplaceForQuestion(ctx, questionID, records); err != nil {
+		return err
+	}
+
+	logic := ""
+	if len(canonical) > 0 {
+		data, err := json.Marshal(canonical)
+		if err != nil {
+			return err
+		}
+		logic = string(data)
+	}
+	question.BranchingLogic = logic
+	question.UpdatedAt = now
+	return s.questionRepo.Update(ctx, question)
+}
+
+// GetRules returns the persisted rules of a survey in evaluation order
+func (s *BranchingService) GetRules(ctx context.Context, surveyID uint) ([]models.BranchingRule, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+	return s.ruleRepo.GetBySurveyID(ctx, surveyID)
+}
+
+// Engine loads a survey and builds its branching engine
+func (s *BranchingService) Engine(ctx context.Context, surveyID uint) (*BranchingEngine, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.ruleRepo.GetBySurveyID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return NewBranchingEngine(survey.Questions, rules)
+}
+
+// SessionEngine builds the branching engine of the survey version a session is pinned to,
+// so republishing the survey never changes a participant's path mid-session. Sessions
+// without a pinned version use the survey as it is now. Sessions of other surveys look
+// not found.
+func (s *BranchingService) SessionEngine(ctx context.Context, surveyID, sessionID uint) (*BranchingEngine, error) {
+	session, snapshot, err := loadSessionSnapshot(ctx, s.sessionRepo, s.versionRepo, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.SurveyID != surveyID {
+		return nil, fmt.Errorf("%w: %d", ErrSessionNotFound, sessionID)
+	}
+	if snapshot == nil {
+		return s.Engine(ctx, surveyID)
+	}
+	return NewBranchingEngine(snapshot.Questions, snapshot.BranchingRules)
+}
+
+// NextQuestion evaluates the survey's rules against the answers given so far. With a
+// session ID the rules of the session's pinned version are used.
+func (s *BranchingService) NextQuestion(ctx context.Context, surveyID, sessionID uint, answers SessionAnswers, currentQuestionID uint) (*NextQuestionResult, error) {
+	var engine *BranchingEngine
+	var err error
+	if sessionID != 0 {
+		engine, err = s.SessionEngine(ctx, surveyID, sessionID)
+	} else {
+		engine, err = s.Engine(ctx, surveyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return engine.Next(answers, currentQuestionID)
+}
+
+// MaterializeRules turns the BranchingLogic of a draft question into BranchingRule rows,
+// translating draft question IDs through idMap and destination keys through keys (key to
+// draft question ID). Rules that point at or read questions missing from idMap are
+// skipped and reported back as warnings.
+func MaterializeRules(surveyID, sourceQuestionID uint, logic string, idMap map[uint]uint, keys map[string]uint) ([]models.BranchingRule, string, []string, error) {
+	rules, err := ParseBranchingLogic(logic)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	now := time.Now()
+	var records []models.BranchingRule
+	var canonical []BranchingRule
+	var warnings []string
+	for i, rule := range rules {
+		destination, err := rule.Destination(keys)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		target := uint(0)
+		if destination != 0 {
+			mapped, ok := idMap[destination]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("rule %d targets unknown question %d", i, destination))
+				continue
+			}
+			target = mapped
+		}
+
+		raw, err := rule.ConditionString()
+		if err != nil {
+			return nil, "", nil, err
+		}
+		cond, err := ParseCondition(raw)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		cond, ok := RemapCondition(cond, idMap)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("rule %d reads an unknown question", i))
+			continue
+		}
+		condJSON, err := MarshalCondition(cond)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		records = append(records, models.BranchingRule{
+			SurveyID:         surveyID,
+			SourceQuestionID: sourceQuestionID,
+			TargetQuestionID: target,
+			Condition:        condJSON,
+			Priority:         rule.Priority,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+		canonical = append(canonical, BranchingRule{
+			SourceQuestionID:       sourceQuestionID,
+			DestinationQuestionID:  target,
+			DestinationQuestionKey: rule.DestinationQuestionKey,
+			Condition:              json.RawMessage(condJSON),
+			Priority:               rule.Priority,
+		})
+	}
+
+	if len(canonical) == 0 {
+		return records, "", warnings, nil
+	}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return records, string(data), warnings, nil
+}
+
+// ValidateSurvey runs the branching graph analysis on a survey's persisted rules
+func (s *BranchingService) ValidateSurvey(ctx context.Context, surveyID uint) (*BranchingReport, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.ruleRepo.GetBySurveyID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateBranching(survey.Questions, rules), nil
+}
+
+// Simulate returns the question sequence a participant with the given answers would see
+func (s *BranchingService) Simulate(ctx context.Context, surveyID uint, answers SessionAnswers) (*SimulationResult, error) {
+	engine, err := s.Engine(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Simulate(answers)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// Branching validation issue codes
+const (
+	IssueInvalidCondition  = "INVALID_CONDITION"
+	IssueDanglingTarget    = "DANGLING_TARGET"
+	IssueDanglingSource    = "DANGLING_SOURCE"
+	IssueDanglingReference = "DANGLING_REFERENCE"
+	IssueCycle             = "CYCLE"
+	IssueUnreachable       = "UNREACHABLE_QUESTION"
+	IssueMandatorySkipped  = "MANDATORY_SKIPPED"
+	IssueDanglingPipe      = "DANGLING_PIPE"
+	IssuePipeNotAnswered   = "PIPE_NOT_ANSWERED"
+	IssuePipeOptional      = "PIPE_FROM_OPTIONAL"
+	IssuePipeSamePage      = "PIPE_SAME_PAGE"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// endNode stands for "survey finished" in the question graph
+const endNode uint = 0
+
+// BranchingIssue is a single finding of the branching graph analysis.
+type BranchingIssue struct {
+	Code        string `json:"code"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	QuestionIDs []uint `json:"question_ids,omitempty"`
+	RuleID      uint   `json:"rule_id,omitempty"`
+}
+
+// BranchingReport is the result of analysing a survey's branching rules as a graph.
+// Valid is false when at least one issue has error severity.
+type BranchingReport struct {
+	Valid  bool             `json:"valid"`
+	Issues []BranchingIssue `json:"issues"`
+}
+
+func (r *BranchingReport) add(issue BranchingIssue) {
+	if issue.Severity == SeverityError {
+		r.Valid = false
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// BranchingValidationError is returned by publish operations when the report has errors.
+type BranchingValidationError struct {
+	Report *BranchingReport
+}
+
+func (e *BranchingValidationError) Error() string {
+	for _, issue := range e.Report.Issues {
+		if issue.Severity == SeverityError {
+			return "branching validation failed: " + issue.Message
+		}
+	}
+	return "branching validation failed"
+}
+
+// ValidateBranching analyses the survey path graph. Questions are nodes, each rule adds an
+// edge from its source to its target, and every question also falls through to the next
+// question unless one of its rules matches unconditionally. The analysis is structural:
+// it assumes every condition can be satisfied. Answers piped into question and option
+// text must come from a question on an earlier page that every path to the piping
+// question passes first.
+func ValidateBranching(questions []models.Question, rules []models.BranchingRule) *BranchingReport {
+	report := &BranchingReport{Valid: true, Issues: []BranchingIssue{}}
+
+	ordered := make([]models.Question, len(questions))
+	copy(ordered, questions)
+	sortQuestions(ordered)
+
+	known := make(map[uint]bool, len(ordered))
+	for _, q := range ordered {
+		known[q.QuestionID] = true
+	}
+
+	// Keep only rules that can take part in the graph, reporting the rest
+	bySource := make(map[uint][]compiledRule)
+	for _, rule := range rules {
+		if !known[rule.SourceQuestionID] {
+			report.add(BranchingIssue{
+				Code:     IssueDanglingSource,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("rule %d leaves question %d, which does not exist", rule.RuleID, rule.SourceQuestionID),
+				RuleID:   rule.RuleID,
+			})
+			continue
+		}
+		if rule.TargetQuestionID != endNode && !known[rule.TargetQuestionID] {
+			report.add(BranchingIssue{
+				Code:        IssueDanglingTarget,
+				Severity:    SeverityError,
+				Message:     fmt.Sprintf("rule %d on question %d targets question %d, which does not exist", rule.RuleID, rule.SourceQuestionID, rule.TargetQuestionID),
+				QuestionIDs: []uint{rule.SourceQuestionID},
+				RuleID:      rule.RuleID,
+			})
+			continue
+		}
+		cond, err := ParseCondition(rule.Condition)
+		if err != nil {
+			report.add(BranchingIssue{
+				Code:        IssueInvalidCondition,
+				Severity:    SeverityError,
+				Message:     fmt.Sprintf("rule %d on question %d: %v", rule.RuleID, rule.SourceQuestionID, err),
+				QuestionIDs: []uint{rule.SourceQuestionID},
+				RuleID:      rule.RuleID,
+			})
+			continue
+		}
+		for _, ref := range cond.QuestionIDs() {
+			if !known[ref] {
+				report.add(BranchingIssue{
+					Code:        IssueDanglingReference,
+					Severity:    SeverityError,
+					Message:     fmt.Sprintf("rule %d on question %d reads the answer to question %d, which does not exist", rule.RuleID, rule.SourceQuestionID, ref),
+					QuestionIDs: []uint{rule.SourceQuestionID},
+					RuleID:      rule.RuleID,
+				})
+			}
+		}
+		bySource[rule.SourceQuestionID] = append(bySource[rule.SourceQuestionID], compiledRule{rule: rule, condition: cond})
+	}
+
+	if len(ordered) == 0 {
+		return report
+	}
+
+	graph := buildPathGraph(ordered, bySource)
+	start := ordered[0].QuestionID
+
+	for _, cycle := range findCycles(ordered, graph) {
+		report.add(BranchingIssue{
+			Code:        IssueCycle,
+			Severity:    SeverityError,
+			Message:     fmt.Sprintf("questions %v form a branching loop", cycle),
+			QuestionIDs: cycle,
+		})
+	}
+
+	reachable := reachableFrom(start, graph, 0)
+	for _, q := range ordered {
+		if !reachable[q.QuestionID] {
+			report.add(BranchingIssue{
+				Code:        IssueUnreachable,
+				Severity:    SeverityWarning,
+				Message:     fmt.Sprintf("question %d can never be reached", q.QuestionID),
+				QuestionIDs: []uint{q.QuestionID},
+			})
+		}
+	}
+
+	for _, q := range ordered {
+		if !q.Mandatory || q.QuestionID == start || !reachable[q.QuestionID] {
+			continue
+		}
+		// A mandatory question is skippable if the end is reachable without passing it
+		if reachableFrom(start, graph, q.QuestionID)[endNode] {
+			report.add(BranchingIssue{
+				Code:        IssueMandatorySkipped,
+				Severity:    SeverityWarning,
+				Message:     fmt.Sprintf("mandatory question %d is skipped on at least one path", q.QuestionID),
+				QuestionIDs: []uint{q.QuestionID},
+			})
+		}
+	}
+
+	checkPipes(report, ordered, graph, start, reachable)
+
+	return report
+}
+
+// checkPipes reports piped answers that may not exist yet when their question is shown:
+// unknown keys, sources on the piping question's own page, which is shown at once and
+// may be shuffled, and sources that some path reaches the piping question without passing
+func checkPipes(report *BranchingReport, ordered []models.Question, graph map[uint][]uint, start uint, reachable map[uint]bool) {
+	byKey := make(map[string]models.Question, len(ordered))
+	for _, q := range ordered {
+		if q.QuestionKey != "" {
+			byKey[q.QuestionKey] = q
+		}
+	}
+
+	for _, q := range ordered {
+		for _, key := range questionPipes(q) {
+			source, ok := byKey[key]
+			switch {
+			case !ok:
+				report.add(BranchingIssue{
+					Code:        IssueDanglingPipe,
+					Severity:    SeverityError,
+					Message:     fmt.Sprintf("question %d pipes the answer to %q, which is not a question key of the survey", q.QuestionID, key),
+					QuestionIDs: []uint{q.QuestionID},
+				})
+			case source.QuestionID == q.QuestionID:
+				report.add(BranchingIssue{
+					Code:        IssuePipeNotAnswered,
+					Severity:    SeverityError,
+					Message:     fmt.Sprintf("question %d pipes its own answer", q.QuestionID),
+					QuestionIDs: []uint{q.QuestionID},
+				})
+			case source.PageID != nil && q.PageID != nil && *source.PageID == *q.PageID:
+				report.add(BranchingIssue{
+					Code:        IssuePipeSamePage,
+					Severity:    SeverityError,
+					Message:     fmt.Sprintf("question %d pipes the answer to question %d on the same page; the source must be on an earlier page", q.QuestionID, source.QuestionID),
+					QuestionIDs: []uint{q.QuestionID, source.QuestionID},
+				})
+			case source.QuestionID != start && reachable[q.QuestionID] && reachableFrom(start, graph, source.QuestionID)[q.QuestionID]:
+				// Some path reaches the question without passing its source
+				report.add(BranchingIssue{
+					Code:        IssuePipeNotAnswered,
+					Severity:    SeverityError,
+					Message:     fmt.Sprintf("question %d pipes the answer to question %d, which is not asked before it on every path", q.QuestionID, source.QuestionID),
+					QuestionIDs: []uint{q.QuestionID, source.QuestionID},
+				})
+			case !source.Mandatory:
+				report.add(BranchingIssue{
+					Code:        IssuePipeOptional,
+					Severity:    SeverityWarning,
+					Message:     fmt.Sprintf("question %d pipes the answer to optional question %d, which may be blank", q.QuestionID, source.QuestionID),
+					QuestionIDs: []uint{q.QuestionID, source.QuestionID},
+				})
+			}
+		}
+	}
+}
+
+// buildPathGraph returns the successors of every question, with endNode for "finish"
+func buildPathGraph(ordered []models.Question, bySource map[uint][]compiledRule) map[uint][]uint {
+	order := make([]uint, len(ordered))
+	for i, q := range ordered {
+		order[i] = q.QuestionID
+	}
+
+	graph := make(map[uint][]uint, len(ordered))
+	for _, edge := range pathEdges(order, bySource) {
+		graph[edge.From] = append(graph[edge.From], edge.To)
+	}
+	return graph
+}
+
+// FlowEdge is a possible transition between two questions. To is endNode (0) when
+// the transition finishes the survey; Rule is nil for the default in-order route.
+type FlowEdge struct {
+	From uint
+	To   uint
+	Rule *models.BranchingRule
+	// Condition is nil for the default route
+	Condition Condition
+}
+
+// pathEdges lists the transitions out of every question in evaluation order: each rule
+// in priority order, then the default route to the next question unless an
+// unconditional rule shadows it
+func pathEdges(order []uint, bySource map[uint][]compiledRule) []FlowEdge {
+	var edges []FlowEdge
+	for i, id := range order {
+		list := bySource[id]
+		sort.SliceStable(list, func(a, b int) bool {
+			if list[a].rule.Priority != list[b].rule.Priority {
+				return list[a].rule.Priority < list[b].rule.Priority
+			}
+			return list[a].rule.RuleID < list[b].rule.RuleID
+		})
+
+		fallsThrough := true
+		for _, compiled := range list {
+			rule := compiled.rule
+			edges = append(edges, FlowEdge{From: id, To: rule.TargetQuestionID, Rule: &rule, Condition: compiled.condition})
+			if _, always := compiled.condition.(AlwaysCondition); always {
+				// Later rules and the default route are shadowed
+				fallsThrough = false
+				break
+			}
+		}
+		if fallsThrough {
+			next := endNode
+			if i+1 < len(order) {
+				next = order[i+1]
+			}
+			edges = append(edges, FlowEdge{From: id, To: next})
+		}
+	}
+	return edges
+}
+
+// reachableFrom walks the graph from start, never entering the excluded question
+func reachableFrom(start uint, graph map[uint][]uint, excluded uint) map[uint]bool {
+	seen := map[uint]bool{start: true}
+	queue := []uint{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range graph[current] {
+			if seen[next] || (excluded != 0 && next == excluded) {
+				continue
+			}
+			seen[next] = true
+			if next != endNode {
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// findCycles returns each distinct cycle once, as the question IDs along it
+func findCycles(ordered []models.Question, graph map[uint][]uint) [][]uint {
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[uint]int, len(ordered))
+	var stack []uint
+	var cycles [][]uint
+
+	var visit func(id uint)
+	visit = func(id uint) {
+		state[id] = onStack
+		stack = append(stack, id)
+		for _, next := range graph[id] {
+			if next == endNode {
+				continue
+			}
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case onStack:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := make([]uint, len(stack)-i)
+						copy(cycle, stack[i:])
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, q := range ordered {
+		if state[q.QuestionID] == unvisited {
+			visit(q.QuestionID)
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+func TestValidateBranching(t *testing.T) {
+	questions := []models.Question{
+		{QuestionID: 1, Position: 1},
+		{QuestionID: 2, Position: 2, Mandatory: true},
+		{QuestionID: 3, Position: 3},
+	}
+
+	tests := []struct {
+		name      string
+		rules     []models.BranchingRule
+		wantValid bool
+		wantCodes []string
+	}{
+		{name: "no rules", wantValid: true},
+		{
+			name:      "conditional jump",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 1, TargetQuestionID: 3, Condition: `{"op": "equals", "question_id": 1, "value": "a"}`}},
+			wantValid: true,
+			wantCodes: []string{IssueMandatorySkipped},
+		},
+		{
+			name:      "unconditional jump",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 1, TargetQuestionID: 3, Condition: `{"op": "always"}`}},
+			wantValid: true,
+			wantCodes: []string{IssueUnreachable},
+		},
+		{
+			name:      "end of survey",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 3, TargetQuestionID: 0, Condition: `{"op": "answered", "question_id": 3}`}},
+			wantValid: true,
+		},
+		{
+			name:      "dangling target",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 1, TargetQuestionID: 9, Condition: `{"op": "always"}`}},
+			wantCodes: []string{IssueDanglingTarget},
+		},
+		{
+			name:      "dangling source",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 9, TargetQuestionID: 2, Condition: `{"op": "always"}`}},
+			wantCodes: []string{IssueDanglingSource},
+		},
+		{
+			name:      "invalid condition",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 1, TargetQuestionID: 2, Condition: `{"op": "between"}`}},
+			wantCodes: []string{IssueInvalidCondition},
+		},
+		{
+			name:      "condition reads unknown question",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 1, TargetQuestionID: 3, Condition: `{"op": "answered", "question_id": 9}`}},
+			wantCodes: []string{IssueDanglingReference},
+		},
+		{
+			name:      "cycle",
+			rules:     []models.BranchingRule{{RuleID: 1, SourceQuestionID: 3, TargetQuestionID: 1, Condition: `{"op": "equals", "question_id": 3, "value": "again"}`}},
+			wantCodes: []string{IssueCycle},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidateBranching(questions, tt.rules)
+			if report.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (issues %+v)", report.Valid, tt.wantValid, report.Issues)
+			}
+			codes := make(map[string]bool)
+			for _, issue := range report.Issues {
+				codes[issue.Code] = true
+			}
+			for _, code := range tt.wantCodes {
+				if !codes[code] {
+					t.Errorf("missing %s issue, got %+v", code, report.Issues)
+				}
+			}
+			if len(tt.wantCodes) == 0 && len(report.Issues) != 0 {
+				t.Errorf("want no issues, got %+v", report.Issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+// codeScorerBatch is how many pending answers one claim takes
+const codeScorerBatch = 50
+
+// codeScorerClaimTimeout is how long a claim holds before another scorer may take the
+// answer over, e.g. after the replica that claimed it stopped
+const codeScorerClaimTimeout = 15 * time.Minute
+
+// codeEvaluationClaim is stored as an answer's evaluation while a scorer runs it
+type codeEvaluationClaim struct {
+	Status    string    `json:"status"`
+	ClaimedAt time.Time `json:"claimed_at"`
+}
+
+// CodeAnswerScorer runs CODE answers stored without an evaluation against their
+// question's tests. The Participants service stores submitted answers directly, without
+// running code, so they are scored here. Each answer is claimed before it runs, so
+// replicas never run the same answer, and answers that cannot be scored get an ERROR
+// evaluation instead of being retried forever.
+type CodeAnswerScorer struct {
+	answerRepo   repository.AnswerRepository
+	questionRepo repository.QuestionRepository
+	evaluator    CodeEvaluator
+	interval     time.Duration
+}
+
+func NewCodeAnswerScorer(answerRepo repository.AnswerRepository, questionRepo repository.QuestionRepository, evaluator CodeEvaluator, interval time.Duration) *CodeAnswerScorer {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &CodeAnswerScorer{
+		answerRepo:   answerRepo,
+		questionRepo: questionRepo,
+		evaluator:    evaluator,
+		interval:     interval,
+	}
+}
+
+// Run ticks until ctx is cancelled
+func (s *CodeAnswerScorer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Tick(ctx)
+		}
+	}
+}
+
+// Tick claims and scores pending CODE answers until none are left. If the sandbox is
+// unavailable, or a question cannot be loaded, the claim is released and the answer is
+// tried again on a later tick; if the sandbox is unavailable the tick stops early.
+func (s *CodeAnswerScorer) Tick(ctx context.Context) {
+	scored := 0
+	for {
+		now := time.Now().UTC()
+		claim, err := json.Marshal(codeEvaluationClaim{Status: CodeEvaluationPending, ClaimedAt: now})
+		if err != nil {
+			log.Printf("Code scorer: failed to encode a claim: %v", err)
+			return
+		}
+		answers, err := s.answerRepo.ClaimUnevaluatedCodeAnswers(ctx, string(claim), now.Add(-codeScorerClaimTimeout), codeScorerBatch)
+		if err != nil {
+			log.Printf("Code scorer: failed to claim pending answers: %v", err)
+			return
+		}
+
+		released := false // Released answers would be claimed again straight away
+		for i, answer := range answers {
+			evaluation, err := s.score(ctx, answer)
+			if errors.Is(err, ErrSandboxUnavailable) || ctx.Err() != nil {
+				log.Printf("Code scorer: stopping: %v", err)
+				s.release(ctx, answers[i:])
+				return
+			}
+			if err != nil {
+				log.Printf("Code scorer: failed to score answer %d, will retry: %v", answer.AnswerID, err)
+				s.release(ctx, answers[i:i+1])
+				released = true
+				continue
+			}
+
+			stored, err := s.answerRepo.SetEvaluation(ctx, answer.AnswerID, evaluation)
+			if err != nil {
+				log.Printf("Code scorer: failed to store the evaluation of answer %d: %v", answer.AnswerID, err)
+			} else if stored {
+				scored++
+			}
+		}
+		if len(answers) < codeScorerBatch || released {
+			break
+		}
+	}
+	if scored > 0 {
+		log.Printf("Code scorer: scored %d answers", scored)
+	}
+}
+
+// score returns the evaluation to store for a claimed answer. Answers that can never be
+// scored, e.g. because they do not parse or their question is gone, get an ERROR
+// evaluation; an error means the answer should be tried again later.
+func (s *CodeAnswerScorer) score(ctx context.Context, answer models.Answer) (string, error) {
+	question, err := s.questionRepo.GetByID(ctx, answer.QuestionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return failedEvaluation("", err)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	evaluation, err := evaluateCode(ctx, s.evaluator, question, []byte(answer.ResponseData))
+	if errors.Is(err, ErrSandboxUnavailable) || ctx.Err() != nil {
+		return "", err
+	}
+	if err != nil {
+		log.Printf("Code scorer: answer %d cannot be scored: %v", answer.AnswerID, err)
+		return failedEvaluation(question.CodeLanguage, err)
+	}
+	if evaluation == "" {
+		return failedEvaluation(question.CodeLanguage, errors.New("no code to run"))
+	}
+	return evaluation, nil
+}
+
+// release clears the claims on answers so a later tick picks them up again
+func (s *CodeAnswerScorer) release(ctx context.Context, answers []models.Answer) {
+	ctx = context.WithoutCancel(ctx)
+	for _, answer := range answers {
+		if err := s.answerRepo.ReleaseClaim(ctx, answer.AnswerID); err != nil {
+			log.Printf("Code scorer: failed to release answer %d: %v", answer.AnswerID, err)
+		}
+	}
+}
+
+// failedEvaluation is the ERROR evaluation stored for an answer that cannot be scored
+func failedEvaluation(language string, cause error) (string, error) {
+	data, err := json.Marshal(CodeEvaluation{
+		Status:      CodeEvaluationError,
+		Error:       cause.Error(),
+		Language:    language,
+		Tests:       []CodeTestOutcome{},
+		EvaluatedAt: time.Now(),
+	})
+	return string(data), err
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// Defaults for SandboxConfig
+const (
+	DefaultSandboxTimeLimit   = 5 * time.Second
+	DefaultSandboxMemoryMB    = 256
+	DefaultSandboxOutputLimit = 64 * 1024
+	DefaultSandboxConcurrency = 2
+	DefaultSandboxProcesses   = 32
+)
+
+// DefaultSandboxReadOnlyPaths are the host directories a sandbox sees, read-only, by
+// default: enough for the interpreters and their libraries
+var DefaultSandboxReadOnlyPaths = []string{"/usr", "/lib", "/lib64", "/bin", "/etc/alternatives", "/etc/ssl"}
+
+// Outcomes of running a submission against one test
+const (
+	CodeTestPassed       = "PASSED"
+	CodeTestFailed       = "FAILED"        // Ran, but printed something else
+	CodeTestRuntimeError = "RUNTIME_ERROR" // Exited with an error, e.g. an exception or running out of memory
+	CodeTestTimeout      = "TIMEOUT"
+)
+
+// Statuses of a CodeEvaluation without test results. Evaluations whose tests ran have
+// no status.
+const (
+	CodeEvaluationPending = "PENDING" // Claimed by the background scorer
+	CodeEvaluationError   = "ERROR"   // Cannot be scored, e.g. the answer does not parse; Error says why
+)
+
+// ErrSandboxUnavailable means submissions cannot be run safely on this host, e.g. the
+// interpreter or bubblewrap is missing, no sandbox user is configured or namespaces are
+// not permitted
+var ErrSandboxUnavailable = errors.New("code sandbox unavailable")
+
+// codeLanguage describes how to run a submission in one language
+type codeLanguage struct {
+	file        string   // Name the submission is saved as
+	interpreter string   // Looked up on PATH unless SandboxConfig.Interpreters overrides it
+	args        []string // Before the file name
+}
+
+var codeLanguages = map[string]codeLanguage{
+	"python":     {file: "main.py", interpreter: "python3", args: []string{"-I", "-B"}},
+	"javascript": {file: "main.js", interpreter: "node"},
+}
+
+// SandboxConfig limits every run of a submission
+type SandboxConfig struct {
+	TimeLimit     time.Duration     // Wall-clock time per test; CPU time is capped at the same
+	MemoryMB      int               // Data segment cap per run
+	OutputLimit   int               // Bytes of stdout and stderr kept per run; the rest is discarded
+	Concurrency   int               // Runs at a time across all evaluations
+	Interpreters  map[string]string // Interpreter path by language, overriding PATH lookup; must be under ReadOnlyPaths
+	WorkDirParent string            // Where per-run directories are created; empty means the system temp dir
+
+	// Dedicated unprivileged host user and group runs are started as. They must not be
+	// the service's, and the service needs CAP_SETUID, CAP_SETGID and CAP_KILL to use
+	// them. Runs are refused while unset.
+	UID uint32
+	GID uint32
+
+	Processes     int      // Processes and threads per run
+	Bwrap         string   // Path of bubblewrap; empty means PATH lookup
+	ReadOnlyPaths []string // Host directories visible in the sandbox, read-only; empty means DefaultSandboxReadOnlyPaths
+}
+
+// CodeEvaluation is stored as Answer.Evaluation for a CODE answer
+type CodeEvaluation struct {
+	Status      string            `json:"status,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Language    string            `json:"language"`
+	Passed      int               `json:"passed"`
+	Total       int               `json:"total"`
+	Tests       []CodeTestOutcome `json:"tests"`
+	EvaluatedAt time.Time         `json:"evaluated_at"`
+}
+
+// CodeTestOutcome is the result of one test. Output is left out for hidden tests.
+type CodeTestOutcome struct {
+	TestID     uint   `json:"test_id"`
+	Name       string `json:"name,omitempty"`
+	Hidden     bool   `json:"hidden"`
+	Status     string `json:"status"`
+	Passed     bool   `json:"passed"`
+	DurationMS int64  `json:"duration_ms"`
+	Output     string `json:"output,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CodeEvaluator runs CODE submissions against their question's tests
+type CodeEvaluator interface {
+	Evaluate(ctx context.Context, question *models.Question, code string) (*CodeEvaluation, error)
+}
+
+// codeSandbox runs each test as a local subprocess with no network, its own process
+// tree, a minimal read-only filesystem and resource limits
+type codeSandbox struct {
+	config SandboxConfig
+	slots  chan struct{}
+}
+
+func NewCodeSandbox(config SandboxConfig) CodeEvaluator {
+	if config.TimeLimit <= 0 {
+		config.TimeLimit = DefaultSandboxTimeLimit
+	}
+	if config.MemoryMB <= 0 {
+		config.MemoryMB = DefaultSandboxMemoryMB
+	}
+	if config.OutputLimit <= 0 {
+		config.OutputLimit = DefaultSandboxOutputLimit
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultSandboxConcurrency
+	}
+	if config.Processes <= 0 {
+		config.Processes = DefaultSandboxProcesses
+	}
+	if len(config.ReadOnlyPaths) == 0 {
+		config.ReadOnlyPaths = DefaultSandboxReadOnlyPaths
+	}
+	return &codeSandbox{
+		config: config,
+		slots:  make(chan struct{}, config.Concurrency),
+	}
+}
+
+// SupportsCodeLanguage reports whether CODE questions can be written in a language
+func SupportsCodeLanguage(language string) bool {
+	_, ok := codeLanguages[language]
+	return ok
+}
+
+func codeLanguageNames() []string {
+	names := make([]string, 0, len(codeLanguages))
+	for name := range codeLanguages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *codeSandbox) Evaluate(ctx context.Context, question *models.Question, code string) (*CodeEvaluation, error) {
+	lang, ok := codeLanguages[question.CodeLanguage]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported language %q", ErrInvalidQuestionConfig, question.CodeLanguage)
+	}
+	interpreter, err := s.interpreter(question.CodeLanguage, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(s.config.WorkDirParent, "submission-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	// The sandbox user only needs to read the submission
+	if err := os.Chmod(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, lang.file), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+
+	evaluation := &CodeEvaluation{
+		Language: question.CodeLanguage,
+		Total:    len(question.CodeTests),
+		Tests:    make([]CodeTestOutcome, 0, len(question.CodeTests)),
+	}
+	for _, test := range question.CodeTests {
+		outcome, err := s.runTest(ctx, dir, interpreter, lang, test)
+		if err != nil {
+			return nil, err
+		}
+		if outcome.Passed {
+			evaluation.Passed++
+		}
+		evaluation.Tests = append(evaluation.Tests, outcome)
+	}
+	evaluation.EvaluatedAt = time.Now()
+	return evaluation, nil
+}
+
+func (s *codeSandbox) interpreter(name string, lang codeLanguage) (string, error) {
+	if path := s.config.Interpreters[name]; path != "" {
+		return path, nil
+	}
+	path, err := exec.LookPath(lang.interpreter)
+	if err != nil {
+		return "", fmt.Errorf("%w: no %s interpreter: %v", ErrSandboxUnavailable, name, err)
+	}
+	return path, nil
+}
+
+// runTest runs the submission once with the test's input. It only returns an error
+// when the sandbox itself fails; anything the submission does is an outcome.
+func (s *codeSandbox) runTest(ctx context.Context, dir, interpreter string, lang codeLanguage, test models.CodeTest) (CodeTestOutcome, error) {
+	outcome := CodeTestOutcome{TestID: test.TestID, Hidden: test.Hidden}
+	if !test.Hidden {
+		outcome.Name = test.Name
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return outcome, ctx.Err()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.config.TimeLimit)
+	defer cancel()
+
+	// Limits are applied by a shell before it becomes the interpreter: data segment (KB),
+	// CPU seconds, file size (KB), processes and open files
+	cpuSeconds := int(s.config.TimeLimit.Seconds()) + 1
+	argv := []string{"/bin/sh", "-c", `ulimit -d "$1" && ulimit -t "$2" && ulimit -f "$3" && ulimit -u "$4" && ulimit -n 64 && shift 4 && exec "$@"`, "sandbox",
+		strconv.Itoa(s.config.MemoryMB * 1024), strconv.Itoa(cpuSeconds), strconv.Itoa(s.config.OutputLimit / 1024), strconv.Itoa(s.config.Processes),
+		interpreter}
+	argv = append(argv, lang.args...)
+	argv = append(argv, lang.file)
+
+	cmd, err := sandboxCommand(runCtx, s.config, dir, argv)
+	if err != nil {
+		return outcome, err
+	}
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/tmp", "LANG=C.UTF-8"}
+	cmd.Stdin = strings.NewReader(test.Input)
+	stdout := &limitedBuffer{limit: s.config.OutputLimit}
+	stderr := &limitedBuffer{limit: s.config.OutputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return outcome, fmt.Errorf("%w: %v", ErrSandboxUnavailable, err)
+	}
+	err = cmd.Wait()
+	outcome.DurationMS = time.Since(start).Milliseconds()
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		outcome.Status = CodeTestTimeout
+	case ctx.Err() != nil:
+		return outcome, ctx.Err()
+	case errors.As(err, &exitErr):
+		outcome.Status = CodeTestRuntimeError
+		// bwrap reports a submission killed by a signal as 128 plus the signal
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == 128+int(syscall.SIGXCPU) {
+			outcome.Status = CodeTestTimeout
+		}
+		if !test.Hidden {
+			outcome.Error = stderr.String()
+		}
+	case err != nil:
+		return outcome, err
+	case normalizeOutput(stdout.String()) == normalizeOutput(test.ExpectedOutput):
+		outcome.Status = CodeTestPassed
+		outcome.Passed = true
+	default:
+		outcome.Status = CodeTestFailed
+	}
+	if !test.Hidden {
+		outcome.Output = stdout.String()
+	}
+	return outcome, nil
+}
+
+// normalizeOutput makes outputs comparable across platforms: line endings are unified
+// and trailing whitespace, on each line and at the end, is ignored
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest, so a
+// runaway submission cannot exhaust the service's memory
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// sandboxWorkDir is where the submission's directory appears inside the sandbox
+const sandboxWorkDir = "/sandbox"
+
+// sandboxCommand prepares argv to run under bubblewrap in new user, mount, network, PID,
+// IPC, UTS and cgroup namespaces. The root is an empty tmpfs, remounted read-only, with
+// only config.ReadOnlyPaths, the submission's directory (read-only), a private /tmp, a
+// minimal /dev and a fresh /proc for the sandbox's own processes. bwrap is started as
+// the dedicated host user config.UID, never the service's, so a submission cannot read
+// or signal the service even if it escapes. It has no capabilities, sees only a
+// loopback interface, and everything it starts dies with it or with the service.
+func sandboxCommand(ctx context.Context, config SandboxConfig, dir string, argv []string) (*exec.Cmd, error) {
+	if config.UID == 0 || config.GID == 0 {
+		return nil, fmt.Errorf("%w: no dedicated sandbox user configured", ErrSandboxUnavailable)
+	}
+	if int(config.UID) == os.Getuid() {
+		return nil, fmt.Errorf("%w: the sandbox user must not be the service's own", ErrSandboxUnavailable)
+	}
+	bwrap := config.Bwrap
+	if bwrap == "" {
+		path, err := exec.LookPath("bwrap")
+		if err != nil {
+			return nil, fmt.Errorf("%w: bubblewrap not found: %v", ErrSandboxUnavailable, err)
+		}
+		bwrap = path
+	}
+
+	args := []string{
+		"--unshare-all", "--die-with-parent", "--new-session", "--cap-drop", "ALL",
+		"--uid", "65534", "--gid", "65534", "--hostname", "sandbox",
+	}
+	for _, path := range config.ReadOnlyPaths {
+		args = append(args, "--ro-bind-try", path, path)
+	}
+	args = append(args,
+		"--proc", "/proc",
+		"--dev", "/dev",
+		"--size", strconv.Itoa(config.MemoryMB*1024*1024), "--tmpfs", "/tmp",
+		"--ro-bind", dir, sandboxWorkDir,
+		"--chdir", sandboxWorkDir,
+		"--remount-ro", "/",
+		"--",
+	)
+	args = append(args, argv...)
+
+	cmd := exec.CommandContext(ctx, bwrap, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: config.UID, Gid: config.GID, Groups: []uint32{}},
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	return cmd, nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// sandboxWorkDir is where the submission's directory appears inside the sandbox
+const sandboxWorkDir = "/sandbox"
+
+// sandboxCommand refuses to run submissions: only Linux namespaces can isolate them
+func sandboxCommand(ctx context.Context, config SandboxConfig, dir string, argv []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("%w: requires Linux namespaces", ErrSandboxUnavailable)
+}
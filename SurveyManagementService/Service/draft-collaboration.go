@@ -0,0 +1,760 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// Collaboration message types. Clients send op, presence, lock and unlock; the server
+// sends the rest.
+const (
+	CollabWelcome  = "welcome"  // Sent once on joining: content, revision, presence and locks
+	CollabOp       = "op"       // A patch, from a client or as applied by the server
+	CollabAck      = "ack"      // The sender's op was applied as Revision
+	CollabReject   = "reject"   // The sender's op or lock request was refused; see Code
+	CollabSnapshot = "snapshot" // The draft was saved outside the channel; replace local content
+	CollabPresence = "presence" // Who is connected and which question each is editing
+	CollabLock     = "lock"
+	CollabUnlock   = "unlock"
+	CollabLocks    = "locks"  // Current question locks
+	CollabClosed   = "closed" // The draft is gone (published); the channel ends
+	CollabError    = "error"
+)
+
+// Codes of reject and error messages
+const (
+	CollabCodeConflict   = "DRAFT_REVISION_CONFLICT"
+	CollabCodeLocked     = "QUESTION_LOCKED"
+	CollabCodeInvalid    = "VALIDATION_ERROR"
+	CollabCodeBadPatch   = "INVALID_PATCH"
+	CollabCodePatch      = "PATCH_FAILED"
+	CollabCodeBadMessage = "INVALID_MESSAGE"
+	CollabCodeInternal   = "INTERNAL_ERROR"
+)
+
+// collabOutboxSize is how many messages may wait for a slow client before it is dropped.
+// A dropped client reconnects and starts again from a welcome.
+const collabOutboxSize = 64
+
+// CollabMessage is one message on a draft's collaboration channel. Fields are set
+// according to Type.
+type CollabMessage struct {
+	Type               string             `json:"type"`
+	ClientOpID         string             `json:"client_op_id,omitempty"` // Chosen by the client; echoed in ack and reject
+	ClientID           string             `json:"client_id,omitempty"`
+	UserID             uint               `json:"user_id,omitempty"`
+	BaseRevision       int                `json:"base_revision,omitempty"`
+	Revision           int                `json:"revision,omitempty"`
+	Format             string             `json:"format,omitempty"`
+	Patch              json.RawMessage    `json:"patch,omitempty"`
+	QuestionID         *uint              `json:"question_id,omitempty"`
+	Content            models.JSONContent `json:"content,omitempty"`
+	LastEditedQuestion uint               `json:"last_edited_question,omitempty"`
+	Presence           []EditorPresence   `json:"presence,omitempty"`
+	Locks              []QuestionLock     `json:"locks,omitempty"`
+	Code               string             `json:"code,omitempty"`
+	Message            string             `json:"message,omitempty"`
+	Errors             []DraftFieldError  `json:"errors,omitempty"`
+}
+
+// EditorPresence is one connection to a draft. QuestionID is the draft question_id the
+// user is editing, 0 for none.
+type EditorPresence struct {
+	ClientID   string    `json:"client_id"`
+	UserID     uint      `json:"user_id"`
+	QuestionID uint      `json:"question_id"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// QuestionLock reserves a draft question for one user. It is released on unlock or when
+// the connection that took it leaves.
+type QuestionLock struct {
+	QuestionID uint      `json:"question_id"`
+	UserID     uint      `json:"user_id"`
+	ClientID   string    `json:"client_id"`
+	LockedAt   time.Time `json:"locked_at"`
+}
+
+// QuestionLockedError is returned when a write would change a question another user
+// has locked
+type QuestionLockedError struct {
+	Lock QuestionLock
+}
+
+func (e *QuestionLockedError) Error() string {
+	return fmt.Sprintf("question %d is locked by user %d", e.Lock.QuestionID, e.Lock.UserID)
+}
+
+// CollabClient is one connection to a draft's channel. Messages for it are read from
+// Outbox, which is closed when the client leaves or falls too far behind.
+type CollabClient struct {
+	ID      string
+	UserID  uint
+	DraftID uint
+
+	questionID uint
+	joinedAt   time.Time
+	outbox     chan CollabMessage
+	closed     bool
+}
+
+// Outbox delivers the messages to write to the client's connection
+func (c *CollabClient) Outbox() <-chan CollabMessage {
+	return c.outbox
+}
+
+type collabRoom struct {
+	// apply orders operations: one is persisted and broadcast before the next starts
+	apply   sync.Mutex
+	clients map[string]*CollabClient
+	locks   map[uint]QuestionLock
+}
+
+// CollaborationHub runs a channel per draft for editing it together. The server orders
+// operations: each op names the revision it was made against, is applied with
+// PatchDraft, and is broadcast with the revision it produced. Ops against an older
+// revision are rejected; the client rebases on the ops it has received and resends.
+// Rooms live in memory, so all editors of a draft must reach the same instance.
+type CollaborationHub struct {
+	surveyService SurveyService
+
+	mu    sync.Mutex
+	rooms map[uint]*collabRoom
+}
+
+func NewCollaborationHub(surveyService SurveyService) *CollaborationHub {
+	return &CollaborationHub{
+		surveyService: surveyService,
+		rooms:         make(map[uint]*collabRoom),
+	}
+}
+
+// Join connects a user to a draft's channel. The client's first message is a welcome
+// with the draft's current content and revision.
+func (h *CollaborationHub) Join(ctx context.Context, draftID, userID uint) (*CollabClient, error) {
+	// No op may land between reading the snapshot and joining the broadcast
+	room := h.lockRoom(draftID)
+	defer room.apply.Unlock()
+
+	draft, err := h.surveyService.GetDraft(ctx, draftID)
+	if err != nil {
+		h.mu.Lock()
+		h.dropRoomIfEmpty(draftID, room)
+		h.mu.Unlock()
+		return nil, err
+	}
+
+	client := &CollabClient{
+		ID:       uuid.New().String(),
+		UserID:   userID,
+		DraftID:  draftID,
+		joinedAt: time.Now(),
+		outbox:   make(chan CollabMessage, collabOutboxSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room.clients[client.ID] = client
+	h.send(room, client, CollabMessage{
+		Type:               CollabWelcome,
+		ClientID:           client.ID,
+		UserID:             userID,
+		Revision:           draft.Revision,
+		Content:            draft.DraftContent,
+		LastEditedQuestion: draft.LastEditedQuestion,
+		Presence:           presenceOf(room),
+		Locks:              locksOf(room),
+	})
+	h.broadcast(room, CollabMessage{Type: CollabPresence, Presence: presenceOf(room)}, client.ID)
+	return client, nil
+}
+
+// Leave disconnects a client and releases its locks. It is safe to call more than once.
+func (h *CollaborationHub) Leave(client *CollabClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[client.DraftID]
+	if !ok {
+		return
+	}
+	// A client dropped for being slow is already out of the room but may still hold locks
+	h.disconnect(room, client)
+
+	released := false
+	for id, lock := range room.locks {
+		if lock.ClientID == client.ID {
+			delete(room.locks, id)
+			released = true
+		}
+	}
+	h.broadcast(room, CollabMessage{Type: CollabPresence, Presence: presenceOf(room)}, "")
+	if released {
+		h.broadcast(room, CollabMessage{Type: CollabLocks, Locks: locksOf(room)}, "")
+	}
+	h.dropRoomIfEmpty(client.DraftID, room)
+}
+
+// Handle processes one message from a client
+func (h *CollaborationHub) Handle(ctx context.Context, client *CollabClient, msg CollabMessage) {
+	switch msg.Type {
+	case CollabOp:
+		h.applyOp(ctx, client, msg)
+	case CollabPresence:
+		h.setPresence(client, msg.QuestionID)
+	case CollabLock:
+		h.lock(client, msg)
+	case CollabUnlock:
+		h.unlock(client, msg)
+	default:
+		h.Send(client, CollabMessage{Type: CollabError, Code: CollabCodeBadMessage, Message: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+// DraftSaved tells a draft's editors that it was saved outside the channel, e.g. by
+// PUT or a revision restore, and sends them the new content
+func (h *CollaborationHub) DraftSaved(draft *models.SurveyDraft, userID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[draft.DraftID]
+	if !ok {
+		return
+	}
+	h.broadcast(room, CollabMessage{
+		Type:               CollabSnapshot,
+		UserID:             userID,
+		Revision:           draft.Revision,
+		Content:            draft.DraftContent,
+		LastEditedQuestion: draft.LastEditedQuestion,
+	}, "")
+}
+
+// DraftClosed ends a draft's channel, e.g. because the draft was published
+func (h *CollaborationHub) DraftClosed(draftID uint, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[draftID]
+	if !ok {
+		return
+	}
+	h.broadcast(room, CollabMessage{Type: CollabClosed, Message: reason}, "")
+	for _, client := range room.clients {
+		h.disconnect(room, client)
+	}
+	delete(h.rooms, draftID)
+}
+
+// GuardWrite runs a write to a draft made outside the channel, e.g. by PUT, PATCH or a
+// revision restore, in turn with the channel's ops so that locks hold for it too.
+// touched lists the questions the write changes, given the draft as it stands; if
+// another user has locked one of them the write is refused with a *QuestionLockedError.
+// Drafts nobody is editing together have no locks, and write just runs.
+func (h *CollaborationHub) GuardWrite(ctx context.Context, draftID, userID uint, touched func(current *models.SurveyDraft) ([]uint, error), write func() error) error {
+	h.mu.Lock()
+	_, open := h.rooms[draftID]
+	h.mu.Unlock()
+	if !open {
+		return write()
+	}
+
+	room := h.lockRoom(draftID)
+	defer room.apply.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.dropRoomIfEmpty(draftID, room)
+		h.mu.Unlock()
+	}()
+
+	h.mu.Lock()
+	locked := len(room.locks) > 0
+	h.mu.Unlock()
+	if locked {
+		current, err := h.surveyService.GetDraft(ctx, draftID)
+		if err != nil {
+			return err
+		}
+		ids, err := touched(current)
+		if err != nil {
+			return err
+		}
+		h.mu.Lock()
+		for _, id := range ids {
+			if lock, ok := room.locks[id]; ok && lock.UserID != userID {
+				h.mu.Unlock()
+				return &QuestionLockedError{Lock: lock}
+			}
+		}
+		h.mu.Unlock()
+	}
+	return write()
+}
+
+func (h *CollaborationHub) applyOp(ctx context.Context, client *CollabClient, msg CollabMessage) {
+	reject := func(code, message string) CollabMessage {
+		return CollabMessage{Type: CollabReject, ClientOpID: msg.ClientOpID, Code: code, Message: message}
+	}
+	format := msg.Format
+	if format == "" {
+		format = PatchFormatJSONPatch
+	}
+	if len(msg.Patch) == 0 || msg.BaseRevision < 1 {
+		h.Send(client, reject(CollabCodeBadMessage, "op needs a patch and the base_revision it was made against"))
+		return
+	}
+
+	room := h.lockRoom(client.DraftID)
+	defer room.apply.Unlock()
+
+	h.mu.Lock()
+	member := room.clients[client.ID] == client
+	if !member {
+		h.dropRoomIfEmpty(client.DraftID, room)
+	}
+	h.mu.Unlock()
+	if !member {
+		return
+	}
+
+	draft, err := h.surveyService.GetDraft(ctx, client.DraftID)
+	if err != nil {
+		h.Send(client, reject(CollabCodeInternal, "Failed to load draft: "+err.Error()))
+		return
+	}
+	if draft.Revision != msg.BaseRevision {
+		rejected := reject(CollabCodeConflict, "op was made against an older revision; rebase and resend")
+		rejected.Revision = draft.Revision
+		h.Send(client, rejected)
+		return
+	}
+
+	touched, err := touchedQuestions(format, draft.DraftContent, msg.Patch)
+	if err != nil {
+		h.Send(client, reject(CollabCodeBadPatch, err.Error()))
+		return
+	}
+	h.mu.Lock()
+	for _, id := range touched {
+		if lock, locked := room.locks[id]; locked && lock.UserID != client.UserID {
+			h.mu.Unlock()
+			rejected := reject(CollabCodeLocked, (&QuestionLockedError{Lock: lock}).Error())
+			rejected.QuestionID = &lock.QuestionID
+			rejected.UserID = lock.UserID
+			h.Send(client, rejected)
+			return
+		}
+	}
+	var lastEdited *uint
+	if client.questionID != 0 {
+		question := client.questionID
+		lastEdited = &question
+	}
+	h.mu.Unlock()
+
+	saved, err := h.surveyService.PatchDraft(ctx, client.DraftID, format, msg.Patch, lastEdited, client.UserID, msg.BaseRevision)
+	if err != nil {
+		var conflict *DraftConflictError
+		var invalid *DraftValidationError
+		switch {
+		case errors.As(err, &conflict):
+			rejected := reject(CollabCodeConflict, err.Error())
+			rejected.Revision = conflict.Current
+			h.Send(client, rejected)
+		case errors.As(err, &invalid):
+			rejected := reject(CollabCodeInvalid, err.Error())
+			rejected.Errors = invalid.Errors
+			h.Send(client, rejected)
+		case errors.Is(err, ErrInvalidPatch):
+			h.Send(client, reject(CollabCodeBadPatch, err.Error()))
+		case errors.Is(err, ErrPatchFailed):
+			h.Send(client, reject(CollabCodePatch, err.Error()))
+		default:
+			log.Printf("Collaboration op on draft %d failed: %v", client.DraftID, err)
+			h.Send(client, reject(CollabCodeInternal, "Failed to apply op"))
+		}
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.send(room, client, CollabMessage{Type: CollabAck, ClientOpID: msg.ClientOpID, Revision: saved.Revision})
+	h.broadcast(room, CollabMessage{
+		Type:         CollabOp,
+		ClientID:     client.ID,
+		UserID:       client.UserID,
+		BaseRevision: msg.BaseRevision,
+		Revision:     saved.Revision,
+		Format:       format,
+		Patch:        msg.Patch,
+	}, client.ID)
+}
+
+func (h *CollaborationHub) setPresence(client *CollabClient, questionID *uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[client.DraftID]
+	if !ok {
+		return
+	}
+	client.questionID = 0
+	if questionID != nil {
+		client.questionID = *questionID
+	}
+	h.broadcast(room, CollabMessage{Type: CollabPresence, Presence: presenceOf(room)}, "")
+}
+
+func (h *CollaborationHub) lock(client *CollabClient, msg CollabMessage) {
+	if msg.QuestionID == nil || *msg.QuestionID == 0 {
+		h.Send(client, CollabMessage{Type: CollabReject, Code: CollabCodeBadMessage, Message: "lock needs a question_id"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[client.DraftID]
+	if !ok {
+		return
+	}
+	id := *msg.QuestionID
+	if lock, locked := room.locks[id]; locked {
+		if lock.UserID != client.UserID {
+			h.send(room, client, CollabMessage{
+				Type:       CollabReject,
+				Code:       CollabCodeLocked,
+				Message:    fmt.Sprintf("question %d is locked by user %d", id, lock.UserID),
+				QuestionID: &id,
+				UserID:     lock.UserID,
+			})
+			return
+		}
+	}
+	room.locks[id] = QuestionLock{QuestionID: id, UserID: client.UserID, ClientID: client.ID, LockedAt: time.Now()}
+	h.broadcast(room, CollabMessage{Type: CollabLocks, Locks: locksOf(room)}, "")
+}
+
+func (h *CollaborationHub) unlock(client *CollabClient, msg CollabMessage) {
+	if msg.QuestionID == nil {
+		h.Send(client, CollabMessage{Type: CollabReject, Code: CollabCodeBadMessage, Message: "unlock needs a question_id"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[client.DraftID]
+	if !ok {
+		return
+	}
+	lock, locked := room.locks[*msg.QuestionID]
+	if !locked || lock.UserID != client.UserID {
+		return
+	}
+	delete(room.locks, *msg.QuestionID)
+	h.broadcast(room, CollabMessage{Type: CollabLocks, Locks: locksOf(room)}, "")
+}
+
+// lockRoom returns the draft's room, creating it if needed, with its apply lock held
+func (h *CollaborationHub) lockRoom(draftID uint) *collabRoom {
+	for {
+		h.mu.Lock()
+		room, ok := h.rooms[draftID]
+		if !ok {
+			room = &collabRoom{
+				clients: make(map[string]*CollabClient),
+				locks:   make(map[uint]QuestionLock),
+			}
+			h.rooms[draftID] = room
+		}
+		h.mu.Unlock()
+
+		room.apply.Lock()
+		h.mu.Lock()
+		current := h.rooms[draftID] == room
+		h.mu.Unlock()
+		if current {
+			return room
+		}
+		// The room emptied and was dropped while we waited
+		room.apply.Unlock()
+	}
+}
+
+// Send queues one message for a client
+func (h *CollaborationHub) Send(client *CollabClient, msg CollabMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room, ok := h.rooms[client.DraftID]; ok {
+		h.send(room, client, msg)
+	}
+}
+
+// send queues a message for a client, dropping the client if its outbox is full.
+// Callers hold h.mu.
+func (h *CollaborationHub) send(room *collabRoom, client *CollabClient, msg CollabMessage) {
+	if client.closed {
+		return
+	}
+	select {
+	case client.outbox <- msg:
+	default:
+		log.Printf("Dropping slow collaboration client %s on draft %d", client.ID, client.DraftID)
+		h.disconnect(room, client)
+	}
+}
+
+// broadcast sends a message to every client in the room except the one with skipID.
+// Callers hold h.mu.
+func (h *CollaborationHub) broadcast(room *collabRoom, msg CollabMessage, skipID string) {
+	for id, client := range room.clients {
+		if id != skipID {
+			h.send(room, client, msg)
+		}
+	}
+}
+
+// disconnect removes a client from its room and closes its outbox. Callers hold h.mu.
+func (h *CollaborationHub) disconnect(room *collabRoom, client *CollabClient) {
+	if room.clients[client.ID] == client {
+		delete(room.clients, client.ID)
+	}
+	if !client.closed {
+		client.closed = true
+		close(client.outbox)
+	}
+}
+
+// dropRoomIfEmpty forgets a room nobody is in. Callers hold h.mu.
+func (h *CollaborationHub) dropRoomIfEmpty(draftID uint, room *collabRoom) {
+	if len(room.clients) == 0 && h.rooms[draftID] == room {
+		delete(h.rooms, draftID)
+	}
+}
+
+func presenceOf(room *collabRoom) []EditorPresence {
+	presence := make([]EditorPresence, 0, len(room.clients))
+	for _, client := range room.clients {
+		presence = append(presence, EditorPresence{
+			ClientID:   client.ID,
+			UserID:     client.UserID,
+			QuestionID: client.questionID,
+			JoinedAt:   client.joinedAt,
+		})
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].JoinedAt.Before(presence[j].JoinedAt) })
+	return presence
+}
+
+func locksOf(room *collabRoom) []QuestionLock {
+	locks := make([]QuestionLock, 0, len(room.locks))
+	for _, lock := range room.locks {
+		locks = append(locks, lock)
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].QuestionID < locks[j].QuestionID })
+	return locks
+}
+
+// touchedQuestions lists the draft question_ids a patch would change, so locks can be
+// enforced. Changing options or media files counts as changing their question; replacing
+// a whole section or the document touches every question.
+func touchedQuestions(format string, content models.JSONContent, patch []byte) ([]uint, error) {
+	doc, err := parseDraftDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	all := func() []uint {
+		ids := make([]uint, 0, len(doc.Questions))
+		for _, q := range doc.Questions {
+			ids = append(ids, q.QuestionID)
+		}
+		return ids
+	}
+
+	switch format {
+	case PatchFormatMergePatch:
+		value, err := decodeJSON(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			return all(), nil
+		}
+		for _, section := range []string{"questions", "options", "mediaFiles"} {
+			if _, ok := members[section]; ok {
+				// Merge patches replace arrays wholesale
+				return all(), nil
+			}
+		}
+		return nil, nil
+	case PatchFormatJSONPatch:
+		var ops []patchOperation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		touched := make(map[uint]bool)
+		for _, op := range ops {
+			for _, pointer := range []*string{op.Path, op.From} {
+				if pointer == nil {
+					continue
+				}
+				tokens, err := parsePointer(*pointer)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+				}
+				ids, whole := questionsAt(doc, tokens)
+				if whole {
+					return all(), nil
+				}
+				for _, id := range ids {
+					touched[id] = true
+				}
+				// An option or media file added or moved to a question changes that question
+				if op.Value != nil && len(tokens) >= 2 && (tokens[0] == "options" || tokens[0] == "mediaFiles") {
+					if id := referencedQuestion(*op.Value); id != 0 {
+						touched[id] = true
+					}
+				}
+			}
+		}
+		ids := make([]uint, 0, len(touched))
+		for id := range touched {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidPatch, format)
+}
+
+// questionsAt names the questions owning the value at a JSON Pointer. whole is true when
+// the pointer covers every question.
+func questionsAt(doc *draftDocument, tokens []string) (ids []uint, whole bool) {
+	if len(tokens) == 0 {
+		return nil, true
+	}
+	section := tokens[0]
+	if section != "questions" && section != "options" && section != "mediaFiles" {
+		return nil, false
+	}
+	if len(tokens) == 1 {
+		return nil, true
+	}
+	index, err := strconv.Atoi(tokens[1])
+	if err != nil || index < 0 {
+		// "-" appends, which changes no existing entry
+		return nil, false
+	}
+	switch section {
+	case "questions":
+		if index < len(doc.Questions) {
+			return []uint{doc.Questions[index].QuestionID}, false
+		}
+	case "options":
+		if index < len(doc.Options) {
+			return []uint{doc.Options[index].QuestionID}, false
+		}
+	case "mediaFiles":
+		if index < len(doc.MediaFiles) {
+			return []uint{doc.MediaFiles[index].QuestionID}, false
+		}
+	}
+	return nil, false
+}
+
+// referencedQuestion reads the question_id an option or media file value refers to:
+// either the whole entry or just its question_id
+func referencedQuestion(value json.RawMessage) uint {
+	var entry struct {
+		QuestionID uint `json:"question_id"`
+	}
+	if err := json.Unmarshal(value, &entry); err == nil && entry.QuestionID != 0 {
+		return entry.QuestionID
+	}
+	var id uint
+	if err := json.Unmarshal(value, &id); err == nil {
+		return id
+	}
+	return 0
+}
+
+// PatchedQuestions lists the draft question_ids a patch in format would change in
+// content; see touchedQuestions
+func PatchedQuestions(format string, content models.JSONContent, patch []byte) ([]uint, error) {
+	return touchedQuestions(format, content, patch)
+}
+
+// ChangedQuestions lists the draft question_ids whose question, options or media files
+// differ between two versions of a draft's content, including questions added or
+// removed. Content that is not a draft document changes every question in before.
+func ChangedQuestions(before, after models.JSONContent) ([]uint, error) {
+	old, err := questionFingerprints(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := questionFingerprints(after)
+	if err != nil {
+		updated = map[uint]string{}
+	}
+
+	var ids []uint
+	for id, fingerprint := range old {
+		if updated[id] != fingerprint {
+			ids = append(ids, id)
+		}
+	}
+	for id := range updated {
+		if _, ok := old[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// questionFingerprints renders each question of a draft together with its options and
+// media files, in order, as canonical JSON
+func questionFingerprints(content models.JSONContent) (map[uint]string, error) {
+	var doc struct {
+		Questions  []json.RawMessage `json:"questions"`
+		Options    []json.RawMessage `json:"options"`
+		MediaFiles []json.RawMessage `json:"mediaFiles"`
+	}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, err
+	}
+	parts := make(map[uint][]interface{})
+	for _, section := range [][]json.RawMessage{doc.Questions, doc.Options, doc.MediaFiles} {
+		for _, entry := range section {
+			var value interface{}
+			if err := json.Unmarshal(entry, &value); err != nil {
+				return nil, err
+			}
+			id := referencedQuestion(entry)
+			parts[id] = append(parts[id], value)
+		}
+	}
+	fingerprints := make(map[uint]string, len(parts))
+	for id, values := range parts {
+		data, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints[id] = string(data)
+	}
+	return fingerprints, nil
+}
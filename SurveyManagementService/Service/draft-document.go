@@ -0,0 +1,312 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// draftDocument is the builder state stored in SurveyDraft.DraftContent. Its shape is
+// defined by the schema served from DraftSchema.
+type draftDocument struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	BasicInfo     draftBasicInfo     `json:"basicInfo"`
+	Questions     []draftQuestion    `json:"questions"`
+	Options       []draftOption      `json:"options"`
+	MediaFiles    []draftMediaFile   `json:"mediaFiles"`
+	Requirements  []draftRequirement `json:"requirements"` // nil keeps the survey's requirements on publish
+}
+
+type draftBasicInfo struct {
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	IsSelfRecruitment bool       `json:"is_self_recruitment"`
+	ConductorID       uint       `json:"conductor_id"`
+	Status            string     `json:"status"`
+	OpensAt           *time.Time `json:"opens_at"`
+	ClosesAt          *time.Time `json:"closes_at"`
+}
+
+type draftQuestion struct {
+	QuestionID     uint         `json:"question_id"`
+	QuestionKey    string       `json:"question_key"`       // Optional; assigned on publish when empty
+	SourceQuestion uint         `json:"source_question_id"` // Published question this one edits, if any
+	QuestionText   string       `json:"question_text"`
+	QuestionType   string       `json:"question_type"`
+	Mandatory      bool         `json:"mandatory"`
+	BranchingLogic string       `json:"branching_logic"`
+	CorrectAnswers string       `json:"correct_answers"`
+	Matrix         *draftMatrix `json:"matrix,omitempty"` // MATRIX questions only
+	Code           *draftCode   `json:"code,omitempty"`   // CODE questions only
+
+	ValidationRules *models.ValidationRules `json:"validation_rules,omitempty"`
+
+	// Range, step and unit of numeric and date/time questions
+	models.QuestionConstraints
+
+	// Page of the survey to show it on; kept from the source question when unset
+	PageID *uint `json:"page_id,omitempty"`
+	// Shuffle the options for each participant
+	RandomizeOptions bool `json:"randomize_options,omitempty"`
+	// Question bank entry it was inserted from; kept from the source question when unset
+	BankQuestionID *uint `json:"bank_question_id,omitempty"`
+}
+
+type draftMatrix struct {
+	Rows        []draftMatrixRow    `json:"rows"`
+	Columns     []draftMatrixColumn `json:"columns"`
+	MultiSelect bool                `json:"multi_select"`
+}
+
+type draftMatrixRow struct {
+	Text string `json:"text"`
+}
+
+type draftMatrixColumn struct {
+	Text  string   `json:"text"`
+	Value *float64 `json:"value"`
+}
+
+type draftCode struct {
+	Language    string          `json:"language"`
+	StarterCode string          `json:"starter_code"`
+	Tests       []draftCodeTest `json:"tests"`
+}
+
+type draftCodeTest struct {
+	Name           string `json:"name"`
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+	Hidden         bool   `json:"hidden"`
+}
+
+type draftOption struct {
+	OptionKey  string `json:"option_key,omitempty"` // Names the option in translations; assigned on publishing when empty
+	OptionText string `json:"option_text"`
+	QuestionID uint   `json:"question_id"`
+	MediaID    *uint  `json:"media_id,omitempty"` // Uploaded media shown with the option
+	PinLast    bool   `json:"pin_last,omitempty"` // Kept at the end when the question's options are shuffled
+}
+
+type draftMediaFile struct {
+	QuestionID uint   `json:"question_id"`
+	FileURL    string `json:"file_url"`
+	FileType   string `json:"file_type"`
+}
+
+type draftRequirement struct {
+	SkillName           string `json:"skill_name"`
+	MinProficiencyLevel int    `json:"min_proficiency_level"`
+	ExperienceLevel     string `json:"experience_level"`
+}
+
+// DraftFieldError is one problem in a draft document. Path is a JSON Pointer to the
+// offending value, "/" for the document itself.
+type DraftFieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// DraftValidationError is returned when draft content does not satisfy the draft schema
+// or refers to questions it does not contain
+type DraftValidationError struct {
+	Errors []DraftFieldError
+}
+
+func (e *DraftValidationError) Error() string {
+	if len(e.Errors) == 0 {
+		return "invalid draft content"
+	}
+	first := e.Errors[0]
+	msg := fmt.Sprintf("invalid draft content: %s %s", first.Path, first.Message)
+	if len(e.Errors) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Errors)-1)
+	}
+	return msg
+}
+
+func parseDraftDocument(content models.JSONContent) (*draftDocument, error) {
+	var doc draftDocument
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// validateDraftContent checks content against the draft schema and the references
+// between its parts, and returns the parsed document. Publishing additionally needs a
+// title, at least one question and well-formed branching logic; drafts being edited may
+// lack them.
+func validateDraftContent(content models.JSONContent, forPublish bool) (*draftDocument, error) {
+	value, err := decodeJSON(content)
+	if err != nil {
+		return nil, &DraftValidationError{Errors: []DraftFieldError{{Path: "/", Message: "is not valid JSON: " + err.Error()}}}
+	}
+
+	var errs []DraftFieldError
+	draftSchema.validate(draftSchema, value, "", &errs)
+	if len(errs) > 0 {
+		return nil, &DraftValidationError{Errors: errs}
+	}
+
+	doc, err := parseDraftDocument(content)
+	if err != nil {
+		return nil, &DraftValidationError{Errors: []DraftFieldError{{Path: "/", Message: err.Error()}}}
+	}
+
+	fail := func(path, format string, args ...interface{}) {
+		errs = append(errs, DraftFieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	questionIDs := make(map[uint]int, len(doc.Questions))
+	keys := make(map[string]int, len(doc.Questions))
+	options := make(map[uint][]models.Option, len(doc.Questions))
+	for _, opt := range doc.Options {
+		options[opt.QuestionID] = append(options[opt.QuestionID], models.Option{OptionText: opt.OptionText, MediaID: opt.MediaID, PinLast: opt.PinLast})
+	}
+
+	for i, q := range doc.Questions {
+		path := "/questions/" + strconv.Itoa(i)
+		if other, exists := questionIDs[q.QuestionID]; exists {
+			fail(path+"/question_id", "duplicates /questions/%d/question_id", other)
+		} else {
+			questionIDs[q.QuestionID] = i
+		}
+
+		key, err := NormalizeQuestionKey(q.QuestionKey)
+		if err != nil {
+			fail(path+"/question_key", "%s", strings.TrimPrefix(err.Error(), ErrInvalidQuestionKey.Error()+": "))
+		} else if key != "" {
+			if other, exists := keys[key]; exists {
+				fail(path+"/question_key", "duplicates /questions/%d/question_key", other)
+			} else {
+				keys[key] = i
+			}
+		}
+
+		if forPublish {
+			if _, err := ParseBranchingLogic(q.BranchingLogic); err != nil {
+				fail(path+"/branching_logic", "%v", err)
+			}
+			question := q.model()
+			if err := validateValidationRules(q.ValidationRules); err != nil {
+				fail(path+"/validation_rules", "%s", strings.TrimPrefix(err.Error(), ErrInvalidQuestionConfig.Error()+": "))
+			} else if err := validateQuestionConfig(&question); err != nil {
+				field := ""
+				switch q.QuestionType {
+				case QuestionTypeMatrix:
+					field = "/matrix"
+				case QuestionTypeCode:
+					field = "/code"
+				}
+				fail(path+field, "%s", strings.TrimPrefix(err.Error(), ErrInvalidQuestionConfig.Error()+": "))
+			}
+			if err := validateOptions(q.QuestionType, options[q.QuestionID]); err != nil {
+				fail(path, "%s", strings.TrimPrefix(err.Error(), ErrInvalidQuestionConfig.Error()+": "))
+			}
+		}
+	}
+
+	optionKeys := make(map[uint]map[string]int)
+	for i, opt := range doc.Options {
+		path := "/options/" + strconv.Itoa(i)
+		if _, exists := questionIDs[opt.QuestionID]; !exists {
+			fail(path+"/question_id", "refers to question %d, which is not in the draft", opt.QuestionID)
+		}
+
+		// Option keys follow the question key format and are unique within their question
+		key, err := NormalizeQuestionKey(opt.OptionKey)
+		if err != nil {
+			fail(path+"/option_key", "%s", strings.TrimPrefix(err.Error(), ErrInvalidQuestionKey.Error()+": "))
+		} else if key != "" {
+			if optionKeys[opt.QuestionID] == nil {
+				optionKeys[opt.QuestionID] = make(map[string]int)
+			}
+			if other, exists := optionKeys[opt.QuestionID][key]; exists {
+				fail(path+"/option_key", "duplicates /options/%d/option_key of the same question", other)
+			} else {
+				optionKeys[opt.QuestionID][key] = i
+			}
+		}
+	}
+	for i, m := range doc.MediaFiles {
+		if _, exists := questionIDs[m.QuestionID]; !exists {
+			fail("/mediaFiles/"+strconv.Itoa(i)+"/question_id", "refers to question %d, which is not in the draft", m.QuestionID)
+		}
+	}
+
+	if forPublish {
+		if strings.TrimSpace(doc.BasicInfo.Title) == "" {
+			fail("/basicInfo/title", "is required to publish")
+		}
+		if len(doc.Questions) == 0 {
+			fail("/questions", "must contain at least one question to publish")
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &DraftValidationError{Errors: errs}
+	}
+	return doc, nil
+}
+
+// model converts the draft question to the question it publishes as, without the key,
+// lineage and survey that publishing assigns
+func (q draftQuestion) model() models.Question {
+	question := models.Question{
+		QuestionText:   q.QuestionText,
+		QuestionType:   q.QuestionType,
+		Mandatory:      q.Mandatory,
+		BranchingLogic: q.BranchingLogic,
+		CorrectAnswers: q.CorrectAnswers,
+		PageID:         q.PageID,
+
+		QuestionConstraints: q.QuestionConstraints,
+		ValidationRules:     q.ValidationRules,
+		RandomizeOptions:    q.RandomizeOptions,
+		BankQuestionID:      q.BankQuestionID,
+	}
+	if q.Matrix != nil {
+		question.MatrixMulti = q.Matrix.MultiSelect
+		for i, row := range q.Matrix.Rows {
+			question.MatrixRows = append(question.MatrixRows, models.MatrixRow{RowText: row.Text, Position: i})
+		}
+		for i, column := range q.Matrix.Columns {
+			question.MatrixColumns = append(question.MatrixColumns, models.MatrixColumn{ColumnText: column.Text, Value: column.Value, Position: i})
+		}
+	}
+	if q.Code != nil {
+		question.CodeLanguage = q.Code.Language
+		question.StarterCode = q.Code.StarterCode
+		for i, test := range q.Code.Tests {
+			question.CodeTests = append(question.CodeTests, models.CodeTest{
+				Name:           test.Name,
+				Input:          test.Input,
+				ExpectedOutput: test.ExpectedOutput,
+				Hidden:         test.Hidden,
+				Position:       i,
+			})
+		}
+	}
+	return question
+}
+
+// draftRequirements converts the draft's requirements to survey requirements
+func draftRequirements(doc *draftDocument, surveyID uint, now time.Time) []models.SurveyRequirement {
+	requirements := make([]models.SurveyRequirement, 0, len(doc.Requirements))
+	for _, r := range doc.Requirements {
+		requirements = append(requirements, models.SurveyRequirement{
+			SurveyID:            surveyID,
+			SkillName:           r.SkillName,
+			MinProficiencyLevel: r.MinProficiencyLevel,
+			ExperienceLevel:     r.ExperienceLevel,
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		})
+	}
+	return requirements
+}
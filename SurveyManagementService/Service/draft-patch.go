@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Draft patch formats accepted by PatchDraft
+const (
+	PatchFormatJSONPatch  = "json-patch"  // RFC 6902, application/json-patch+json
+	PatchFormatMergePatch = "merge-patch" // RFC 7396, application/merge-patch+json
+)
+
+var (
+	// ErrInvalidPatch means the patch document itself is malformed
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchFailed means a well-formed patch cannot be applied to the current content
+	ErrPatchFailed = errors.New("patch cannot be applied")
+)
+
+// patchOperation is one operation of an RFC 6902 JSON Patch
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyPatch applies a patch in the given format to a JSON document
+func applyPatch(format string, doc, patch []byte) ([]byte, error) {
+	switch format {
+	case PatchFormatJSONPatch:
+		return applyJSONPatch(doc, patch)
+	case PatchFormatMergePatch:
+		return applyMergePatch(doc, patch)
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidPatch, format)
+}
+
+// decodeJSON decodes a document keeping numbers exact, so untouched values survive a
+// patch byte-for-byte in meaning
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// applyJSONPatch applies an RFC 6902 JSON Patch. Operations run in order and the patch
+// is atomic: if one fails, nothing is applied.
+func applyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	root, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: current content is not JSON: %v", ErrPatchFailed, err)
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("%w: operation %d has no path", ErrInvalidPatch, i)
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+
+		var value interface{}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d (%s) has no value", ErrInvalidPatch, i, op.Op)
+			}
+			if value, err = decodeJSON(*op.Value); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("%w: operation %d (%s) has no from", ErrInvalidPatch, i, op.Op)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+
+		switch op.Op {
+		case "add":
+			root, err = pointerAdd(root, path, value)
+		case "remove":
+			root, _, err = pointerRemove(root, path)
+		case "replace":
+			if root, _, err = pointerRemove(root, path); err == nil {
+				root, err = pointerAdd(root, path, value)
+			}
+		case "test":
+			var current interface{}
+			if current, err = pointerGet(root, path); err == nil && !jsonEqual(current, value) {
+				err = fmt.Errorf("value at %q differs", *op.Path)
+			}
+		case "move", "copy":
+			var from []string
+			if from, err = parsePointer(*op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+			if op.Op == "move" && isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: operation %d moves %q into itself", ErrInvalidPatch, i, *op.From)
+			}
+			var moved interface{}
+			if op.Op == "move" {
+				root, moved, err = pointerRemove(root, from)
+			} else {
+				moved, err = pointerGet(root, from)
+				moved = deepCopy(moved)
+			}
+			if err == nil {
+				root, err = pointerAdd(root, path, moved)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s %s): %v", ErrPatchFailed, i, op.Op, *op.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// applyMergePatch applies an RFC 7396 JSON Merge Patch: objects merge recursively, null
+// removes a member and any other value replaces the target
+func applyMergePatch(doc, patch []byte) ([]byte, error) {
+	patchValue, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: current content is not JSON: %v", ErrPatchFailed, err)
+	}
+	return json.Marshal(mergePatch(target, patchValue))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex resolves an array reference token. "-" (past the end) is only valid when
+// allowEnd is set, as for add.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into a scalar at %q", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd inserts value at path and returns the new root. Arrays are inserted into,
+// object members are set, and an empty path replaces the whole document.
+func pointerAdd(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+		return root, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := append(container, nil)
+		copy(grown[index+1:], grown[index:])
+		grown[index] = value
+		return replaceAt(root, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("cannot add to a scalar at %q", last)
+}
+
+// pointerRemove deletes the value at path and returns the new root and the removed value
+func pointerRemove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, root, nil
+	}
+	parent, err := pointerGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, ok := container[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q does not exist", last)
+		}
+		delete(container, last)
+		return root, value, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := container[index]
+		shrunk := append(container[:index:index], container[index+1:]...)
+		root, err = replaceAt(root, path[:len(path)-1], shrunk)
+		return root, value, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove from a scalar at %q", last)
+}
+
+// replaceAt stores value at path, which must exist, and returns the new root. Arrays
+// change identity when they grow or shrink, so their parent has to be updated.
+func replaceAt(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+	}
+	return root, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// jsonEqual compares decoded JSON values, treating numbers by value as RFC 6902 test requires
+func jsonEqual(a, b interface{}) bool {
+	x, xok := a.(json.Number)
+	y, yok := b.(json.Number)
+	if xok && yok {
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		if errx == nil && erry == nil {
+			return fx == fy
+		}
+		return x == y
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, item := range av {
+			other, exists := bv[key]
+			if !exists || !jsonEqual(item, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{"title": "Survey", "questions": [{"id": 1}, {"id": 2}], "meta": {"tags": ["a"], "count": 10000000000000001}}`
+
+	tests := []struct {
+		name    string
+		format  string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "add to end of array",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "add", "path": "/questions/-", "value": {"id": 3}}]`,
+			want:   `{"title": "Survey", "questions": [{"id": 1}, {"id": 2}, {"id": 3}], "meta": {"tags": ["a"], "count": 10000000000000001}}`,
+		},
+		{
+			name:   "insert into array",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "add", "path": "/questions/0", "value": {"id": 0}}]`,
+			want:   `{"title": "Survey", "questions": [{"id": 0}, {"id": 1}, {"id": 2}], "meta": {"tags": ["a"], "count": 10000000000000001}}`,
+		},
+		{
+			name:   "remove and replace",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "remove", "path": "/questions/1"}, {"op": "replace", "path": "/title", "value": "Renamed"}]`,
+			want:   `{"title": "Renamed", "questions": [{"id": 1}], "meta": {"tags": ["a"], "count": 10000000000000001}}`,
+		},
+		{
+			name:   "move and copy",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "move", "path": "/questions/0", "from": "/questions/1"}, {"op": "copy", "path": "/meta/first", "from": "/questions/0"}]`,
+			want:   `{"title": "Survey", "questions": [{"id": 2}, {"id": 1}], "meta": {"tags": ["a"], "count": 10000000000000001, "first": {"id": 2}}}`,
+		},
+		{
+			name:   "escaped pointer",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "add", "path": "/meta/a~1b~0c", "value": true}]`,
+			want:   `{"title": "Survey", "questions": [{"id": 1}, {"id": 2}], "meta": {"tags": ["a"], "count": 10000000000000001, "a/b~c": true}}`,
+		},
+		{
+			name:   "passing test",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op": "test", "path": "/title", "value": "Survey"}, {"op": "remove", "path": "/meta"}]`,
+			want:   `{"title": "Survey", "questions": [{"id": 1}, {"id": 2}]}`,
+		},
+		{
+			name:    "failing test applies nothing",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "remove", "path": "/meta"}, {"op": "test", "path": "/title", "value": "Other"}]`,
+			wantErr: ErrPatchFailed,
+		},
+		{
+			name:    "missing path",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "remove", "path": "/nothing"}]`,
+			wantErr: ErrPatchFailed,
+		},
+		{
+			name:    "array index out of range",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "replace", "path": "/questions/5", "value": {}}]`,
+			wantErr: ErrPatchFailed,
+		},
+		{
+			name:    "unknown op",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "rename", "path": "/title"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "add without value",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "add", "path": "/title"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "move into itself",
+			format:  PatchFormatJSONPatch,
+			patch:   `[{"op": "move", "path": "/meta/tags", "from": "/meta"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "not an array",
+			format:  PatchFormatJSONPatch,
+			patch:   `{"op": "remove", "path": "/title"}`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:   "merge patch",
+			format: PatchFormatMergePatch,
+			patch:  `{"title": "Renamed", "meta": {"tags": null, "owner": "x"}}`,
+			want:   `{"title": "Renamed", "questions": [{"id": 1}, {"id": 2}], "meta": {"count": 10000000000000001, "owner": "x"}}`,
+		},
+		{
+			name:   "merge patch replaces arrays",
+			format: PatchFormatMergePatch,
+			patch:  `{"questions": []}`,
+			want:   `{"title": "Survey", "questions": [], "meta": {"tags": ["a"], "count": 10000000000000001}}`,
+		},
+		{
+			name:    "unknown format",
+			format:  "xml-patch",
+			patch:   `{}`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch(tt.format, []byte(doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("applyPatch error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPatch: %v", err)
+			}
+			gotValue, err := decodeJSON(got)
+			if err != nil {
+				t.Fatalf("result is not JSON: %v", err)
+			}
+			wantValue, _ := decodeJSON([]byte(tt.want))
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("applyPatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchKeepsNumbersExact(t *testing.T) {
+	got, err := applyPatch(PatchFormatJSONPatch, []byte(`{"big": 10000000000000001, "x": 1}`), []byte(`[{"op": "remove", "path": "/x"}]`))
+	if err != nil {
+		t.Fatalf("applyPatch: %v", err)
+	}
+	var value map[string]json.RawMessage
+	if err := json.Unmarshal(got, &value); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if string(value["big"]) != "10000000000000001" {
+		t.Errorf("untouched number became %s", value["big"])
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// AnyRevision disables the revision check of draft writes
+const AnyRevision = -1
+
+var ErrDraftRevisionConflict = errors.New("draft was changed by another save")
+
+// DraftConflictError reports a draft write based on a revision that is no longer current
+type DraftConflictError struct {
+	DraftID  uint
+	Expected int
+	Current  int
+}
+
+func (e *DraftConflictError) Error() string {
+	return fmt.Sprintf("%v: draft %d is at revision %d, not %d", ErrDraftRevisionConflict, e.DraftID, e.Current, e.Expected)
+}
+
+func (e *DraftConflictError) Unwrap() error { return ErrDraftRevisionConflict }
+
+func checkDraftRevision(draft *models.SurveyDraft, expected int) error {
+	if expected != AnyRevision && draft.Revision != expected {
+		return &DraftConflictError{DraftID: draft.DraftID, Expected: expected, Current: draft.Revision}
+	}
+	return nil
+}
+
+// FieldChange is one field whose value differs between two revisions
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// QuestionDiff identifies a draft question and, for changed questions, what changed.
+// Options and media files are compared as fields of their question.
+type QuestionDiff struct {
+	QuestionID   uint          `json:"question_id"`
+	QuestionText string        `json:"question_text"`
+	Changes      []FieldChange `json:"changes,omitempty"`
+}
+
+// DraftDiff is the structural difference between two revisions of a draft
+type DraftDiff struct {
+	From      int            `json:"from"`
+	To        int            `json:"to"`
+	BasicInfo []FieldChange  `json:"basic_info"`
+	Added     []QuestionDiff `json:"added"`
+	Removed   []QuestionDiff `json:"removed"`
+	Changed   []QuestionDiff `json:"changed"`
+}
+
+// contentHash returns the SHA-256 of the draft content in canonical form (sorted keys,
+// no insignificant whitespace), so re-saving the same builder state hashes the same
+func contentHash(content models.JSONContent) string {
+	canonical := []byte(content)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		if encoded, err := json.Marshal(value); err == nil {
+			canonical = encoded
+		}
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordRevisionWithTx stores the draft's current content as its next revision. A save
+// that leaves the content unchanged records nothing and returns the latest revision.
+func (s *surveyService) recordRevisionWithTx(ctx context.Context, tx *gorm.DB, draft *models.SurveyDraft, authorID uint) (*models.SurveyDraftRevision, error) {
+	hash := contentHash(draft.DraftContent)
+
+	number := 1
+	latest, err := s.revisionRepo.LatestWithTx(ctx, tx, draft.DraftID)
+	switch {
+	case err == nil:
+		if latest.ContentHash == hash {
+			return latest, nil
+		}
+		number = latest.RevisionNumber + 1
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	revision := &models.SurveyDraftRevision{
+		DraftID:            draft.DraftID,
+		RevisionNumber:     number,
+		AuthorID:           authorID,
+		ContentHash:        hash,
+		DraftContent:       draft.DraftContent,
+		LastEditedQuestion: draft.LastEditedQuestion,
+		CreatedAt:          time.Now(),
+	}
+	if err := s.revisionRepo.CreateWithTx(ctx, tx, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// ListDraftRevisions returns a draft's revisions newest first, without their content
+func (s *surveyService) ListDraftRevisions(ctx context.Context, draftID uint) ([]models.SurveyDraftRevision, error) {
+	if _, err := s.surveyDraftRepo.GetByID(ctx, draftID); err != nil {
+		return nil, err
+	}
+	return s.revisionRepo.ListByDraftID(ctx, draftID)
+}
+
+func (s *surveyService) GetDraftRevision(ctx context.Context, draftID uint, number int) (*models.SurveyDraftRevision, error) {
+	return s.revisionRepo.GetByNumber(ctx, draftID, number)
+}
+
+// RestoreDraftRevision makes an earlier revision the draft's content again. The restore
+// is itself saved as a new revision, so it can be undone the same way.
+func (s *surveyService) RestoreDraftRevision(ctx context.Context, draftID uint, number int, authorID uint, expectedRevision int) (*models.SurveyDraft, error) {
+	revision, err := s.revisionRepo.GetByNumber(ctx, draftID, number)
+	if err != nil {
+		return nil, err
+	}
+	return s.UpdateDraft(ctx, draftID, revision.DraftContent, revision.LastEditedQuestion, authorID, expectedRevision)
+}
+
+// DiffDraftRevisions compares two revisions of a draft. Questions are matched by their
+// draft question_id.
+func (s *surveyService) DiffDraftRevisions(ctx context.Context, draftID uint, from, to int) (*DraftDiff, error) {
+	before, err := s.revisionRepo.GetByNumber(ctx, draftID, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.revisionRepo.GetByNumber(ctx, draftID, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffDraftContent(from, to, before.DraftContent, after.DraftContent)
+}
+
+// genericDraft is the draft document decoded loosely, so every field is compared,
+// including ones this service does not interpret
+type genericDraft struct {
+	BasicInfo  map[string]interface{}   `json:"basicInfo"`
+	Questions  []map[string]interface{} `json:"questions"`
+	Options    []map[string]interface{} `json:"options"`
+	MediaFiles []map[string]interface{} `json:"mediaFiles"`
+}
+
+// questions returns the draft's questions in document order, each with its options and
+// media files folded in
+func (d *genericDraft) questions() ([]uint, map[uint]map[string]interface{}) {
+	order := make([]uint, 0, len(d.Questions))
+	byID := make(map[uint]map[string]interface{}, len(d.Questions))
+	for _, q := range d.Questions {
+		id := genericID(q["question_id"])
+		order = append(order, id)
+		byID[id] = q
+	}
+
+	attach := func(items []map[string]interface{}, field string) {
+		for _, item := range items {
+			q, ok := byID[genericID(item["question_id"])]
+			if !ok {
+				continue
+			}
+			rest := make(map[string]interface{}, len(item))
+			for k, v := range item {
+				if k != "question_id" {
+					rest[k] = v
+				}
+			}
+			list, _ := q[field].([]interface{})
+			q[field] = append(list, rest)
+		}
+	}
+	attach(d.Options, "options")
+	attach(d.MediaFiles, "media_files")
+	return order, byID
+}
+
+func genericID(v interface{}) uint {
+	n, _ := toNumber(v)
+	return uint(n)
+}
+
+func diffDraftContent(from, to int, before, after models.JSONContent) (*DraftDiff, error) {
+	var a, b genericDraft
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &a); err != nil {
+			return nil, fmt.Errorf("revision %d: %w", from, err)
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &b); err != nil {
+			return nil, fmt.Errorf("revision %d: %w", to, err)
+		}
+	}
+
+	diff := &DraftDiff{
+		From:      from,
+		To:        to,
+		BasicInfo: fieldChanges(a.BasicInfo, b.BasicInfo),
+		Added:     []QuestionDiff{},
+		Removed:   []QuestionDiff{},
+		Changed:   []QuestionDiff{},
+	}
+
+	oldOrder, oldQuestions := a.questions()
+	newOrder, newQuestions := b.questions()
+	for _, id := range newOrder {
+		q := newQuestions[id]
+		old, existed := oldQuestions[id]
+		if !existed {
+			diff.Added = append(diff.Added, QuestionDiff{QuestionID: id, QuestionText: scalarString(q["question_text"])})
+			continue
+		}
+		if changes := fieldChanges(old, q); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, QuestionDiff{QuestionID: id, QuestionText: scalarString(q["question_text"]), Changes: changes})
+		}
+	}
+	for _, id := range oldOrder {
+		if _, kept := newQuestions[id]; !kept {
+			diff.Removed = append(diff.Removed, QuestionDiff{QuestionID: id, QuestionText: scalarString(oldQuestions[id]["question_text"])})
+		}
+	}
+	return diff, nil
+}
+
+// fieldChanges lists the fields whose values differ, in field name order
+func fieldChanges(before, after map[string]interface{}) []FieldChange {
+	fields := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		fields[k] = true
+	}
+	for k := range after {
+		fields[k] = true
+	}
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != "question_id" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		if !reflect.DeepEqual(before[name], after[name]) {
+			changes = append(changes, FieldChange{Field: name, Before: before[name], After: after[name]})
+		}
+	}
+	return changes
+}
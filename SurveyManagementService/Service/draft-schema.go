@@ -0,0 +1,258 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DraftSchemaID identifies the JSON Schema draft documents are validated against
+const DraftSchemaID = "urn:survey-platform:survey-draft:v1"
+
+// DraftSchemaVersion is the schemaVersion a draft document may declare
+const DraftSchemaVersion = 1
+
+//go:embed schema/survey-draft.v1.json
+var draftSchemaJSON []byte
+
+// draftSchema is the parsed form of draftSchemaJSON
+var draftSchema = mustParseSchema(draftSchemaJSON)
+
+// DraftSchema returns the JSON Schema of the draft document
+func DraftSchema() []byte {
+	return draftSchemaJSON
+}
+
+// jsonSchema is the subset of JSON Schema (2020-12) the draft schema uses: $ref to
+// local $defs, type, const, enum, properties, required, additionalProperties, items,
+// minLength, maxLength, minimum, maximum, pattern and the date-time format.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Type                 schemaTypes            `json:"type"`
+	Const                *json.RawMessage       `json:"const"`
+	Enum                 []json.RawMessage      `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Pattern              string                 `json:"pattern"`
+	Format               string                 `json:"format"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes accepts "type" as a single name or a list of names
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+func mustParseSchema(data []byte) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		panic("invalid embedded schema: " + err.Error())
+	}
+	if err := schema.compile(); err != nil {
+		panic("invalid embedded schema: " + err.Error())
+	}
+	return &schema
+}
+
+// compile prepares patterns throughout the schema
+func (s *jsonSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	children := []*jsonSchema{s.Items}
+	for _, def := range s.Defs {
+		children = append(children, def)
+	}
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	for _, child := range children {
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks value (decoded with json.Number) against the schema and appends
+// an error for every violation, with its JSON Pointer path
+func (s *jsonSchema) validate(root *jsonSchema, value interface{}, path string, errs *[]DraftFieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, DraftFieldError{Path: pointerOrRoot(path), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/$defs/")
+		def, ok := root.Defs[name]
+		if !ok {
+			fail("schema reference %s is undefined", s.Ref)
+			return
+		}
+		def.validate(root, value, path, errs)
+		return
+	}
+
+	if len(s.Type) > 0 && !matchesType(value, s.Type) {
+		fail("must be %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if s.Const != nil {
+		want, _ := decodeJSON(*s.Const)
+		if !jsonEqual(value, want) {
+			fail("must be %s", string(*s.Const))
+		}
+	}
+	if len(s.Enum) > 0 {
+		allowed := make([]string, len(s.Enum))
+		found := false
+		for i, raw := range s.Enum {
+			allowed[i] = string(raw)
+			if want, _ := decodeJSON(raw); jsonEqual(value, want) {
+				found = true
+			}
+		}
+		if !found {
+			fail("must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("must be an RFC 3339 date-time")
+			}
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(root, item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, DraftFieldError{Path: path + "/" + escapePointer(name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			childPath := path + "/" + escapePointer(name)
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(root, v[name], childPath, errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, DraftFieldError{Path: childPath, Message: "is not a known field"})
+			}
+		}
+	}
+}
+
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			if t == "integer" {
+				if _, err := v.Int64(); err == nil {
+					return true
+				}
+				if f, err := v.Float64(); err == nil && f == float64(int64(f)) {
+					return true
+				}
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func pointerOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
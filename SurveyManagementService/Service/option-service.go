@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
@@ -16,15 +17,18 @@ type OptionService interface {
 	UpdateOption(ctx context.Context, option *models.Option) error
 	DeleteOption(ctx context.Context, id uint) error
 	BatchCreateOptions(ctx context.Context, options []models.Option) error
+	ReorderOptions(ctx context.Context, questionID uint, optionIDs []uint) ([]models.Option, error)
 }
 
 type optionService struct {
-	optionRepo repository.OptionRepository
+	optionRepo   repository.OptionRepository
+	questionRepo repository.QuestionRepository
 }
 
-func NewOptionService(repo repository.OptionRepository) OptionService {
+func NewOptionService(repo repository.OptionRepository, questionRepo repository.QuestionRepository) OptionService {
 	return &optionService{
-		optionRepo: repo,
+		optionRepo:   repo,
+		questionRepo: questionRepo,
 	}
 }
 
@@ -41,6 +45,13 @@ func (s *optionService) CreateOption(ctx context.Context, option *models.Option)
 		return errors.New("option text is required")
 	}
 
+	if err := s.checkMedia(ctx, []models.Option{*option}); err != nil {
+		return err
+	}
+
+	if option.OptionKey == "" {
+		option.OptionKey = NewQuestionKey()
+	}
 	option.CreatedAt = time.Now()
 	option.UpdatedAt = time.Now()
 
@@ -72,6 +83,10 @@ func (s *optionService) UpdateOption(ctx context.Context, option *models.Option)
 		return errors.New("option text is required")
 	}
 
+	if err := s.checkMedia(ctx, []models.Option{*option}); err != nil {
+		return err
+	}
+
 	option.UpdatedAt = time.Now()
 
 	return s.optionRepo.Update(ctx, option)
@@ -101,9 +116,84 @@ func (s *optionService) BatchCreateOptions(ctx context.Context, options []models
 			return errors.New("option text is required for all options")
 		}
 
+		if options[i].OptionKey == "" {
+			options[i].OptionKey = NewQuestionKey()
+		}
 		options[i].CreatedAt = now
 		options[i].UpdatedAt = now
 	}
+	if err := s.checkMedia(ctx, options); err != nil {
+		return err
+	}
 
 	return s.optionRepo.BatchCreate(ctx, options)
 }
+
+// ReorderOptions puts a question's options in the order of optionIDs, which must list
+// each of them exactly once, and returns them in their new order
+func (s *optionService) ReorderOptions(ctx context.Context, questionID uint, optionIDs []uint) ([]models.Option, error) {
+	if questionID == 0 {
+		return nil, errors.New("invalid question ID")
+	}
+	if _, err := s.questionRepo.GetByID(ctx, questionID); err != nil {
+		return nil, err
+	}
+
+	options, err := s.optionRepo.GetByQuestionID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	own := make(map[uint]bool, len(options))
+	for _, option := range options {
+		own[option.OptionID] = true
+	}
+	seen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !own[id] {
+			return nil, fmt.Errorf("%w: option %d is not an option of question %d", ErrInvalidLayout, id, questionID)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: option %d is listed more than once", ErrInvalidLayout, id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(options) {
+		return nil, fmt.Errorf("%w: all %d options of question %d must be listed", ErrInvalidLayout, len(options), questionID)
+	}
+
+	if err := s.optionRepo.Reorder(ctx, questionID, optionIDs); err != nil {
+		return nil, err
+	}
+	return s.optionRepo.GetByQuestionID(ctx, questionID)
+}
+
+// checkMedia checks the media options refer to against the type of their question,
+// e.g. that every IMAGE_RANKING option has an image
+func (s *optionService) checkMedia(ctx context.Context, options []models.Option) error {
+	byQuestion := make(map[uint][]models.Option)
+	for _, option := range options {
+		byQuestion[option.QuestionID] = append(byQuestion[option.QuestionID], option)
+	}
+
+	for questionID, options := range byQuestion {
+		question, err := s.questionRepo.GetByID(ctx, questionID)
+		if err != nil {
+			return err
+		}
+		if question.QuestionType == QuestionTypeImageRanking {
+			for _, option := range options {
+				if option.MediaID == nil {
+					return fmt.Errorf("%w: an IMAGE_RANKING option needs a media_id", ErrInvalidQuestionConfig)
+				}
+			}
+		}
+		media, err := s.optionRepo.GetMediaFiles(ctx, optionMediaIDs(options))
+		if err != nil {
+			return err
+		}
+		if err := validateOptionMedia(question.QuestionType, options, media); err != nil {
+			return err
+		}
+	}
+	return nil
+}
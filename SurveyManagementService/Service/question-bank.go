@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+const (
+	defaultBankPageSize = 50
+	maxBankPageSize     = 200
+)
+
+var (
+	ErrInvalidBankQuestion       = errors.New("invalid bank question")
+	ErrBankQuestionNotAccessible = errors.New("bank question is not accessible to this conductor")
+)
+
+// bankContent is what a bank question stores: one question and its options in the draft
+// format, without the draft question_id or anything tied to a survey
+type bankContent struct {
+	Question draftQuestion `json:"question"`
+	Options  []draftOption `json:"options"`
+}
+
+// BankQuestionInput saves a question to the bank, either from a survey question or from
+// content in the draft format: {"question": {...}, "options": [{"option_text": ...}]}
+type BankQuestionInput struct {
+	ConductorID uint               `json:"-"`     // The authenticated conductor, set by the handler
+	Scope       string             `json:"scope"` // PERSONAL (default) or ORGANISATION
+	Tags        []string           `json:"tags"`
+	QuestionID  uint               `json:"question_id"` // Survey question to save; used when Content is empty
+	Content     models.JSONContent `json:"content"`
+}
+
+// BankQuestionResults are the results of one published question inserted from a bank
+// question, for comparing its answers across surveys
+type BankQuestionResults struct {
+	SurveyID        uint             `json:"survey_id"`
+	SurveyVersionID uint             `json:"survey_version_id"`
+	QuestionID      uint             `json:"question_id"`
+	QuestionKey     string           `json:"question_key"`
+	Retired         bool             `json:"retired"` // Superseded by a later publish of the survey
+	Results         *QuestionResults `json:"results"`
+}
+
+// BankInsertResult is the draft a bank question was inserted into and the draft
+// question_id it was given
+type BankInsertResult struct {
+	Draft      *models.SurveyDraft
+	QuestionID uint
+}
+
+type QuestionBankService interface {
+	SaveQuestion(ctx context.Context, input BankQuestionInput) (*models.BankQuestion, error)
+	GetQuestion(ctx context.Context, id, conductorID uint) (*models.BankQuestion, error)
+	UpdateQuestion(ctx context.Context, id uint, input BankQuestionInput) (*models.BankQuestion, error)
+	DeleteQuestion(ctx context.Context, id, conductorID uint) error
+	SearchQuestions(ctx context.Context, filter repository.BankQuestionFilter) ([]models.BankQuestion, error)
+	InsertIntoDraft(ctx context.Context, id, conductorID, draftID uint, pageID *uint, authorID uint, expectedRevision int) (*BankInsertResult, error)
+	CompareResults(ctx context.Context, id, conductorID uint) ([]BankQuestionResults, error)
+}
+
+type questionBankService struct {
+	bankRepo      repository.QuestionBankRepository
+	questionRepo  repository.QuestionRepository
+	surveyService SurveyService
+	answerService AnswerService
+}
+
+func NewQuestionBankService(bankRepo repository.QuestionBankRepository, questionRepo repository.QuestionRepository, surveyService SurveyService, answerService AnswerService) QuestionBankService {
+	return &questionBankService{
+		bankRepo:      bankRepo,
+		questionRepo:  questionRepo,
+		surveyService: surveyService,
+		answerService: answerService,
+	}
+}
+
+// SaveQuestion adds a question to the conductor's bank
+func (s *questionBankService) SaveQuestion(ctx context.Context, input BankQuestionInput) (*models.BankQuestion, error) {
+	if input.ConductorID == 0 {
+		return nil, fmt.Errorf("%w: conductor_id is required", ErrInvalidBankQuestion)
+	}
+	scope, err := bankScope(input.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var content *bankContent
+	var sourceID uint
+	if len(input.Content) > 0 {
+		if content, err = parseBankContent(input.Content); err != nil {
+			return nil, err
+		}
+	} else if input.QuestionID != 0 {
+		question, err := s.questionRepo.GetByID(ctx, input.QuestionID)
+		if err != nil {
+			return nil, err
+		}
+		content = bankContentFromQuestion(question)
+		sourceID = question.QuestionID
+	} else {
+		return nil, fmt.Errorf("%w: content or question_id is required", ErrInvalidBankQuestion)
+	}
+
+	now := time.Now()
+	question := &models.BankQuestion{
+		ConductorID:      input.ConductorID,
+		Scope:            scope,
+		SourceQuestionID: sourceID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := setBankContent(question, content, input.Tags); err != nil {
+		return nil, err
+	}
+	if err := s.bankRepo.Create(ctx, question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// GetQuestion returns a bank question the conductor may use
+func (s *questionBankService) GetQuestion(ctx context.Context, id, conductorID uint) (*models.BankQuestion, error) {
+	question, err := s.bankRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if question.Scope == models.TemplateScopePersonal && question.ConductorID != conductorID {
+		return nil, ErrBankQuestionNotAccessible
+	}
+	return question, nil
+}
+
+// UpdateQuestion changes a bank question's scope, tags and, when given, content. Only
+// the conductor who saved it may. Questions already inserted are not affected.
+func (s *questionBankService) UpdateQuestion(ctx context.Context, id uint, input BankQuestionInput) (*models.BankQuestion, error) {
+	question, err := s.bankRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if question.ConductorID != input.ConductorID {
+		return nil, ErrBankQuestionNotAccessible
+	}
+	if question.Scope, err = bankScope(input.Scope); err != nil {
+		return nil, err
+	}
+
+	var content *bankContent
+	if len(input.Content) > 0 {
+		content, err = parseBankContent(input.Content)
+	} else {
+		content, err = storedBankContent(question)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := setBankContent(question, content, input.Tags); err != nil {
+		return nil, err
+	}
+	question.UpdatedAt = time.Now()
+	if err := s.bankRepo.Update(ctx, question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// DeleteQuestion removes a bank question; only the conductor who saved it may
+func (s *questionBankService) DeleteQuestion(ctx context.Context, id, conductorID uint) error {
+	question, err := s.bankRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if question.ConductorID != conductorID {
+		return ErrBankQuestionNotAccessible
+	}
+	return s.bankRepo.Delete(ctx, id)
+}
+
+// SearchQuestions lists the bank questions a conductor may use
+func (s *questionBankService) SearchQuestions(ctx context.Context, filter repository.BankQuestionFilter) ([]models.BankQuestion, error) {
+	if filter.ConductorID == 0 {
+		return nil, errors.New("invalid conductor ID")
+	}
+	if filter.Scope != "" {
+		scope, err := bankScope(filter.Scope)
+		if err != nil {
+			return nil, err
+		}
+		filter.Scope = scope
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	filter.QuestionType = strings.ToUpper(strings.TrimSpace(filter.QuestionType))
+	filter.Search = strings.TrimSpace(filter.Search)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultBankPageSize
+	}
+	if filter.Limit > maxBankPageSize {
+		filter.Limit = maxBankPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.bankRepo.Search(ctx, filter)
+}
+
+// InsertIntoDraft appends a bank question and its options to a draft as a new question
+// that remembers its bank origin. The question keeps the bank question's key unless the
+// draft already uses it. The insert is saved as a draft revision like any other edit;
+// with AnyRevision it is still checked against the revision it was prepared from, so a
+// concurrent save surfaces as a *DraftConflictError.
+func (s *questionBankService) InsertIntoDraft(ctx context.Context, id, conductorID, draftID uint, pageID *uint, authorID uint, expectedRevision int) (*BankInsertResult, error) {
+	question, err := s.GetQuestion(ctx, id, conductorID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := storedBankContent(question)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.surveyService.GetDraft(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDraftDocument(draft.DraftContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextID uint = 1
+	keys := make(map[string]bool, len(doc.Questions))
+	for _, q := range doc.Questions {
+		if q.QuestionID >= nextID {
+			nextID = q.QuestionID + 1
+		}
+		if key, err := NormalizeQuestionKey(q.QuestionKey); err == nil && key != "" {
+			keys[key] = true
+		}
+	}
+
+	inserted := content.Question
+	inserted.QuestionID = nextID
+	inserted.BankQuestionID = &question.BankQuestionID
+	inserted.PageID = pageID
+	if key, _ := NormalizeQuestionKey(inserted.QuestionKey); keys[key] {
+		inserted.QuestionKey = ""
+	}
+	options := make([]interface{}, 0, len(content.Options))
+	for _, option := range content.Options {
+		option.QuestionID = nextID
+		options = append(options, option)
+	}
+	ops := appendOps(draft.DraftContent, "questions", []interface{}{inserted})
+	ops = append(ops, appendOps(draft.DraftContent, "options", options)...)
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedRevision == AnyRevision {
+		expectedRevision = draft.Revision
+	}
+	updated, err := s.surveyService.PatchDraft(ctx, draftID, PatchFormatJSONPatch, patch, &nextID, authorID, expectedRevision)
+	if err != nil {
+		return nil, err
+	}
+	return &BankInsertResult{Draft: updated, QuestionID: nextID}, nil
+}
+
+// CompareResults returns the results of every published question inserted from a bank
+// question into the conductor's own surveys, one entry per survey version
+func (s *questionBankService) CompareResults(ctx context.Context, id, conductorID uint) ([]BankQuestionResults, error) {
+	if _, err := s.GetQuestion(ctx, id, conductorID); err != nil {
+		return nil, err
+	}
+	questions, err := s.bankRepo.GetInsertedQuestions(ctx, id, conductorID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := make([]BankQuestionResults, 0, len(questions))
+	for _, q := range questions {
+		results, err := s.answerService.GetQuestionResults(ctx, q.QuestionID)
+		if err != nil {
+			return nil, err
+		}
+		comparison = append(comparison, BankQuestionResults{
+			SurveyID:        q.SurveyID,
+			SurveyVersionID: q.SurveyVersionID,
+			QuestionID:      q.QuestionID,
+			QuestionKey:     q.QuestionKey,
+			Retired:         q.RetiredAt != nil,
+			Results:         results,
+		})
+	}
+	return comparison, nil
+}
+
+// appendOps are the JSON Patch operations appending values to one of the draft's
+// top-level arrays. When the draft has no such array, a single operation creates it with
+// all the values, since separate adds would each replace the one before.
+func appendOps(content models.JSONContent, field string, values []interface{}) []map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(content, &doc); err == nil {
+		if raw, ok := doc[field]; ok && bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			ops := make([]map[string]interface{}, 0, len(values))
+			for _, value := range values {
+				ops = append(ops, map[string]interface{}{"op": "add", "path": "/" + field + "/-", "value": value})
+			}
+			return ops
+		}
+	}
+	return []map[string]interface{}{{"op": "add", "path": "/" + field, "value": values}}
+}
+
+// bankScope normalises a bank question's scope; empty means PERSONAL
+func bankScope(scope string) (string, error) {
+	scope = strings.ToUpper(strings.TrimSpace(scope))
+	switch scope {
+	case "":
+		return models.TemplateScopePersonal, nil
+	case models.TemplateScopePersonal, models.TemplateScopeOrganisation:
+		return scope, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidTemplateScope, scope)
+}
+
+// normalizeTags trims and lower-cases tags and drops empty and repeated ones
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// setBankContent stores content and tags on a bank question along with the columns
+// copied from content
+func setBankContent(question *models.BankQuestion, content *bankContent, tags []string) error {
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	tagsJSON, err := json.Marshal(normalizeTags(tags))
+	if err != nil {
+		return err
+	}
+	question.QuestionText = content.Question.QuestionText
+	question.QuestionType = content.Question.QuestionType
+	question.Content = models.JSONContent(contentJSON)
+	question.Tags = models.JSONContent(tagsJSON)
+	return nil
+}
+
+func storedBankContent(question *models.BankQuestion) (*bankContent, error) {
+	var content bankContent
+	if err := json.Unmarshal(question.Content, &content); err != nil {
+		return nil, fmt.Errorf("reading bank question %d: %w", question.BankQuestionID, err)
+	}
+	return &content, nil
+}
+
+// parseBankContent reads and checks content given in the draft format. It must pass the
+// checks a draft question gets on publish; branching logic, pages and bank origins refer
+// to a particular survey and are dropped.
+func parseBankContent(raw models.JSONContent) (*bankContent, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var content bankContent
+	if err := decoder.Decode(&content); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBankQuestion, err)
+	}
+
+	content.Question.QuestionID = 1
+	content.Question.SourceQuestion = 0
+	content.Question.BranchingLogic = ""
+	content.Question.PageID = nil
+	content.Question.BankQuestionID = nil
+	if content.Options == nil {
+		content.Options = []draftOption{}
+	}
+	for i := range content.Options {
+		content.Options[i].QuestionID = 1
+	}
+
+	// Check it as the only question of a draft, reporting paths within the content
+	doc, err := json.Marshal(map[string]interface{}{
+		"basicInfo": map[string]interface{}{},
+		"questions": []draftQuestion{content.Question},
+		"options":   content.Options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var errs []DraftFieldError
+	if _, err := validateDraftContent(models.JSONContent(doc), false); err != nil {
+		var invalid *DraftValidationError
+		if !errors.As(err, &invalid) {
+			return nil, err
+		}
+		errs = invalid.Errors
+	} else {
+		question := content.Question.model()
+		options := make([]models.Option, 0, len(content.Options))
+		for _, opt := range content.Options {
+			options = append(options, models.Option{OptionText: opt.OptionText, MediaID: opt.MediaID, PinLast: opt.PinLast})
+		}
+		if !questionTypes[question.QuestionType] {
+			errs = append(errs, DraftFieldError{Path: "/questions/0/question_type", Message: fmt.Sprintf("unknown question type %q", question.QuestionType)})
+		} else if err := validateQuestionConfig(&question); err != nil {
+			errs = append(errs, DraftFieldError{Path: "/question", Message: strings.TrimPrefix(err.Error(), ErrInvalidQuestionConfig.Error()+": ")})
+		}
+		if err := validateOptions(question.QuestionType, options); err != nil {
+			errs = append(errs, DraftFieldError{Path: "/options", Message: strings.TrimPrefix(err.Error(), ErrInvalidQuestionConfig.Error()+": ")})
+		}
+	}
+	if len(errs) > 0 {
+		for i := range errs {
+			errs[i].Path = "/content" + strings.Replace(errs[i].Path, "/questions/0", "/question", 1)
+		}
+		return nil, &DraftValidationError{Errors: errs}
+	}
+
+	content.Question.QuestionID = 0
+	for i := range content.Options {
+		content.Options[i].QuestionID = 0
+	}
+	return &content, nil
+}
+
+// bankContentFromQuestion converts a survey question to bank content
+func bankContentFromQuestion(q *models.Question) *bankContent {
+	content := &bankContent{
+		Question: draftQuestion{
+			QuestionKey:    q.QuestionKey,
+			QuestionText:   q.QuestionText,
+			QuestionType:   q.QuestionType,
+			Mandatory:      q.Mandatory,
+			CorrectAnswers: q.CorrectAnswers,
+
+			ValidationRules:     q.ValidationRules,
+			QuestionConstraints: q.QuestionConstraints,
+			RandomizeOptions:    q.RandomizeOptions,
+		},
+		Options: make([]draftOption, 0, len(q.Options)),
+	}
+	if len(q.MatrixRows) > 0 || len(q.MatrixColumns) > 0 {
+		matrix := &draftMatrix{MultiSelect: q.MatrixMulti}
+		for _, row := range q.MatrixRows {
+			matrix.Rows = append(matrix.Rows, draftMatrixRow{Text: row.RowText})
+		}
+		for _, column := range q.MatrixColumns {
+			matrix.Columns = append(matrix.Columns, draftMatrixColumn{Text: column.ColumnText, Value: column.Value})
+		}
+		content.Question.Matrix = matrix
+	}
+	if q.QuestionType == QuestionTypeCode {
+		code := &draftCode{Language: q.CodeLanguage, StarterCode: q.StarterCode, Tests: []draftCodeTest{}}
+		for _, test := range q.CodeTests {
+			code.Tests = append(code.Tests, draftCodeTest{
+				Name:           test.Name,
+				Input:          test.Input,
+				ExpectedOutput: test.ExpectedOutput,
+				Hidden:         test.Hidden,
+			})
+		}
+		content.Question.Code = code
+	}
+	for _, option := range q.Options {
+		content.Options = append(content.Options, draftOption{OptionKey: option.OptionKey, OptionText: option.OptionText, MediaID: option.MediaID, PinLast: option.PinLast})
+	}
+	return content
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidQuestionKey = errors.New("invalid question key")
+
+// questionKeyPattern accepts conductor-chosen slugs ("age", "nps-score") as well as
+// the UUIDs generated for questions that were published without a key
+var questionKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// NewQuestionKey generates a key for a question, or an option, that does not have one
+// yet. Option keys follow the same format.
+func NewQuestionKey() string {
+	return uuid.New().String()
+}
+
+// NormalizeQuestionKey trims and lower-cases a key and checks its format. An empty
+// key is returned as is, meaning "assign one".
+func NormalizeQuestionKey(key string) (string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" {
+		return "", nil
+	}
+	if !questionKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("%w: %q must be 1-64 lowercase letters, digits, '-' or '_'", ErrInvalidQuestionKey, key)
+	}
+	return key, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// QuestionResults summarises the answers to one question. Only question types with a
+// structured answer have a breakdown; for the rest Responses is all there is.
+type QuestionResults struct {
+	QuestionID   uint              `json:"question_id"`
+	QuestionType string            `json:"question_type"`
+	Responses    int               `json:"responses"`
+	Invalid      int               `json:"invalid"` // Answers that no longer fit the question, e.g. after a row was removed
+	Matrix       []MatrixRowResult `json:"matrix,omitempty"`
+	Ranking      []RankingResult   `json:"ranking,omitempty"` // Best Borda score first
+	Code         *CodeResults      `json:"code,omitempty"`
+}
+
+// CodeResults summarises the evaluations of a CODE question's answers
+type CodeResults struct {
+	Evaluated  int              `json:"evaluated"`   // Answers with an evaluation; skipped optional answers have none
+	AllPassed  int              `json:"all_passed"`  // Answers that passed every test
+	MeanPassed float64          `json:"mean_passed"` // Mean share of tests passed, from 0 to 1
+	Tests      []CodeTestResult `json:"tests"`
+}
+
+// CodeTestResult counts how the evaluated answers fared on one test
+type CodeTestResult struct {
+	TestID   uint    `json:"test_id"`
+	Name     string  `json:"name"`
+	Hidden   bool    `json:"hidden"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"` // Including timeouts and runtime errors
+	PassRate float64 `json:"pass_rate"`
+}
+
+// MatrixRowResult is the distribution of answers over the columns of one matrix row
+type MatrixRowResult struct {
+	RowID     uint               `json:"row_id"`
+	RowText   string             `json:"row_text"`
+	Responses int                `json:"responses"`
+	Columns   []MatrixCellResult `json:"columns"`
+	Mean      *float64           `json:"mean,omitempty"` // Mean column value, when every chosen column has one
+}
+
+// RankingResult is how one option of a RANKING or IMAGE_RANKING question fared. An
+// option at rank r of n options earns n-r Borda points; unranked options earn none, so
+// partial rankings count towards the options they list.
+type RankingResult struct {
+	OptionID   uint     `json:"option_id"`
+	OptionText string   `json:"option_text"`
+	MediaID    *uint    `json:"media_id,omitempty"`
+	Ranked     int      `json:"ranked"`              // Answers that ranked the option at all
+	FirstPlace int      `json:"first_place"`         // Answers that ranked it first
+	MeanRank   *float64 `json:"mean_rank,omitempty"` // Over the answers that ranked it, 1 being best
+	BordaScore int      `json:"borda_score"`
+}
+
+// MatrixCellResult counts how often one column was chosen for a row. Percentage is of
+// the row's responses, so multi-select rows can add up to more than 100.
+type MatrixCellResult struct {
+	ColumnID   uint    `json:"column_id"`
+	ColumnText string  `json:"column_text"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+func (s *answerService) GetQuestionResults(ctx context.Context, questionID uint) (*QuestionResults, error) {
+	if questionID == 0 {
+		return nil, errors.New("invalid question ID")
+	}
+
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.answerRepo.GetByQuestionID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &QuestionResults{
+		QuestionID:   question.QuestionID,
+		QuestionType: question.QuestionType,
+	}
+	switch question.QuestionType {
+	case QuestionTypeMatrix:
+		matrixResults(question, answers, results)
+	case QuestionTypeRanking, QuestionTypeImageRanking:
+		rankingResults(question, answers, results)
+	case QuestionTypeCode:
+		codeResults(question, answers, results)
+	default:
+		results.Responses = len(answers)
+	}
+	return results, nil
+}
+
+func matrixResults(question *models.Question, answers []models.Answer, results *QuestionResults) {
+	counts := make(map[uint]map[uint]int, len(question.MatrixRows))
+	responses := make(map[uint]int, len(question.MatrixRows))
+	for _, answer := range answers {
+		chosen, err := parseMatrixAnswer(question, []byte(answer.ResponseData))
+		if err != nil {
+			results.Invalid++
+			continue
+		}
+		results.Responses++
+		for rowID, columns := range chosen {
+			if counts[rowID] == nil {
+				counts[rowID] = make(map[uint]int, len(columns))
+			}
+			for _, columnID := range columns {
+				counts[rowID][columnID]++
+			}
+			responses[rowID]++
+		}
+	}
+
+	results.Matrix = make([]MatrixRowResult, 0, len(question.MatrixRows))
+	for _, row := range question.MatrixRows {
+		result := MatrixRowResult{
+			RowID:     row.RowID,
+			RowText:   row.RowText,
+			Responses: responses[row.RowID],
+			Columns:   make([]MatrixCellResult, 0, len(question.MatrixColumns)),
+		}
+
+		var sum float64
+		var chosen int
+		scored := true
+		for _, column := range question.MatrixColumns {
+			count := counts[row.RowID][column.ColumnID]
+			cell := MatrixCellResult{
+				ColumnID:   column.ColumnID,
+				ColumnText: column.ColumnText,
+				Count:      count,
+			}
+			if result.Responses > 0 {
+				cell.Percentage = float64(count) * 100 / float64(result.Responses)
+			}
+			result.Columns = append(result.Columns, cell)
+
+			if count == 0 {
+				continue
+			}
+			if column.Value == nil {
+				scored = false
+				continue
+			}
+			sum += *column.Value * float64(count)
+			chosen += count
+		}
+		if scored && chosen > 0 {
+			mean := sum / float64(chosen)
+			result.Mean = &mean
+		}
+		results.Matrix = append(results.Matrix, result)
+	}
+}
+
+func rankingResults(question *models.Question, answers []models.Answer, results *QuestionResults) {
+	n := len(question.Options)
+	rankSums := make(map[uint]int, n)
+	byOption := make(map[uint]*RankingResult, n)
+	results.Ranking = make([]RankingResult, n)
+	for i, option := range question.Options {
+		results.Ranking[i] = RankingResult{
+			OptionID:   option.OptionID,
+			OptionText: option.OptionText,
+			MediaID:    option.MediaID,
+		}
+		byOption[option.OptionID] = &results.Ranking[i]
+	}
+
+	for _, answer := range answers {
+		ranking, err := parseRankingAnswer(question, []byte(answer.ResponseData))
+		if err != nil {
+			results.Invalid++
+			continue
+		}
+		results.Responses++
+		for i, optionID := range ranking {
+			result := byOption[optionID]
+			result.Ranked++
+			result.BordaScore += n - (i + 1)
+			if i == 0 {
+				result.FirstPlace++
+			}
+			rankSums[optionID] += i + 1
+		}
+	}
+
+	for i := range results.Ranking {
+		result := &results.Ranking[i]
+		if result.Ranked > 0 {
+			mean := float64(rankSums[result.OptionID]) / float64(result.Ranked)
+			result.MeanRank = &mean
+		}
+	}
+	sort.SliceStable(results.Ranking, func(i, j int) bool {
+		return results.Ranking[i].BordaScore > results.Ranking[j].BordaScore
+	})
+}
+
+func codeResults(question *models.Question, answers []models.Answer, results *QuestionResults) {
+	code := &CodeResults{Tests: make([]CodeTestResult, len(question.CodeTests))}
+	byTest := make(map[uint]*CodeTestResult, len(question.CodeTests))
+	for i, test := range question.CodeTests {
+		code.Tests[i] = CodeTestResult{TestID: test.TestID, Name: test.Name, Hidden: test.Hidden}
+		byTest[test.TestID] = &code.Tests[i]
+	}
+
+	var shareSum float64
+	for _, answer := range answers {
+		if _, err := parseCodeAnswer(question, []byte(answer.ResponseData)); err != nil {
+			results.Invalid++
+			continue
+		}
+		results.Responses++
+		if answer.Evaluation == "" {
+			continue
+		}
+		var evaluation CodeEvaluation
+		if err := json.Unmarshal([]byte(answer.Evaluation), &evaluation); err != nil || evaluation.Status != "" {
+			continue
+		}
+
+		code.Evaluated++
+		if evaluation.Total > 0 {
+			shareSum += float64(evaluation.Passed) / float64(evaluation.Total)
+			if evaluation.Passed == evaluation.Total {
+				code.AllPassed++
+			}
+		}
+		for _, outcome := range evaluation.Tests {
+			// Tests removed since the answer was evaluated are not reported
+			result, ok := byTest[outcome.TestID]
+			if !ok {
+				continue
+			}
+			if outcome.Passed {
+				result.Passed++
+			} else {
+				result.Failed++
+			}
+		}
+	}
+
+	if code.Evaluated > 0 {
+		code.MeanPassed = shareSum / float64(code.Evaluated)
+	}
+	for i := range code.Tests {
+		if total := code.Tests[i].Passed + code.Tests[i].Failed; total > 0 {
+			code.Tests[i].PassRate = float64(code.Tests[i].Passed) / float64(total)
+		}
+	}
+	results.Code = code
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
@@ -15,6 +16,7 @@ type QuestionService interface {
 	CreateQuestion(ctx context.Context, question *models.Question) error
 	GetQuestionByID(ctx context.Context, id uint) (*models.Question, error)
 	GetQuestionsBySurveyID(ctx context.Context, surveyID uint) ([]models.Question, error)
+	GetQuestionByKey(ctx context.Context, surveyID uint, key string) (*models.Question, error)
 	UpdateQuestion(ctx context.Context, question *models.Question) error
 	DeleteQuestion(ctx context.Context, id uint) error
 	ValidateQuestionType(questionType string) bool
@@ -25,13 +27,15 @@ type questionService struct {
 	questionRepo repository.QuestionRepository
 	optionRepo   repository.OptionRepository
 	surveyRepo   repository.SurveyRepository
+	sectionRepo  repository.SectionRepository
 }
 
-func NewQuestionService(questionRepo repository.QuestionRepository, optionRepo repository.OptionRepository, surveyRepo repository.SurveyRepository) QuestionService {
+func NewQuestionService(questionRepo repository.QuestionRepository, optionRepo repository.OptionRepository, surveyRepo repository.SurveyRepository, sectionRepo repository.SectionRepository) QuestionService {
 	return &questionService{
 		questionRepo: questionRepo,
 		optionRepo:   optionRepo,
 		surveyRepo:   surveyRepo,
+		sectionRepo:  sectionRepo,
 	}
 }
 
@@ -43,11 +47,56 @@ func (s *questionService) CreateQuestion(ctx context.Context, question *models.Q
 	if !s.ValidateQuestionType(question.QuestionType) {
 		return errors.New("invalid question type")
 	}
+	if err := validateQuestionConfig(question); err != nil {
+		return err
+	}
+	if err := s.checkPage(ctx, question); err != nil {
+		return err
+	}
+
+	if err := s.assignQuestionKey(ctx, question); err != nil {
+		return err
+	}
 
 	question.CreatedAt = time.Now()
 	question.UpdatedAt = time.Now()
 
-	return s.questionRepo.Create(ctx, question)
+	return keyInUse(s.questionRepo.Create(ctx, question), question.QuestionKey)
+}
+
+// assignQuestionKey normalizes the question's key, generating one when it is empty,
+// and makes sure no other current question of the survey uses it
+func (s *questionService) assignQuestionKey(ctx context.Context, question *models.Question) error {
+	key, err := NormalizeQuestionKey(question.QuestionKey)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		question.QuestionKey = NewQuestionKey()
+		return nil
+	}
+
+	existing, err := s.questionRepo.GetByKey(ctx, question.SurveyID, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.QuestionID != question.QuestionID {
+		return fmt.Errorf("%w: %q is already used by question %d", ErrInvalidQuestionKey, key, existing.QuestionID)
+	}
+	question.QuestionKey = key
+	return nil
+}
+
+func (s *questionService) GetQuestionByKey(ctx context.Context, surveyID uint, key string) (*models.Question, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+
+	key, err := NormalizeQuestionKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.questionRepo.GetByKey(ctx, surveyID, key)
 }
 
 func (s *questionService) GetQuestionByID(ctx context.Context, id uint) (*models.Question, error) {
@@ -74,10 +123,57 @@ func (s *questionService) UpdateQuestion(ctx context.Context, question *models.Q
 	if !s.ValidateQuestionType(question.QuestionType) {
 		return errors.New("invalid question type")
 	}
+	if err := validateQuestionConfig(question); err != nil {
+		return err
+	}
+
+	existing, err := s.questionRepo.GetByID(ctx, question.QuestionID)
+	if err != nil {
+		return err
+	}
 
+	// Keys are stable: keep the current one unless the update sets a new one
+	if question.QuestionKey == "" {
+		question.QuestionKey = existing.QuestionKey
+	}
+	if err := s.assignQuestionKey(ctx, question); err != nil {
+		return err
+	}
+
+	// Version bookkeeping is owned by publishing, not by edits
+	question.SurveyVersionID = existing.SurveyVersionID
+	question.LineageID = existing.LineageID
+	question.RetiredAt = existing.RetiredAt
+	question.BankQuestionID = existing.BankQuestionID
+	question.CreatedAt = existing.CreatedAt
 	question.UpdatedAt = time.Now()
 
-	return s.questionRepo.Update(ctx, question)
+	// A question's place only changes through the survey layout
+	question.PageID = existing.PageID
+	question.Position = existing.Position
+
+	return keyInUse(s.questionRepo.Update(ctx, question), question.QuestionKey)
+}
+
+// keyInUse reports a key another writer took after assignQuestionKey checked it as the
+// error the check itself returns
+func keyInUse(err error, key string) error {
+	if errors.Is(err, repository.ErrDuplicateQuestionKey) {
+		return fmt.Errorf("%w: %q is already used", ErrInvalidQuestionKey, key)
+	}
+	return err
+}
+
+// checkPage makes sure the page a new question is put on belongs to its survey
+func (s *questionService) checkPage(ctx context.Context, question *models.Question) error {
+	if question.PageID == nil {
+		return nil
+	}
+	page, err := s.sectionRepo.GetPage(ctx, *question.PageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && page.SurveyID != question.SurveyID) {
+		return fmt.Errorf("%w: page %d is not in survey %d", ErrInvalidLayout, *question.PageID, question.SurveyID)
+	}
+	return err
 }
 
 func (s *questionService) DeleteQuestion(ctx context.Context, id uint) error {
@@ -89,16 +185,7 @@ func (s *questionService) DeleteQuestion(ctx context.Context, id uint) error {
 }
 
 func (s *questionService) ValidateQuestionType(questionType string) bool {
-	validTypes := map[string]bool{
-		"TEXT":            true,
-		"MULTIPLE_CHOICE": true,
-		"SINGLE_CHOICE":   true,
-		"RATING":          true,
-		"FILE_UPLOAD":     true,
-		"VIDEO":           true,
-		"AUDIO":           true,
-	}
-	return validTypes[questionType]
+	return questionTypes[questionType]
 }
 
 func (s *questionService) CreateQuestionWithOptions(ctx context.Context, question *models.Question, options []models.Option) error {
@@ -111,27 +198,55 @@ func (s *questionService) CreateQuestionWithOptions(ctx context.Context, questio
 	}
 
 	// Check if we need options
-	needsOptions := question.QuestionType == "MULTIPLE_CHOICE" || question.QuestionType == "SINGLE_CHOICE"
+	needsOptions := TakesOptions(question.QuestionType)
 	if needsOptions && (options == nil || len(options) == 0) {
 		return errors.New("options required for this question type")
 	}
+	if err := validateQuestionConfig(question); err != nil {
+		return err
+	}
+	if err := validateOptions(question.QuestionType, options); err != nil {
+		return err
+	}
+	if err := s.checkPage(ctx, question); err != nil {
+		return err
+	}
+	media, err := s.optionRepo.GetMediaFiles(ctx, optionMediaIDs(options))
+	if err != nil {
+		return err
+	}
+	if err := validateOptionMedia(question.QuestionType, options, media); err != nil {
+		return err
+	}
 
 	// Use a transaction to ensure atomicity
 	return s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.assignQuestionKey(ctx, question); err != nil {
+			return err
+		}
+
 		// Set timestamps
 		now := time.Now()
 		question.CreatedAt = now
 		question.UpdatedAt = now
 
+		position, err := s.sectionRepo.NextQuestionPositionWithTx(ctx, tx, question.SurveyID)
+		if err != nil {
+			return err
+		}
+		question.Position = position
+
 		// Create the question
 		if err := tx.Create(question).Error; err != nil {
-			return err
+			return keyInUse(repository.QuestionKeyError(err), question.QuestionKey)
 		}
 
 		// Create options if needed
 		if needsOptions && len(options) > 0 {
 			for i := range options {
 				options[i].QuestionID = question.QuestionID
+				options[i].Media = nil // Referenced by MediaID, never created through an option
+				options[i].Position = i
 				options[i].CreatedAt = now
 				options[i].UpdatedAt = now
 			}
@@ -141,6 +256,10 @@ func (s *questionService) CreateQuestionWithOptions(ctx context.Context, questio
 			}
 		}
 
+		// Moves the question from the end of the survey to the end of its page
+		if question.PageID != nil {
+			return s.sectionRepo.RenumberQuestionsWithTx(ctx, tx, question.SurveyID)
+		}
 		return nil
 	})
 }
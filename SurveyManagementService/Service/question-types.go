@@ -0,0 +1,458 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// Question types
+const (
+	QuestionTypeText           = "TEXT"
+	QuestionTypeMultipleChoice = "MULTIPLE_CHOICE"
+	QuestionTypeSingleChoice   = "SINGLE_CHOICE"
+	QuestionTypeRating         = "RATING"
+	QuestionTypeFileUpload     = "FILE_UPLOAD"
+	QuestionTypeVideo          = "VIDEO"
+	QuestionTypeAudio          = "AUDIO"
+	QuestionTypeMatrix         = "MATRIX"
+	QuestionTypeRanking        = "RANKING"
+	QuestionTypeImageRanking   = "IMAGE_RANKING"
+	QuestionTypeCode           = "CODE"
+	QuestionTypeNumber         = "NUMBER"
+	QuestionTypeSlider         = "SLIDER"
+	QuestionTypeDate           = "DATE"
+	QuestionTypeTime           = "TIME"
+	QuestionTypeDateTime       = "DATETIME"
+	QuestionTypeNPS            = "NPS" // Net Promoter Score: 0 to 10
+)
+
+var questionTypes = map[string]bool{
+	QuestionTypeText:           true,
+	QuestionTypeMultipleChoice: true,
+	QuestionTypeSingleChoice:   true,
+	QuestionTypeRating:         true,
+	QuestionTypeFileUpload:     true,
+	QuestionTypeVideo:          true,
+	QuestionTypeAudio:          true,
+	QuestionTypeMatrix:         true,
+	QuestionTypeRanking:        true,
+	QuestionTypeImageRanking:   true,
+	QuestionTypeCode:           true,
+	QuestionTypeNumber:         true,
+	QuestionTypeSlider:         true,
+	QuestionTypeDate:           true,
+	QuestionTypeTime:           true,
+	QuestionTypeDateTime:       true,
+	QuestionTypeNPS:            true,
+}
+
+// TakesOptions reports whether questions of a type are answered from their options
+func TakesOptions(questionType string) bool {
+	switch questionType {
+	case QuestionTypeMultipleChoice, QuestionTypeSingleChoice, QuestionTypeRanking, QuestionTypeImageRanking:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrInvalidQuestionConfig means a question's type-specific settings are incomplete or inconsistent
+	ErrInvalidQuestionConfig = errors.New("invalid question configuration")
+	// ErrInvalidAnswer means an answer does not fit the question it answers
+	ErrInvalidAnswer = errors.New("invalid answer")
+)
+
+// validateQuestionConfig checks the question's validation rules and the settings its
+// type depends on, and numbers its sub-items in the order given
+func validateQuestionConfig(question *models.Question) error {
+	if err := validateValidationRules(question.ValidationRules); err != nil {
+		return err
+	}
+	if question.RandomizeOptions && !TakesOptions(question.QuestionType) {
+		return fmt.Errorf("%w: %s questions have no options to shuffle", ErrInvalidQuestionConfig, question.QuestionType)
+	}
+	switch question.QuestionType {
+	case QuestionTypeMatrix:
+		return validateMatrixConfig(question)
+	case QuestionTypeCode:
+		return validateCodeConfig(question)
+	case QuestionTypeNumber, QuestionTypeSlider:
+		return validateNumericConfig(question)
+	case QuestionTypeDate, QuestionTypeTime, QuestionTypeDateTime:
+		return validateTemporalConfig(question)
+	}
+	return nil
+}
+
+func validateMatrixConfig(question *models.Question) error {
+	if len(question.MatrixRows) == 0 {
+		return fmt.Errorf("%w: a matrix needs at least one row", ErrInvalidQuestionConfig)
+	}
+	if len(question.MatrixColumns) < 2 {
+		return fmt.Errorf("%w: a matrix needs at least two columns", ErrInvalidQuestionConfig)
+	}
+	for i := range question.MatrixRows {
+		if strings.TrimSpace(question.MatrixRows[i].RowText) == "" {
+			return fmt.Errorf("%w: matrix row %d has no text", ErrInvalidQuestionConfig, i+1)
+		}
+		question.MatrixRows[i].Position = i
+	}
+	for i := range question.MatrixColumns {
+		if strings.TrimSpace(question.MatrixColumns[i].ColumnText) == "" {
+			return fmt.Errorf("%w: matrix column %d has no text", ErrInvalidQuestionConfig, i+1)
+		}
+		question.MatrixColumns[i].Position = i
+	}
+	return nil
+}
+
+// maxCodeSize bounds a CODE submission and its starter code
+const maxCodeSize = 64 * 1024
+
+func validateCodeConfig(question *models.Question) error {
+	if !SupportsCodeLanguage(question.CodeLanguage) {
+		return fmt.Errorf("%w: code_language must be one of %s", ErrInvalidQuestionConfig, strings.Join(codeLanguageNames(), ", "))
+	}
+	if len(question.StarterCode) > maxCodeSize {
+		return fmt.Errorf("%w: starter code is longer than %d bytes", ErrInvalidQuestionConfig, maxCodeSize)
+	}
+	if len(question.CodeTests) == 0 {
+		return fmt.Errorf("%w: a CODE question needs at least one test", ErrInvalidQuestionConfig)
+	}
+	for i := range question.CodeTests {
+		question.CodeTests[i].Position = i
+	}
+	return nil
+}
+
+func validateNumericConfig(question *models.Question) error {
+	c := question.QuestionConstraints
+	if question.QuestionType == QuestionTypeSlider && (c.MinValue == nil || c.MaxValue == nil) {
+		return fmt.Errorf("%w: a slider needs min_value and max_value", ErrInvalidQuestionConfig)
+	}
+	if c.MinValue != nil && c.MaxValue != nil && *c.MinValue > *c.MaxValue {
+		return fmt.Errorf("%w: min_value is greater than max_value", ErrInvalidQuestionConfig)
+	}
+	if c.Step != nil && *c.Step <= 0 {
+		return fmt.Errorf("%w: step must be positive", ErrInvalidQuestionConfig)
+	}
+	return nil
+}
+
+// temporalLayouts are the formats of DATE, TIME and DATETIME answers and limits
+var temporalLayouts = map[string][]string{
+	QuestionTypeDate:     {"2006-01-02"},
+	QuestionTypeTime:     {"15:04", "15:04:05"},
+	QuestionTypeDateTime: {time.RFC3339},
+}
+
+func validateTemporalConfig(question *models.Question) error {
+	c := question.QuestionConstraints
+	var earliest, latest time.Time
+	var err error
+	if c.Earliest != "" {
+		if earliest, err = parseTemporal(question.QuestionType, c.Earliest); err != nil {
+			return fmt.Errorf("%w: earliest: %v", ErrInvalidQuestionConfig, err)
+		}
+	}
+	if c.Latest != "" {
+		if latest, err = parseTemporal(question.QuestionType, c.Latest); err != nil {
+			return fmt.Errorf("%w: latest: %v", ErrInvalidQuestionConfig, err)
+		}
+	}
+	if c.Earliest != "" && c.Latest != "" && earliest.After(latest) {
+		return fmt.Errorf("%w: earliest is after latest", ErrInvalidQuestionConfig)
+	}
+	return nil
+}
+
+// parseTemporal reads a value in one of the type's layouts
+func parseTemporal(questionType, value string) (time.Time, error) {
+	layouts := temporalLayouts[questionType]
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a valid %s (expected %s)", value, strings.ToLower(questionType), strings.Join(layouts, " or "))
+}
+
+// validateOptions checks the options a question is created with. Ranking needs something
+// to order, and every option of an image ranking needs its image.
+func validateOptions(questionType string, options []models.Option) error {
+	switch questionType {
+	case QuestionTypeRanking, QuestionTypeImageRanking:
+		if len(options) < 2 {
+			return fmt.Errorf("%w: a ranking needs at least two options", ErrInvalidQuestionConfig)
+		}
+	}
+	if questionType == QuestionTypeImageRanking {
+		for i, option := range options {
+			if option.MediaID == nil {
+				return fmt.Errorf("%w: option %d has no media_id", ErrInvalidQuestionConfig, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// optionMediaIDs returns the media the options refer to
+func optionMediaIDs(options []models.Option) []uint {
+	var ids []uint
+	for _, option := range options {
+		if option.MediaID != nil {
+			ids = append(ids, *option.MediaID)
+		}
+	}
+	return ids
+}
+
+// validateOptionMedia checks that the media the options refer to exist, and are images
+// for an image ranking. media holds the referenced media files that were found.
+func validateOptionMedia(questionType string, options []models.Option, media []models.SurveyMediaFile) error {
+	byID := make(map[uint]models.SurveyMediaFile, len(media))
+	for _, m := range media {
+		byID[m.MediaID] = m
+	}
+	for _, option := range options {
+		if option.MediaID == nil {
+			continue
+		}
+		m, ok := byID[*option.MediaID]
+		if !ok {
+			return fmt.Errorf("%w: media %d does not exist", ErrInvalidQuestionConfig, *option.MediaID)
+		}
+		if questionType == QuestionTypeImageRanking && m.FileType != "IMAGE" {
+			return fmt.Errorf("%w: media %d is not an image", ErrInvalidQuestionConfig, m.MediaID)
+		}
+	}
+	return nil
+}
+
+// validateResponse checks an answer's ResponseData against its question. Types without
+// a structured answer only need valid JSON, which the caller has checked.
+func validateResponse(question *models.Question, data []byte) error {
+	switch question.QuestionType {
+	case QuestionTypeMatrix:
+		_, err := parseMatrixAnswer(question, data)
+		return err
+	case QuestionTypeRanking, QuestionTypeImageRanking:
+		_, err := parseRankingAnswer(question, data)
+		return err
+	case QuestionTypeCode:
+		_, err := parseCodeAnswer(question, data)
+		return err
+	case QuestionTypeNumber, QuestionTypeSlider, QuestionTypeNPS:
+		_, err := parseNumericAnswer(question, data)
+		return err
+	case QuestionTypeDate, QuestionTypeTime, QuestionTypeDateTime:
+		_, err := parseTemporalAnswer(question, data)
+		return err
+	}
+	return nil
+}
+
+// parseNumericAnswer reads a NUMBER, SLIDER or NPS answer: a JSON number such as 42.5,
+// or null when an optional question is skipped. It returns nil for a skipped answer.
+func parseNumericAnswer(question *models.Question, data []byte) (*float64, error) {
+	var value *float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("%w: the answer must be a number", ErrInvalidAnswer)
+	}
+	if value == nil {
+		if question.Mandatory {
+			return nil, fmt.Errorf("%w: a value is required", ErrInvalidAnswer)
+		}
+		return nil, nil
+	}
+	v := *value
+
+	if question.QuestionType == QuestionTypeNPS {
+		// The scale is fixed, whatever the question says
+		if v < 0 || v > 10 || v != math.Trunc(v) {
+			return nil, fmt.Errorf("%w: a score must be a whole number from 0 to 10", ErrInvalidAnswer)
+		}
+		return &v, nil
+	}
+
+	c := question.QuestionConstraints
+	if c.MinValue != nil && v < *c.MinValue {
+		return nil, fmt.Errorf("%w: %v is below the minimum of %v", ErrInvalidAnswer, v, *c.MinValue)
+	}
+	if c.MaxValue != nil && v > *c.MaxValue {
+		return nil, fmt.Errorf("%w: %v is above the maximum of %v", ErrInvalidAnswer, v, *c.MaxValue)
+	}
+	if c.Step != nil {
+		base := 0.0
+		if c.MinValue != nil {
+			base = *c.MinValue
+		}
+		steps := (v - base) / *c.Step
+		if math.Abs(steps-math.Round(steps)) > 1e-9 {
+			return nil, fmt.Errorf("%w: %v is not a multiple of the step %v from %v", ErrInvalidAnswer, v, *c.Step, base)
+		}
+	}
+	return &v, nil
+}
+
+// parseTemporalAnswer reads a DATE, TIME or DATETIME answer: a string in the type's
+// format, e.g. "2024-05-31", "14:30" or "2024-05-31T14:30:00Z", or null when an
+// optional question is skipped. It returns nil for a skipped answer.
+func parseTemporalAnswer(question *models.Question, data []byte) (*time.Time, error) {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("%w: the answer must be a string", ErrInvalidAnswer)
+	}
+	if value == nil || *value == "" {
+		if question.Mandatory {
+			return nil, fmt.Errorf("%w: a value is required", ErrInvalidAnswer)
+		}
+		return nil, nil
+	}
+
+	t, err := parseTemporal(question.QuestionType, *value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnswer, err)
+	}
+	c := question.QuestionConstraints
+	if c.Earliest != "" {
+		if earliest, err := parseTemporal(question.QuestionType, c.Earliest); err == nil && t.Before(earliest) {
+			return nil, fmt.Errorf("%w: %s is before %s", ErrInvalidAnswer, *value, c.Earliest)
+		}
+	}
+	if c.Latest != "" {
+		if latest, err := parseTemporal(question.QuestionType, c.Latest); err == nil && t.After(latest) {
+			return nil, fmt.Errorf("%w: %s is after %s", ErrInvalidAnswer, *value, c.Latest)
+		}
+	}
+	return &t, nil
+}
+
+// codeAnswer is the ResponseData of a CODE answer
+type codeAnswer struct {
+	Code string `json:"code"`
+}
+
+// parseCodeAnswer reads a CODE answer, e.g. {"code": "print(input())"}. The language
+// is the question's.
+func parseCodeAnswer(question *models.Question, data []byte) (string, error) {
+	var answer codeAnswer
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return "", fmt.Errorf("%w: a code answer must be an object with the submitted code", ErrInvalidAnswer)
+	}
+	if len(answer.Code) > maxCodeSize {
+		return "", fmt.Errorf("%w: code is longer than %d bytes", ErrInvalidAnswer, maxCodeSize)
+	}
+	if question.Mandatory && strings.TrimSpace(answer.Code) == "" {
+		return "", fmt.Errorf("%w: code is required", ErrInvalidAnswer)
+	}
+	return answer.Code, nil
+}
+
+// parseRankingAnswer reads a RANKING or IMAGE_RANKING answer: option IDs from most to
+// least preferred, e.g. [7, 5, 9]. The ranking may be partial, listing only the top
+// options; a mandatory question needs at least one.
+func parseRankingAnswer(question *models.Question, data []byte) ([]uint, error) {
+	var ranking []uint
+	if err := json.Unmarshal(data, &ranking); err != nil {
+		return nil, fmt.Errorf("%w: a ranking answer must be a list of option IDs", ErrInvalidAnswer)
+	}
+
+	options := make(map[uint]bool, len(question.Options))
+	for _, option := range question.Options {
+		options[option.OptionID] = true
+	}
+	seen := make(map[uint]bool, len(ranking))
+	for _, id := range ranking {
+		if !options[id] {
+			return nil, fmt.Errorf("%w: %d is not an option of this question", ErrInvalidAnswer, id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: option %d is ranked twice", ErrInvalidAnswer, id)
+		}
+		seen[id] = true
+	}
+
+	if question.Mandatory && len(ranking) == 0 {
+		return nil, fmt.Errorf("%w: rank at least one option", ErrInvalidAnswer)
+	}
+	return ranking, nil
+}
+
+// parseMatrixAnswer reads a MATRIX answer: an object from row ID to the chosen column
+// ID, or to a list of column IDs when the question is multi-select, e.g. {"12": 40}.
+// Unanswered rows are left out; a mandatory question needs every row.
+func parseMatrixAnswer(question *models.Question, data []byte) (map[uint][]uint, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: a matrix answer must be an object from row ID to column ID", ErrInvalidAnswer)
+	}
+
+	rows := make(map[uint]bool, len(question.MatrixRows))
+	for _, row := range question.MatrixRows {
+		rows[row.RowID] = true
+	}
+	columns := make(map[uint]bool, len(question.MatrixColumns))
+	for _, column := range question.MatrixColumns {
+		columns[column.ColumnID] = true
+	}
+
+	answer := make(map[uint][]uint, len(raw))
+	for key, value := range raw {
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || !rows[uint(id)] {
+			return nil, fmt.Errorf("%w: %q is not a row of this matrix", ErrInvalidAnswer, key)
+		}
+		rowID := uint(id)
+
+		var chosen []uint
+		if question.MatrixMulti {
+			if err := json.Unmarshal(value, &chosen); err != nil {
+				return nil, fmt.Errorf("%w: row %d takes a list of column IDs", ErrInvalidAnswer, rowID)
+			}
+		} else {
+			var column uint
+			if err := json.Unmarshal(value, &column); err != nil {
+				return nil, fmt.Errorf("%w: row %d takes exactly one column ID", ErrInvalidAnswer, rowID)
+			}
+			chosen = []uint{column}
+		}
+		if len(chosen) == 0 {
+			continue
+		}
+
+		seen := make(map[uint]bool, len(chosen))
+		for _, column := range chosen {
+			if !columns[column] {
+				return nil, fmt.Errorf("%w: %d is not a column of this matrix", ErrInvalidAnswer, column)
+			}
+			if seen[column] {
+				return nil, fmt.Errorf("%w: row %d lists column %d twice", ErrInvalidAnswer, rowID, column)
+			}
+			seen[column] = true
+		}
+		answer[rowID] = chosen
+	}
+
+	if question.Mandatory {
+		var missing []int
+		for _, row := range question.MatrixRows {
+			if _, ok := answer[row.RowID]; !ok {
+				missing = append(missing, int(row.RowID))
+			}
+		}
+		if len(missing) > 0 {
+			sort.Ints(missing)
+			return nil, fmt.Errorf("%w: rows %v are unanswered", ErrInvalidAnswer, missing)
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+func TestParseMatrixAnswer(t *testing.T) {
+	single := &models.Question{
+		QuestionType:  QuestionTypeMatrix,
+		MatrixRows:    []models.MatrixRow{{RowID: 1}, {RowID: 2}},
+		MatrixColumns: []models.MatrixColumn{{ColumnID: 10}, {ColumnID: 11}},
+	}
+	multi := &models.Question{
+		QuestionType:  QuestionTypeMatrix,
+		MatrixRows:    single.MatrixRows,
+		MatrixColumns: single.MatrixColumns,
+		MatrixMulti:   true,
+	}
+	mandatory := &models.Question{
+		QuestionType:  QuestionTypeMatrix,
+		MatrixRows:    single.MatrixRows,
+		MatrixColumns: single.MatrixColumns,
+		Mandatory:     true,
+	}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		data     string
+		want     map[uint][]uint
+		wantErr  bool
+	}{
+		{name: "one column per row", question: single, data: `{"1": 10, "2": 11}`, want: map[uint][]uint{1: {10}, 2: {11}}},
+		{name: "rows may be left out", question: single, data: `{"2": 10}`, want: map[uint][]uint{2: {10}}},
+		{name: "empty", question: single, data: `{}`, want: map[uint][]uint{}},
+		{name: "list for single select", question: single, data: `{"1": [10]}`, wantErr: true},
+		{name: "unknown row", question: single, data: `{"3": 10}`, wantErr: true},
+		{name: "unknown column", question: single, data: `{"1": 12}`, wantErr: true},
+		{name: "not an object", question: single, data: `[10, 11]`, wantErr: true},
+		{name: "multi select", question: multi, data: `{"1": [10, 11], "2": []}`, want: map[uint][]uint{1: {10, 11}}},
+		{name: "repeated column", question: multi, data: `{"1": [10, 10]}`, wantErr: true},
+		{name: "single column for multi select", question: multi, data: `{"1": 10}`, wantErr: true},
+		{name: "mandatory with every row", question: mandatory, data: `{"1": 10, "2": 10}`, want: map[uint][]uint{1: {10}, 2: {10}}},
+		{name: "mandatory with a row missing", question: mandatory, data: `{"1": 10}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMatrixAnswer(tt.question, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("parseMatrixAnswer(%s) error = %v, want ErrInvalidAnswer", tt.data, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMatrixAnswer(%s): %v", tt.data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMatrixAnswer(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRankingAnswer(t *testing.T) {
+	options := []models.Option{{OptionID: 5}, {OptionID: 7}, {OptionID: 9}}
+	optional := &models.Question{QuestionType: QuestionTypeRanking, Options: options}
+	mandatory := &models.Question{QuestionType: QuestionTypeImageRanking, Options: options, Mandatory: true}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		data     string
+		want     []uint
+		wantErr  bool
+	}{
+		{name: "full ranking", question: optional, data: `[7, 5, 9]`, want: []uint{7, 5, 9}},
+		{name: "partial ranking", question: optional, data: `[9]`, want: []uint{9}},
+		{name: "optional skipped", question: optional, data: `[]`, want: []uint{}},
+		{name: "unknown option", question: optional, data: `[7, 8]`, wantErr: true},
+		{name: "ranked twice", question: optional, data: `[7, 7]`, wantErr: true},
+		{name: "not a list", question: optional, data: `{"7": 1}`, wantErr: true},
+		{name: "mandatory needs one", question: mandatory, data: `[]`, wantErr: true},
+		{name: "mandatory partial", question: mandatory, data: `[5]`, want: []uint{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRankingAnswer(tt.question, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("parseRankingAnswer(%s) error = %v, want ErrInvalidAnswer", tt.data, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRankingAnswer(%s): %v", tt.data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRankingAnswer(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCodeAnswer(t *testing.T) {
+	optional := &models.Question{QuestionType: QuestionTypeCode}
+	mandatory := &models.Question{QuestionType: QuestionTypeCode, Mandatory: true}
+	tooLong := `{"code": "` + strings.Repeat("x", maxCodeSize+1) + `"}`
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		data     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "code", question: optional, data: `{"code": "print(input())"}`, want: "print(input())"},
+		{name: "optional skipped", question: optional, data: `{"code": ""}`, want: ""},
+		{name: "mandatory blank", question: mandatory, data: `{"code": "  \n"}`, wantErr: true},
+		{name: "not an object", question: optional, data: `"print(1)"`, wantErr: true},
+		{name: "too long", question: optional, data: tooLong, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCodeAnswer(tt.question, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("parseCodeAnswer error = %v, want ErrInvalidAnswer", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCodeAnswer: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCodeAnswer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumericAnswer(t *testing.T) {
+	min, max, step := 1.0, 5.0, 0.5
+	bounded := &models.Question{
+		QuestionType:        QuestionTypeSlider,
+		QuestionConstraints: models.QuestionConstraints{MinValue: &min, MaxValue: &max, Step: &step},
+	}
+	optional := &models.Question{QuestionType: QuestionTypeNumber}
+	mandatory := &models.Question{QuestionType: QuestionTypeNumber, Mandatory: true}
+	nps := &models.Question{QuestionType: QuestionTypeNPS}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		data     string
+		want     *float64
+		wantErr  bool
+	}{
+		{name: "any number", question: optional, data: `-12.75`, want: floatPtr(-12.75)},
+		{name: "optional skipped", question: optional, data: `null`},
+		{name: "mandatory skipped", question: mandatory, data: `null`, wantErr: true},
+		{name: "not a number", question: optional, data: `"12"`, wantErr: true},
+		{name: "on a step", question: bounded, data: `3.5`, want: floatPtr(3.5)},
+		{name: "minimum", question: bounded, data: `1`, want: floatPtr(1)},
+		{name: "below minimum", question: bounded, data: `0.5`, wantErr: true},
+		{name: "above maximum", question: bounded, data: `5.5`, wantErr: true},
+		{name: "off step", question: bounded, data: `3.2`, wantErr: true},
+		{name: "nps score", question: nps, data: `10`, want: floatPtr(10)},
+		{name: "nps out of scale", question: nps, data: `11`, wantErr: true},
+		{name: "nps fraction", question: nps, data: `7.5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNumericAnswer(tt.question, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("parseNumericAnswer(%s) error = %v, want ErrInvalidAnswer", tt.data, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNumericAnswer(%s): %v", tt.data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNumericAnswer(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemporalAnswer(t *testing.T) {
+	date := &models.Question{
+		QuestionType:        QuestionTypeDate,
+		QuestionConstraints: models.QuestionConstraints{Earliest: "2024-01-01", Latest: "2024-12-31"},
+	}
+	clock := &models.Question{QuestionType: QuestionTypeTime, Mandatory: true}
+	stamp := &models.Question{QuestionType: QuestionTypeDateTime}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		data     string
+		want     string // RFC 3339, empty for a skipped answer
+		wantErr  bool
+	}{
+		{name: "date in range", question: date, data: `"2024-05-31"`, want: "2024-05-31T00:00:00Z"},
+		{name: "date on earliest", question: date, data: `"2024-01-01"`, want: "2024-01-01T00:00:00Z"},
+		{name: "date too early", question: date, data: `"2023-12-31"`, wantErr: true},
+		{name: "date too late", question: date, data: `"2025-01-01"`, wantErr: true},
+		{name: "date in wrong format", question: date, data: `"31/05/2024"`, wantErr: true},
+		{name: "optional skipped", question: date, data: `""`},
+		{name: "time with minutes", question: clock, data: `"14:30"`, want: "0000-01-01T14:30:00Z"},
+		{name: "time with seconds", question: clock, data: `"14:30:15"`, want: "0000-01-01T14:30:15Z"},
+		{name: "mandatory skipped", question: clock, data: `null`, wantErr: true},
+		{name: "datetime", question: stamp, data: `"2024-05-31T14:30:00+02:00"`, want: "2024-05-31T14:30:00+02:00"},
+		{name: "not a string", question: stamp, data: `20240531`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTemporalAnswer(tt.question, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("parseTemporalAnswer(%s) error = %v, want ErrInvalidAnswer", tt.data, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTemporalAnswer(%s): %v", tt.data, err)
+			}
+			var formatted string
+			if got != nil {
+				formatted = got.Format(time.RFC3339)
+			}
+			if formatted != tt.want {
+				t.Errorf("parseTemporalAnswer(%s) = %q, want %q", tt.data, formatted, tt.want)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+// ErrInvalidLayout means a layout or ordering does not match what the survey or question
+// contains, e.g. it leaves out a question or names another survey's page
+var ErrInvalidLayout = errors.New("invalid layout")
+
+// SurveyLayout is the order of a survey's sections, pages and current questions.
+// Titles are informational; SetLayout ignores them.
+type SurveyLayout struct {
+	QuestionIDs []uint          `json:"question_ids"` // Questions on no page, shown before the sections
+	Sections    []LayoutSection `json:"sections"`
+}
+
+type LayoutSection struct {
+	SectionID uint         `json:"section_id"`
+	Title     string       `json:"title,omitempty"`
+	Pages     []LayoutPage `json:"pages"`
+}
+
+type LayoutPage struct {
+	PageID      uint   `json:"page_id"`
+	Title       string `json:"title,omitempty"`
+	QuestionIDs []uint `json:"question_ids"`
+}
+
+type SectionService interface {
+	CreateSection(ctx context.Context, section *models.Section) error
+	GetSection(ctx context.Context, id uint) (*models.Section, error)
+	UpdateSection(ctx context.Context, section *models.Section) error
+	DeleteSection(ctx context.Context, id uint) error
+	CreatePage(ctx context.Context, page *models.Page) error
+	UpdatePage(ctx context.Context, page *models.Page) error
+	DeletePage(ctx context.Context, id uint) error
+	GetLayout(ctx context.Context, surveyID uint) (*SurveyLayout, error)
+	SetLayout(ctx context.Context, surveyID uint, layout SurveyLayout) (*SurveyLayout, error)
+}
+
+type sectionService struct {
+	sectionRepo repository.SectionRepository
+	surveyRepo  repository.SurveyRepository
+}
+
+func NewSectionService(sectionRepo repository.SectionRepository, surveyRepo repository.SurveyRepository) SectionService {
+	return &sectionService{
+		sectionRepo: sectionRepo,
+		surveyRepo:  surveyRepo,
+	}
+}
+
+// CreateSection adds a section at the end of the survey
+func (s *sectionService) CreateSection(ctx context.Context, section *models.Section) error {
+	if section == nil || section.SurveyID == 0 {
+		return errors.New("survey ID is required")
+	}
+	if _, err := s.surveyRepo.GetByID(ctx, section.SurveyID); err != nil {
+		return err
+	}
+
+	section.Pages = nil
+	section.CreatedAt = time.Now()
+	section.UpdatedAt = time.Now()
+	return s.sectionRepo.CreateSection(ctx, section)
+}
+
+func (s *sectionService) GetSection(ctx context.Context, id uint) (*models.Section, error) {
+	if id == 0 {
+		return nil, errors.New("invalid section ID")
+	}
+	return s.sectionRepo.GetSection(ctx, id)
+}
+
+// UpdateSection changes a section's title, description and whether its questions are
+// shuffled
+func (s *sectionService) UpdateSection(ctx context.Context, section *models.Section) error {
+	if section == nil || section.SectionID == 0 {
+		return errors.New("invalid section provided")
+	}
+	existing, err := s.sectionRepo.GetSection(ctx, section.SectionID)
+	if err != nil {
+		return err
+	}
+
+	existing.Title = section.Title
+	existing.Description = section.Description
+	existing.RandomizeQuestions = section.RandomizeQuestions
+	existing.UpdatedAt = time.Now()
+	if err := s.sectionRepo.UpdateSection(ctx, existing); err != nil {
+		return err
+	}
+	*section = *existing
+	return nil
+}
+
+func (s *sectionService) DeleteSection(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("invalid section ID")
+	}
+	return s.sectionRepo.DeleteSection(ctx, id)
+}
+
+// CreatePage adds a page at the end of its section
+func (s *sectionService) CreatePage(ctx context.Context, page *models.Page) error {
+	if page == nil || page.SectionID == 0 {
+		return errors.New("section ID is required")
+	}
+	section, err := s.sectionRepo.GetSection(ctx, page.SectionID)
+	if err != nil {
+		return err
+	}
+
+	page.SurveyID = section.SurveyID
+	page.CreatedAt = time.Now()
+	page.UpdatedAt = time.Now()
+	return s.sectionRepo.CreatePage(ctx, page)
+}
+
+// UpdatePage changes a page's title
+func (s *sectionService) UpdatePage(ctx context.Context, page *models.Page) error {
+	if page == nil || page.PageID == 0 {
+		return errors.New("invalid page provided")
+	}
+	existing, err := s.sectionRepo.GetPage(ctx, page.PageID)
+	if err != nil {
+		return err
+	}
+
+	existing.Title = page.Title
+	existing.UpdatedAt = time.Now()
+	if err := s.sectionRepo.UpdatePage(ctx, existing); err != nil {
+		return err
+	}
+	*page = *existing
+	return nil
+}
+
+func (s *sectionService) DeletePage(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("invalid page ID")
+	}
+	return s.sectionRepo.DeletePage(ctx, id)
+}
+
+func (s *sectionService) GetLayout(ctx context.Context, surveyID uint) (*SurveyLayout, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := s.sectionRepo.GetBySurveyID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return buildLayout(sections, survey.Questions), nil
+}
+
+// SetLayout moves and reorders a survey's sections, pages and current questions in one
+// transaction. The layout must list every one of them exactly once.
+func (s *sectionService) SetLayout(ctx context.Context, surveyID uint, layout SurveyLayout) (*SurveyLayout, error) {
+	if surveyID == 0 {
+		return nil, errors.New("invalid survey ID")
+	}
+
+	var result *SurveyLayout
+	err := s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		survey, err := s.surveyRepo.GetByIDWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return err
+		}
+		current, err := s.sectionRepo.GetBySurveyIDWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return err
+		}
+
+		sections, questions, err := applyLayout(layout, current, survey.Questions)
+		if err != nil {
+			return err
+		}
+		if err := s.sectionRepo.SaveLayoutWithTx(ctx, tx, sections, questions); err != nil {
+			return err
+		}
+		result = buildLayout(sections, questions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// buildLayout groups questions, given in survey order, by the sections and pages they
+// are on. Questions on a page the sections do not contain are treated as on no page.
+func buildLayout(sections []models.Section, questions []models.Question) *SurveyLayout {
+	layout := &SurveyLayout{QuestionIDs: []uint{}, Sections: make([]LayoutSection, 0, len(sections))}
+	pages := make(map[uint]*LayoutPage)
+	for _, section := range sections {
+		ls := LayoutSection{SectionID: section.SectionID, Title: section.Title, Pages: make([]LayoutPage, 0, len(section.Pages))}
+		for _, page := range section.Pages {
+			ls.Pages = append(ls.Pages, LayoutPage{PageID: page.PageID, Title: page.Title, QuestionIDs: []uint{}})
+		}
+		layout.Sections = append(layout.Sections, ls)
+	}
+	for i := range layout.Sections {
+		for j := range layout.Sections[i].Pages {
+			page := &layout.Sections[i].Pages[j]
+			pages[page.PageID] = page
+		}
+	}
+
+	for _, q := range questions {
+		if q.PageID != nil {
+			if page, ok := pages[*q.PageID]; ok {
+				page.QuestionIDs = append(page.QuestionIDs, q.QuestionID)
+				continue
+			}
+		}
+		layout.QuestionIDs = append(layout.QuestionIDs, q.QuestionID)
+	}
+	return layout
+}
+
+// applyLayout checks a layout against the survey's sections and current questions and
+// returns them with their new places. Question positions run through the whole survey:
+// questions on no page first, then page by page.
+func applyLayout(layout SurveyLayout, sections []models.Section, questions []models.Question) ([]models.Section, []models.Question, error) {
+	knownSections := make(map[uint]models.Section, len(sections))
+	knownPages := make(map[uint]models.Page)
+	for _, section := range sections {
+		knownSections[section.SectionID] = section
+		for _, page := range section.Pages {
+			knownPages[page.PageID] = page
+		}
+	}
+	knownQuestions := make(map[uint]models.Question, len(questions))
+	for _, q := range questions {
+		knownQuestions[q.QuestionID] = q
+	}
+
+	seenSections := make(map[uint]bool, len(sections))
+	seenPages := make(map[uint]bool, len(knownPages))
+	seenQuestions := make(map[uint]bool, len(questions))
+	var placed []models.Question
+	place := func(id uint, pageID *uint) error {
+		q, ok := knownQuestions[id]
+		if !ok {
+			return fmt.Errorf("%w: question %d is not a current question of the survey", ErrInvalidLayout, id)
+		}
+		if seenQuestions[id] {
+			return fmt.Errorf("%w: question %d is listed more than once", ErrInvalidLayout, id)
+		}
+		seenQuestions[id] = true
+		q.PageID = pageID
+		q.Position = len(placed)
+		placed = append(placed, q)
+		return nil
+	}
+
+	for _, id := range layout.QuestionIDs {
+		if err := place(id, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	result := make([]models.Section, 0, len(layout.Sections))
+	for i, ls := range layout.Sections {
+		section, ok := knownSections[ls.SectionID]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: section %d is not in the survey", ErrInvalidLayout, ls.SectionID)
+		}
+		if seenSections[ls.SectionID] {
+			return nil, nil, fmt.Errorf("%w: section %d is listed more than once", ErrInvalidLayout, ls.SectionID)
+		}
+		seenSections[ls.SectionID] = true
+		section.Position = i
+		section.Pages = make([]models.Page, 0, len(ls.Pages))
+
+		for j, lp := range ls.Pages {
+			page, ok := knownPages[lp.PageID]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: page %d is not in the survey", ErrInvalidLayout, lp.PageID)
+			}
+			if seenPages[lp.PageID] {
+				return nil, nil, fmt.Errorf("%w: page %d is listed more than once", ErrInvalidLayout, lp.PageID)
+			}
+			seenPages[lp.PageID] = true
+			page.SectionID = section.SectionID
+			page.Position = j
+			section.Pages = append(section.Pages, page)
+
+			pageID := page.PageID
+			for _, id := range lp.QuestionIDs {
+				if err := place(id, &pageID); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		result = append(result, section)
+	}
+
+	for _, section := range sections {
+		if !seenSections[section.SectionID] {
+			return nil, nil, fmt.Errorf("%w: section %d is missing", ErrInvalidLayout, section.SectionID)
+		}
+	}
+	for id := range knownPages {
+		if !seenPages[id] {
+			return nil, nil, fmt.Errorf("%w: page %d is missing", ErrInvalidLayout, id)
+		}
+	}
+	for _, q := range questions {
+		if !seenQuestions[q.QuestionID] {
+			return nil, nil, fmt.Errorf("%w: question %d is missing", ErrInvalidLayout, q.QuestionID)
+		}
+	}
+	return result, placed, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+// loadSessionSnapshot loads a session and the survey version it is pinned to. The snapshot
+// is nil if the session started before its survey had a published version.
+func loadSessionSnapshot(ctx context.Context, sessionRepo repository.SurveySessionRepository, versionRepo repository.SurveyVersionRepository, sessionID uint) (*models.SurveySession, *models.SurveySnapshot, error) {
+	session, err := sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("%w: %d", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.SurveyVersionID == 0 {
+		return session, nil, nil
+	}
+
+	version, err := versionRepo.GetByID(ctx, session.SurveyVersionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var snapshot models.SurveySnapshot
+	if err := json.Unmarshal(version.Snapshot, &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("reading survey version %d: %w", version.VersionID, err)
+	}
+	return session, &snapshot, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// questionBlueprint describes a question to create in a survey. SourceID identifies it
+// in whatever it is copied from (a draft question_id or a published question ID), and
+// is what branching logic in Question.BranchingLogic refers to.
+type questionBlueprint struct {
+	SourceID uint
+	Question models.Question
+	Options  []models.Option // OptionKey, OptionText, MediaID and PinLast are copied, in this order
+	Media    []models.SurveyMediaFile
+}
+
+// copyQuestionsWithTx creates the blueprints' questions, options and media in a survey,
+// then materializes their branching logic with every question reference translated
+// from source IDs to the new IDs. keys maps question keys to source IDs. It returns the
+// created questions by source ID. Questions are placed in blueprint order, on the page
+// their Question.PageID names.
+func (s *surveyService) copyQuestionsWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, blueprints []questionBlueprint, keys map[string]uint) (map[uint]models.Question, error) {
+	created := make(map[uint]models.Question, len(blueprints))
+	now := time.Now()
+
+	optionMedia, err := s.copyOptionMediaWithTx(ctx, tx, surveyID, blueprints, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, bp := range blueprints {
+		question := bp.Question
+		question.QuestionID = 0
+		question.Position = i
+		question.SurveyID = surveyID
+		question.Options = nil
+		question.MatrixRows = nil
+		question.MatrixColumns = nil
+		question.CodeTests = nil
+		question.CreatedAt = now
+		question.UpdatedAt = now
+
+		if err := s.surveyRepo.CreateQuestionWithTx(ctx, tx, &question); err != nil {
+			return nil, err
+		}
+
+		// Matrix rows and columns and code tests are copied as new sub-items of the new
+		// question
+		for _, r := range bp.Question.MatrixRows {
+			row := models.MatrixRow{
+				QuestionID: question.QuestionID,
+				RowText:    r.RowText,
+				Position:   r.Position,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return nil, err
+			}
+			question.MatrixRows = append(question.MatrixRows, row)
+		}
+		for _, col := range bp.Question.MatrixColumns {
+			column := models.MatrixColumn{
+				QuestionID: question.QuestionID,
+				ColumnText: col.ColumnText,
+				Value:      col.Value,
+				Position:   col.Position,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			if err := tx.Create(&column).Error; err != nil {
+				return nil, err
+			}
+			question.MatrixColumns = append(question.MatrixColumns, column)
+		}
+		for _, t := range bp.Question.CodeTests {
+			test := models.CodeTest{
+				QuestionID:     question.QuestionID,
+				Name:           t.Name,
+				Input:          t.Input,
+				ExpectedOutput: t.ExpectedOutput,
+				Hidden:         t.Hidden,
+				Position:       t.Position,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+			if err := tx.Create(&test).Error; err != nil {
+				return nil, err
+			}
+			question.CodeTests = append(question.CodeTests, test)
+		}
+		created[bp.SourceID] = question
+
+		for j, opt := range bp.Options {
+			option := models.Option{
+				QuestionID: question.QuestionID,
+				OptionKey:  opt.OptionKey,
+				OptionText: opt.OptionText,
+				Position:   j,
+				PinLast:    opt.PinLast,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			if option.OptionKey == "" {
+				option.OptionKey = NewQuestionKey()
+			}
+			if opt.MediaID != nil {
+				mediaID := optionMedia[*opt.MediaID]
+				option.MediaID = &mediaID
+			}
+			if err := tx.Create(&option).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		for _, m := range bp.Media {
+			mediaFile := models.SurveyMediaFile{
+				SurveyID:   surveyID,
+				QuestionID: question.QuestionID,
+				FileURL:    m.FileURL,
+				FileType:   m.FileType,
+				CreatedAt:  now,
+			}
+			if err := s.surveyRepo.CreateMediaFileWithTx(ctx, tx, &mediaFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Group the questions by page, keeping blueprint order within each
+	if err := s.sectionRepo.RenumberQuestionsWithTx(ctx, tx, surveyID); err != nil {
+		return nil, err
+	}
+
+	// Persist branching rules, translating source question IDs to the new ones
+	idMap := make(map[uint]uint, len(created))
+	for sourceID, question := range created {
+		idMap[sourceID] = question.QuestionID
+	}
+	for _, bp := range blueprints {
+		question := created[bp.SourceID]
+		rules, logic, warnings, err := MaterializeRules(surveyID, question.QuestionID, question.BranchingLogic, idMap, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range warnings {
+			log.Printf("Warning: question %d branching %s", bp.SourceID, warning)
+		}
+
+		for i := range rules {
+			if err := s.ruleRepo.CreateWithTx(ctx, tx, &rules[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if logic != question.BranchingLogic {
+			if err := tx.Model(&models.Question{}).Where("question_id = ?", question.QuestionID).Update("branching_logic", logic).Error; err != nil {
+				return nil, err
+			}
+			question.BranchingLogic = logic
+			created[bp.SourceID] = question
+		}
+	}
+
+	return created, nil
+}
+
+// copyOptionMediaWithTx checks the media the blueprints' options refer to and gives the
+// survey its own record of each, so deleting the survey they came from leaves the copy's
+// options intact. Media already belonging to the survey are reused. It returns the media
+// ID to use for each referenced ID.
+func (s *surveyService) copyOptionMediaWithTx(ctx context.Context, tx *gorm.DB, surveyID uint, blueprints []questionBlueprint, now time.Time) (map[uint]uint, error) {
+	var ids []uint
+	for _, bp := range blueprints {
+		ids = append(ids, optionMediaIDs(bp.Options)...)
+	}
+	media, err := s.surveyRepo.GetMediaFilesByIDsWithTx(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, bp := range blueprints {
+		if err := validateOptionMedia(bp.Question.QuestionType, bp.Options, media); err != nil {
+			return nil, err
+		}
+	}
+
+	mediaIDs := make(map[uint]uint, len(media))
+	for _, m := range media {
+		if m.SurveyID == surveyID {
+			mediaIDs[m.MediaID] = m.MediaID
+			continue
+		}
+		mediaFile := models.SurveyMediaFile{
+			SurveyID:  surveyID,
+			FileURL:   m.FileURL,
+			FileType:  m.FileType,
+			CreatedAt: now,
+		}
+		if err := s.surveyRepo.CreateMediaFileWithTx(ctx, tx, &mediaFile); err != nil {
+			return nil, err
+		}
+		mediaIDs[m.MediaID] = mediaFile.MediaID
+	}
+	return mediaIDs, nil
+}
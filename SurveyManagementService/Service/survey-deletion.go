@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// DefaultRestoreWindow is how long a soft-deleted survey can be restored when the
+// service is not configured otherwise
+const DefaultRestoreWindow = 30 * 24 * time.Hour
+
+var (
+	ErrRestoreWindowExpired = errors.New("survey restore window has expired")
+	ErrSurveyHasResponses   = errors.New("survey has completed sessions")
+)
+
+// DeleteSurvey soft-deletes a survey. It disappears from every lookup and listing but
+// keeps all its data until it is restored or hard-deleted. Only its own conductor may
+// delete it.
+func (s *surveyService) DeleteSurvey(ctx context.Context, surveyID, conductorID uint) error {
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return err
+	}
+	if survey.ConductorID != conductorID {
+		return ErrSurveyNotAccessible
+	}
+	return s.surveyRepo.Delete(ctx, surveyID)
+}
+
+// RestoreSurvey undoes a soft delete made within the restore window by the survey's own
+// conductor
+func (s *surveyService) RestoreSurvey(ctx context.Context, surveyID, conductorID uint) (*models.Survey, error) {
+	survey, err := s.surveyRepo.GetDeleted(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey.ConductorID != conductorID {
+		return nil, ErrSurveyNotAccessible
+	}
+	if time.Since(survey.DeletedAt.Time) > s.restoreWindow {
+		return nil, fmt.Errorf("%w: deleted at %s", ErrRestoreWindowExpired, survey.DeletedAt.Time.Format(time.RFC3339))
+	}
+
+	if err := s.surveyRepo.Restore(ctx, surveyID); err != nil {
+		return nil, err
+	}
+	return s.surveyRepo.GetByID(ctx, surveyID)
+}
+
+// ListDeletedSurveys returns a conductor's soft-deleted surveys, most recently deleted first
+func (s *surveyService) ListDeletedSurveys(ctx context.Context, conductorID uint) ([]models.Survey, error) {
+	return s.surveyRepo.ListDeleted(ctx, conductorID)
+}
+
+// HardDeleteSurvey permanently removes a survey, soft-deleted or not, with all its
+// questions, drafts, versions, media, sessions and answers in one transaction. Surveys
+// with completed sessions are refused unless force is set. Only its own conductor may
+// delete it.
+func (s *surveyService) HardDeleteSurvey(ctx context.Context, surveyID, conductorID uint, force bool) error {
+	return s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		survey, err := s.surveyRepo.GetIncludingDeletedWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return err
+		}
+		if survey.ConductorID != conductorID {
+			return ErrSurveyNotAccessible
+		}
+
+		if !force {
+			completed, err := s.surveyRepo.CountCompletedSessionsWithTx(ctx, tx, surveyID)
+			if err != nil {
+				return err
+			}
+			if completed > 0 {
+				return fmt.Errorf("%w: %d completed, pass force to delete them", ErrSurveyHasResponses, completed)
+			}
+		}
+
+		return s.surveyRepo.HardDeleteWithTx(ctx, tx, surveyID)
+	})
+}
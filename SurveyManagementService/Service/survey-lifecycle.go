@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid survey status transition")
+	ErrInvalidSchedule         = errors.New("invalid survey schedule")
+)
+
+// statusTransitions lists the states each state may move to by hand. Leaving DRAFT
+// happens only by publishing, which picks SCHEDULED or OPEN from the schedule.
+var statusTransitions = map[string][]string{
+	models.StatusDraft:     {},
+	models.StatusScheduled: {models.StatusOpen, models.StatusClosed},
+	models.StatusOpen:      {models.StatusPaused, models.StatusClosed},
+	models.StatusPaused:    {models.StatusOpen, models.StatusClosed},
+	models.StatusClosed:    {models.StatusArchived},
+	models.StatusArchived:  {},
+}
+
+// normalizeStatus maps legacy and empty statuses onto the lifecycle
+func normalizeStatus(status string) string {
+	switch status {
+	case "":
+		return models.StatusDraft
+	case models.StatusPublished:
+		return models.StatusOpen
+	}
+	return status
+}
+
+// CanTransition reports whether a survey in status from may be moved to status to by hand
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[normalizeStatus(from)] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTransition(from, to string) error {
+	if _, known := statusTransitions[to]; !known {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidStatusTransition, to)
+	}
+	if normalizeStatus(from) == models.StatusDraft {
+		return fmt.Errorf("%w: publish the survey to move it out of %s", ErrInvalidStatusTransition, models.StatusDraft)
+	}
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, normalizeStatus(from), to)
+	}
+	return nil
+}
+
+// validateSchedule checks that a survey closes after it opens
+func validateSchedule(opensAt, closesAt *time.Time) error {
+	if opensAt != nil && closesAt != nil && !closesAt.After(*opensAt) {
+		return fmt.Errorf("%w: closes_at must be after opens_at", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// publishStatus is the status a survey has after being published at now. A first
+// publish opens the survey, or schedules it when opens_at is still ahead; republishing
+// keeps the current status, since it only adds a version.
+func publishStatus(survey *models.Survey, now time.Time) (string, error) {
+	if err := validateSchedule(survey.OpensAt, survey.ClosesAt); err != nil {
+		return "", err
+	}
+
+	switch status := normalizeStatus(survey.Status); status {
+	case models.StatusDraft:
+		if survey.ClosesAt != nil && !survey.ClosesAt.After(now) {
+			return "", fmt.Errorf("%w: closes_at is in the past", ErrInvalidSchedule)
+		}
+		if survey.OpensAt != nil && survey.OpensAt.After(now) {
+			return models.StatusScheduled, nil
+		}
+		return models.StatusOpen, nil
+	case models.StatusArchived:
+		return "", fmt.Errorf("%w: archived surveys cannot be republished", ErrInvalidStatusTransition)
+	default:
+		return status, nil
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+var ErrInvalidSurveyQuery = errors.New("invalid survey query")
+
+const (
+	defaultSurveyPageSize = 20
+	maxSurveyPageSize     = 100
+)
+
+// SurveyListQuery describes one page of a conductor's survey listing
+type SurveyListQuery struct {
+	ConductorID   uint
+	Statuses      []string
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Sort          string // created_at (default), updated_at or title
+	Order         string // desc (default) or asc
+	Limit         int
+	Cursor        string // next_cursor of the previous page
+}
+
+// SurveyPage is one page of surveys. NextCursor is empty on the last page.
+type SurveyPage struct {
+	Surveys    []models.Survey `json:"surveys"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
+}
+
+// surveyCursor is the opaque next_cursor token. It records the sort it was issued for,
+// so a cursor cannot be replayed against a different ordering.
+type surveyCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func encodeSurveyCursor(c surveyCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSurveyCursor(token string) (*surveyCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSurveyQuery)
+	}
+	var c surveyCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSurveyQuery)
+	}
+	return &c, nil
+}
+
+// sortValue returns the survey's value for the sort column, as stored in a cursor
+func sortValue(survey models.Survey, sort string) string {
+	switch sort {
+	case repository.SurveySortUpdatedAt:
+		return survey.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case repository.SurveySortTitle:
+		return survey.Title
+	default:
+		return survey.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// surveyFilter validates a listing query and turns it into a repository filter
+func surveyFilter(query SurveyListQuery) (repository.SurveyFilter, error) {
+	filter := repository.SurveyFilter{
+		ConductorID:   query.ConductorID,
+		Search:        strings.TrimSpace(query.Search),
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		UpdatedAfter:  query.UpdatedAfter,
+		UpdatedBefore: query.UpdatedBefore,
+		Limit:         query.Limit,
+	}
+	if query.ConductorID == 0 {
+		return filter, fmt.Errorf("%w: conductor_id is required", ErrInvalidSurveyQuery)
+	}
+
+	for _, status := range query.Statuses {
+		status = strings.ToUpper(strings.TrimSpace(status))
+		if status == "" {
+			continue
+		}
+		if _, known := statusTransitions[status]; !known && status != models.StatusPublished {
+			return filter, fmt.Errorf("%w: unknown status %q", ErrInvalidSurveyQuery, status)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	switch query.Sort {
+	case "":
+		filter.Sort = repository.SurveySortCreatedAt
+	case repository.SurveySortCreatedAt, repository.SurveySortUpdatedAt, repository.SurveySortTitle:
+		filter.Sort = query.Sort
+	default:
+		return filter, fmt.Errorf("%w: sort must be created_at, updated_at or title", ErrInvalidSurveyQuery)
+	}
+
+	order := strings.ToLower(query.Order)
+	switch order {
+	case "", "desc":
+		order = "desc"
+		filter.Descending = true
+	case "asc":
+	default:
+		return filter, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSurveyQuery)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSurveyPageSize
+	}
+	if filter.Limit > maxSurveyPageSize {
+		filter.Limit = maxSurveyPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeSurveyCursor(query.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != order {
+			return filter, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidSurveyQuery)
+		}
+		after := &repository.SurveyCursor{Value: cursor.Value, ID: cursor.ID}
+		if filter.Sort != repository.SurveySortTitle {
+			at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return filter, fmt.Errorf("%w: malformed cursor", ErrInvalidSurveyQuery)
+			}
+			after.Value = at
+		}
+		filter.After = after
+	}
+	return filter, nil
+}
+
+// ListSurveys returns one page of a conductor's surveys
+func (s *surveyService) ListSurveys(ctx context.Context, query SurveyListQuery) (*SurveyPage, error) {
+	filter, err := surveyFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	pageSize := filter.Limit
+	filter.Limit++
+	surveys, err := s.surveyRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SurveyPage{Surveys: surveys}
+	if len(surveys) > pageSize {
+		page.Surveys = surveys[:pageSize]
+		page.HasMore = true
+
+		last := page.Surveys[pageSize-1]
+		order := "asc"
+		if filter.Descending {
+			order = "desc"
+		}
+		page.NextCursor = encodeSurveyCursor(surveyCursor{
+			Sort:  filter.Sort,
+			Order: order,
+			Value: sortValue(last, filter.Sort),
+			ID:    last.SurveyID,
+		})
+	}
+	if page.Surveys == nil {
+		page.Surveys = []models.Survey{}
+	}
+	return page, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+// SurveyScheduler opens scheduled surveys and closes expired ones on time. Every tick
+// is a pair of conditional bulk updates, so running several replicas is safe.
+type SurveyScheduler struct {
+	surveyRepo repository.SurveyRepository
+	interval   time.Duration
+}
+
+func NewSurveyScheduler(surveyRepo repository.SurveyRepository, interval time.Duration) *SurveyScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &SurveyScheduler{
+		surveyRepo: surveyRepo,
+		interval:   interval,
+	}
+}
+
+// Run ticks until ctx is cancelled. Surveys published before the lifecycle existed
+// are moved from PUBLISHED to OPEN first.
+func (s *SurveyScheduler) Run(ctx context.Context) {
+	if renamed, err := s.surveyRepo.RenameStatus(ctx, models.StatusPublished, models.StatusOpen); err != nil {
+		log.Printf("Scheduler: failed to migrate %s surveys: %v", models.StatusPublished, err)
+	} else if renamed > 0 {
+		log.Printf("Scheduler: moved %d %s surveys to %s", renamed, models.StatusPublished, models.StatusOpen)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Tick(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Tick(ctx, now)
+		}
+	}
+}
+
+// Tick applies every transition due at now
+func (s *SurveyScheduler) Tick(ctx context.Context, now time.Time) {
+	opened, err := s.surveyRepo.OpenDue(ctx, now)
+	if err != nil {
+		log.Printf("Scheduler: failed to open scheduled surveys: %v", err)
+	} else if opened > 0 {
+		log.Printf("Scheduler: opened %d surveys", opened)
+	}
+
+	closed, err := s.surveyRepo.CloseDue(ctx, now)
+	if err != nil {
+		log.Printf("Scheduler: failed to close expired surveys: %v", err)
+	} else if closed > 0 {
+		log.Printf("Scheduler: closed %d surveys", closed)
+	}
+}
@@ -2,10 +2,10 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -17,15 +17,35 @@ import (
 
 type SurveyService interface {
 	CreateSurvey(ctx context.Context, survey *models.Survey) error
+	ListSurveys(ctx context.Context, query SurveyListQuery) (*SurveyPage, error)
 	SaveSection(ctx context.Context, surveyID uint, questions []models.Question, mediaFiles []models.SurveyMediaFile, branchingRules []models.BranchingRule) error
-	CreateDraft(ctx context.Context, surveyID uint, content models.JSONContent, lastEditedQuestion uint) (*models.SurveyDraft, error)
-	UpdateDraft(ctx context.Context, draftID uint, content models.JSONContent, lastEditedQuestion uint) (*models.SurveyDraft, error)
+	CreateDraft(ctx context.Context, surveyID uint, content models.JSONContent, lastEditedQuestion uint, authorID uint) (*models.SurveyDraft, error)
+	UpdateDraft(ctx context.Context, draftID uint, content models.JSONContent, lastEditedQuestion uint, authorID uint, expectedRevision int) (*models.SurveyDraft, error)
+	PatchDraft(ctx context.Context, draftID uint, format string, patch []byte, lastEditedQuestion *uint, authorID uint, expectedRevision int) (*models.SurveyDraft, error)
 	PublishSurvey(ctx context.Context, surveyID uint) error
 	GetProgress(ctx context.Context, surveyID uint) (*SurveyProgress, error)
 	GetSurvey(ctx context.Context, surveyID uint) (*models.Survey, error)
 	GetDraft(ctx context.Context, draftID uint) (*models.SurveyDraft, error)
-	PublishDraftToSurvey(ctx context.Context, draftID uint) (uint, error)
+	PublishDraftToSurvey(ctx context.Context, draftID uint, expectedRevision int) (uint, error)
+	ValidateDraft(ctx context.Context, draftID uint) (*BranchingReport, error)
+	ExportFlow(ctx context.Context, surveyID uint, format string) (string, error)
 	GetLatestDraft(ctx context.Context, surveyID uint) (*models.SurveyDraft, error)
+	ListVersions(ctx context.Context, surveyID uint) ([]models.SurveyVersion, error)
+	GetVersion(ctx context.Context, surveyID uint, versionNumber int) (*models.SurveyVersion, error)
+	ChangeStatus(ctx context.Context, surveyID, conductorID uint, status string) (*models.Survey, error)
+	SetSchedule(ctx context.Context, surveyID, conductorID uint, opensAt, closesAt *time.Time) (*models.Survey, error)
+	CloneSurvey(ctx context.Context, surveyID uint, opts CloneOptions) (*models.Survey, error)
+	SetTemplate(ctx context.Context, surveyID uint, scope string) (*models.Survey, error)
+	ListTemplates(ctx context.Context, conductorID uint) ([]models.Survey, error)
+	UseTemplate(ctx context.Context, templateID uint, opts CloneOptions) (*models.Survey, error)
+	DeleteSurvey(ctx context.Context, surveyID, conductorID uint) error
+	RestoreSurvey(ctx context.Context, surveyID, conductorID uint) (*models.Survey, error)
+	ListDeletedSurveys(ctx context.Context, conductorID uint) ([]models.Survey, error)
+	HardDeleteSurvey(ctx context.Context, surveyID, conductorID uint, force bool) error
+	ListDraftRevisions(ctx context.Context, draftID uint) ([]models.SurveyDraftRevision, error)
+	GetDraftRevision(ctx context.Context, draftID uint, number int) (*models.SurveyDraftRevision, error)
+	DiffDraftRevisions(ctx context.Context, draftID uint, from, to int) (*DraftDiff, error)
+	RestoreDraftRevision(ctx context.Context, draftID uint, number int, authorID uint, expectedRevision int) (*models.SurveyDraft, error)
 }
 
 type SurveyProgress struct {
@@ -37,36 +57,202 @@ type SurveyProgress struct {
 	Status            string    `json:"status"`
 }
 
+// draftQuestionKeys checks the keys set on draft questions and maps each to its draft
+// question ID. Keys must be well-formed and unique within the draft.
+func draftQuestionKeys(doc *draftDocument) (map[string]uint, error) {
+	keys := make(map[string]uint, len(doc.Questions))
+	for _, q := range doc.Questions {
+		key, err := NormalizeQuestionKey(q.QuestionKey)
+		if err != nil {
+			return nil, fmt.Errorf("question %d: %w", q.QuestionID, err)
+		}
+		if key == "" {
+			continue
+		}
+		if other, exists := keys[key]; exists {
+			return nil, fmt.Errorf("%w: questions %d and %d share key %q", ErrInvalidQuestionKey, other, q.QuestionID, key)
+		}
+		keys[key] = q.QuestionID
+	}
+	return keys, nil
+}
+
+// inheritOptionKeys gives options without a key the key of the previous option with the
+// same text, so translations follow options a draft reorders or leaves unchanged
+func inheritOptionKeys(options, previous []models.Option) {
+	used := make(map[string]bool, len(options))
+	for _, option := range options {
+		used[option.OptionKey] = true
+	}
+	for i := range options {
+		if options[i].OptionKey != "" {
+			continue
+		}
+		for _, p := range previous {
+			if p.OptionKey != "" && !used[p.OptionKey] && p.OptionText == options[i].OptionText {
+				options[i].OptionKey = p.OptionKey
+				used[p.OptionKey] = true
+				break
+			}
+		}
+	}
+}
+
+// validateDraftBranching runs the branching graph analysis on draft question IDs,
+// before publishing assigns the real ones
+func validateDraftBranching(doc *draftDocument) (*BranchingReport, error) {
+	keys, err := draftQuestionKeys(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[uint][]models.Option)
+	for _, opt := range doc.Options {
+		options[opt.QuestionID] = append(options[opt.QuestionID], models.Option{OptionText: opt.OptionText})
+	}
+
+	questions := make([]models.Question, 0, len(doc.Questions))
+	var rules []models.BranchingRule
+	for i, q := range doc.Questions {
+		key, _ := NormalizeQuestionKey(q.QuestionKey)
+		questions = append(questions, models.Question{
+			QuestionID:   q.QuestionID,
+			QuestionKey:  key,
+			QuestionText: q.QuestionText,
+			Options:      options[q.QuestionID],
+			Mandatory:    q.Mandatory,
+			Position:     i,        // Publishing keeps the draft's order
+			PageID:       q.PageID, // For pipes; unset keeps the source question's page on publish
+		})
+
+		parsed, err := ParseBranchingLogic(q.BranchingLogic)
+		if err != nil {
+			return nil, fmt.Errorf("question %d: %w", q.QuestionID, err)
+		}
+		for i, rule := range parsed {
+			condition, err := rule.ConditionString()
+			if err != nil {
+				return nil, fmt.Errorf("question %d: %w", q.QuestionID, err)
+			}
+			target, err := rule.Destination(keys)
+			if err != nil {
+				return nil, fmt.Errorf("question %d: %w", q.QuestionID, err)
+			}
+			rules = append(rules, models.BranchingRule{
+				RuleID:           uint(i + 1),
+				SourceQuestionID: q.QuestionID,
+				TargetQuestionID: target,
+				Condition:        condition,
+				Priority:         rule.Priority,
+			})
+		}
+	}
+	return ValidateBranching(questions, rules), nil
+}
+
 type surveyService struct {
 	surveyRepo      repository.SurveyRepository
 	surveyDraftRepo repository.SurveyDraftRepository
+	ruleRepo        repository.BranchingRuleRepository
+	versionRepo     repository.SurveyVersionRepository
+	revisionRepo    repository.DraftRevisionRepository
+	sectionRepo     repository.SectionRepository
+	translationRepo repository.TranslationRepository
+	restoreWindow   time.Duration
 }
 
-func NewSurveyService(surveyRepo repository.SurveyRepository, surveyDraftRepo repository.SurveyDraftRepository) SurveyService {
+// NewSurveyService creates the survey service. Soft-deleted surveys can be restored for
+// restoreWindow; zero means DefaultRestoreWindow.
+func NewSurveyService(surveyRepo repository.SurveyRepository, surveyDraftRepo repository.SurveyDraftRepository, ruleRepo repository.BranchingRuleRepository, versionRepo repository.SurveyVersionRepository, revisionRepo repository.DraftRevisionRepository, sectionRepo repository.SectionRepository, translationRepo repository.TranslationRepository, restoreWindow time.Duration) SurveyService {
+	if restoreWindow <= 0 {
+		restoreWindow = DefaultRestoreWindow
+	}
 	return &surveyService{
 		surveyRepo:      surveyRepo,
 		surveyDraftRepo: surveyDraftRepo,
+		ruleRepo:        ruleRepo,
+		versionRepo:     versionRepo,
+		revisionRepo:    revisionRepo,
+		sectionRepo:     sectionRepo,
+		translationRepo: translationRepo,
+		restoreWindow:   restoreWindow,
+	}
+}
+
+// createVersionWithTx snapshots the survey's current questions, options, media and
+// branching rules into a new immutable version and makes it the current one
+func (s *surveyService) createVersionWithTx(ctx context.Context, tx *gorm.DB, surveyID uint) (*models.SurveyVersion, error) {
+	if err := s.surveyRepo.AssignMissingQuestionKeysWithTx(ctx, tx, surveyID, NewQuestionKey); err != nil {
+		return nil, err
+	}
+	if err := s.versionRepo.AssignLineageWithTx(ctx, tx, surveyID); err != nil {
+		return nil, err
+	}
+
+	number, err := s.versionRepo.NextVersionNumberWithTx(ctx, tx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.versionRepo.LoadSnapshotWithTx(ctx, tx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	version := &models.SurveyVersion{
+		SurveyID:      surveyID,
+		VersionNumber: number,
+		Snapshot:      models.JSONContent(content),
+		PublishedAt:   now,
+		CreatedAt:     now,
+	}
+	if err := s.versionRepo.CreateWithTx(ctx, tx, version); err != nil {
+		return nil, err
+	}
+
+	if err := s.versionRepo.StampQuestionsWithTx(ctx, tx, surveyID, version.VersionID); err != nil {
+		return nil, err
+	}
+	if err := s.versionRepo.SetCurrentVersionWithTx(ctx, tx, surveyID, version.VersionID); err != nil {
+		return nil, err
 	}
+	return version, nil
 }
 
 func (s *surveyService) CreateSurvey(ctx context.Context, survey *models.Survey) error {
-	survey.Status = "DRAFT"
+	survey.Status = models.StatusDraft
 	survey.CreatedAt = time.Now()
 	survey.UpdatedAt = time.Now()
 
 	return s.surveyRepo.Create(ctx, survey)
 }
 
+// SaveSection appends a batch of questions to the end of a survey. It predates Section and
+// does not create one; questions join sections through their page, see SectionService.
 func (s *surveyService) SaveSection(ctx context.Context, surveyID uint, questions []models.Question, mediaFiles []models.SurveyMediaFile, branchingRules []models.BranchingRule) error {
 	return s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
 		survey, err := s.surveyRepo.GetByID(ctx, surveyID)
 		if err != nil {
 			return err
 		}
+		position, err := s.sectionRepo.NextQuestionPositionWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return err
+		}
 
 		// Save questions
 		for i := range questions {
 			questions[i].SurveyID = surveyID
+			questions[i].PageID = nil
+			questions[i].Position = position + i
+			for j := range questions[i].Options {
+				questions[i].Options[j].Position = j
+			}
 			questions[i].CreatedAt = time.Now()
 			questions[i].UpdatedAt = time.Now()
 		}
@@ -91,15 +277,6 @@ func (s *surveyService) SaveSection(ctx context.Context, surveyID uint, question
 	})
 }
 
-// Helper function to pretty print JSON content for logging
-func prettyPrintJSON(content models.JSONContent) string {
-	var prettyContent bytes.Buffer
-	if err := json.Indent(&prettyContent, []byte(content), "", "  "); err == nil {
-		return prettyContent.String()
-	}
-	return string(content)
-}
-
 // Helper function to create a new draft object
 func newDraft(surveyID uint, content models.JSONContent, lastEditedQuestion uint) *models.SurveyDraft {
 	now := time.Now()
@@ -107,32 +284,100 @@ func newDraft(surveyID uint, content models.JSONContent, lastEditedQuestion uint
 		SurveyID:           surveyID,
 		DraftContent:       content,
 		LastEditedQuestion: lastEditedQuestion,
+		Revision:           1,
 		LastSaved:          now,
 		CreatedAt:          now,
 		UpdatedAt:          now,
 	}
 }
 
-func (s *surveyService) CreateDraft(ctx context.Context, surveyID uint, content models.JSONContent, lastEditedQuestion uint) (*models.SurveyDraft, error) {
-	log.Printf("Service creating draft with content: %s", prettyPrintJSON(content))
+// CreateDraft saves a new draft and its first revision
+func (s *surveyService) CreateDraft(ctx context.Context, surveyID uint, content models.JSONContent, lastEditedQuestion uint, authorID uint) (*models.SurveyDraft, error) {
+	log.Printf("Service creating draft for survey %d (%d bytes)", surveyID, len(content))
+	if _, err := validateDraftContent(content, false); err != nil {
+		return nil, err
+	}
 	draft := newDraft(surveyID, content, lastEditedQuestion)
-	return s.surveyDraftRepo.CreateDraft(ctx, draft)
+	err := s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.surveyDraftRepo.CreateDraftWithTx(ctx, tx, draft); err != nil {
+			return err
+		}
+		_, err := s.recordRevisionWithTx(ctx, tx, draft, authorID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
 }
 
-func (s *surveyService) UpdateDraft(ctx context.Context, draftID uint, content models.JSONContent, lastEditedQuestion uint) (*models.SurveyDraft, error) {
-	log.Printf("Service updating draft %d with content: %s", draftID, prettyPrintJSON(content))
-
-	draft, err := s.surveyDraftRepo.GetByID(ctx, draftID)
-	if err != nil {
+// UpdateDraft replaces the draft's content, keeping the previous content as a revision.
+// It fails with a *DraftConflictError unless the draft is still at expectedRevision
+// (AnyRevision skips the check).
+func (s *surveyService) UpdateDraft(ctx context.Context, draftID uint, content models.JSONContent, lastEditedQuestion uint, authorID uint, expectedRevision int) (*models.SurveyDraft, error) {
+	log.Printf("Service updating draft %d (%d bytes)", draftID, len(content))
+	if _, err := validateDraftContent(content, false); err != nil {
 		return nil, err
 	}
+	return s.modifyDraft(ctx, draftID, authorID, expectedRevision, func(draft *models.SurveyDraft) error {
+		draft.DraftContent = content
+		draft.LastEditedQuestion = lastEditedQuestion
+		return nil
+	})
+}
 
-	draft.DraftContent = content
-	draft.LastEditedQuestion = lastEditedQuestion
-	draft.LastSaved = time.Now()
-	draft.UpdatedAt = time.Now()
+// PatchDraft applies a JSON Patch or JSON Merge Patch (see PatchFormatJSONPatch and
+// PatchFormatMergePatch) to the draft's content on the server. lastEditedQuestion is
+// kept when nil. Revisions are checked and recorded as for UpdateDraft.
+func (s *surveyService) PatchDraft(ctx context.Context, draftID uint, format string, patch []byte, lastEditedQuestion *uint, authorID uint, expectedRevision int) (*models.SurveyDraft, error) {
+	log.Printf("Service patching draft %d (%s, %d bytes)", draftID, format, len(patch))
+	return s.modifyDraft(ctx, draftID, authorID, expectedRevision, func(draft *models.SurveyDraft) error {
+		content, err := applyPatch(format, draft.DraftContent, patch)
+		if err != nil {
+			return err
+		}
+		if _, err := validateDraftContent(content, false); err != nil {
+			return err
+		}
+		draft.DraftContent = content
+		if lastEditedQuestion != nil {
+			draft.LastEditedQuestion = *lastEditedQuestion
+		}
+		return nil
+	})
+}
+
+// modifyDraft locks the draft, checks it is still at expectedRevision, applies change
+// and saves the result as the next revision
+func (s *surveyService) modifyDraft(ctx context.Context, draftID uint, authorID uint, expectedRevision int, change func(draft *models.SurveyDraft) error) (*models.SurveyDraft, error) {
+	var draft *models.SurveyDraft
+	err := s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		draft, err = s.surveyDraftRepo.GetByIDForUpdateWithTx(ctx, tx, draftID)
+		if err != nil {
+			return err
+		}
+		if err := checkDraftRevision(draft, expectedRevision); err != nil {
+			return err
+		}
+		if err := change(draft); err != nil {
+			return err
+		}
 
-	return s.surveyDraftRepo.Update(ctx, draft)
+		draft.Revision++
+		draft.LastSaved = time.Now()
+		draft.UpdatedAt = time.Now()
+
+		if err := s.surveyDraftRepo.UpdateWithTx(ctx, tx, draft); err != nil {
+			return err
+		}
+		_, err = s.recordRevisionWithTx(ctx, tx, draft, authorID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
 }
 
 func (s *surveyService) PublishSurvey(ctx context.Context, surveyID uint) error {
@@ -145,9 +390,29 @@ func (s *surveyService) PublishSurvey(ctx context.Context, surveyID uint) error
 		return errors.New("cannot publish survey without questions")
 	}
 
-	survey.Status = "PUBLISHED"
-	survey.UpdatedAt = time.Now()
-	return s.surveyRepo.Update(ctx, survey)
+	rules, err := s.ruleRepo.GetBySurveyID(ctx, surveyID)
+	if err != nil {
+		return err
+	}
+	if report := ValidateBranching(survey.Questions, rules); !report.Valid {
+		return &BranchingValidationError{Report: report}
+	}
+
+	status, err := publishStatus(survey, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		survey.Status = status
+		survey.UpdatedAt = time.Now()
+		if err := s.surveyRepo.UpdateWithTx(ctx, tx, survey); err != nil {
+			return err
+		}
+
+		_, err := s.createVersionWithTx(ctx, tx, surveyID)
+		return err
+	})
 }
 
 func (s *surveyService) GetProgress(ctx context.Context, surveyID uint) (*SurveyProgress, error) {
@@ -177,53 +442,52 @@ func (s *surveyService) GetDraft(ctx context.Context, draftID uint) (*models.Sur
 	return s.surveyDraftRepo.GetByID(ctx, draftID)
 }
 
-func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint) (uint, error) {
+// PublishDraftToSurvey publishes the draft's content, provided the draft is still at
+// expectedRevision (AnyRevision skips the check)
+func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint, expectedRevision int) (uint, error) {
 	// Get the draft by ID
 	draft, err := s.surveyDraftRepo.GetByID(ctx, draftID)
 	if err != nil {
 		return 0, err
 	}
 
-	// Parse the draft content
-	var draftContent struct {
-		BasicInfo struct {
-			Title             string `json:"title"`
-			Description       string `json:"description"`
-			IsSelfRecruitment bool   `json:"is_self_recruitment"`
-			ConductorID       uint   `json:"conductor_id"`
-			Status            string `json:"status"`
-		} `json:"basicInfo"`
-		Questions []struct {
-			QuestionID     uint   `json:"question_id"`
-			QuestionText   string `json:"question_text"`
-			QuestionType   string `json:"question_type"`
-			Mandatory      bool   `json:"mandatory"`
-			BranchingLogic string `json:"branching_logic"`
-			CorrectAnswers string `json:"correct_answers"`
-		} `json:"questions"`
-		Options []struct {
-			OptionText string `json:"option_text"`
-			QuestionID uint   `json:"question_id"`
-		} `json:"options"`
-		MediaFiles []struct {
-			QuestionID uint   `json:"question_id"`
-			FileURL    string `json:"file_url"`
-			FileType   string `json:"file_type"`
-		} `json:"mediaFiles"`
-	}
-
-	// Unmarshal the JSON content
-	if err := json.Unmarshal([]byte(draft.DraftContent), &draftContent); err != nil {
-		log.Printf("Error unmarshaling draft content: %v", err)
-		log.Printf("Draft content: %s", draft.DraftContent)
+	draftContent, err := validateDraftContent(draft.DraftContent, true)
+	if err != nil {
+		return 0, err
+	}
+
+	// Refuse to publish a survey whose branching graph is broken
+	report, err := validateDraftBranching(draftContent)
+	if err != nil {
+		return 0, err
+	}
+	if !report.Valid {
+		return 0, &BranchingValidationError{Report: report}
+	}
+	draftKeys, err := draftQuestionKeys(draftContent)
+	if err != nil {
 		return 0, err
 	}
 
 	// Begin a transaction
 	return s.surveyRepo.TransactionWithResult(ctx, func(tx *gorm.DB) (uint, error) {
+		// Hold the draft until publishing ends; what was validated above must still be current
+		locked, err := s.surveyDraftRepo.GetByIDForUpdateWithTx(ctx, tx, draftID)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkDraftRevision(locked, expectedRevision); err != nil {
+			return 0, err
+		}
+		if locked.Revision != draft.Revision {
+			return 0, &DraftConflictError{DraftID: draftID, Expected: draft.Revision, Current: locked.Revision}
+		}
+
 		// Check if survey exists or create a new one
 		var survey models.Survey
 		var surveyID uint
+		current := make(map[uint]models.Question)
+		currentByKey := make(map[string]models.Question)
 
 		if draft.SurveyID > 0 {
 			// Update existing survey
@@ -236,7 +500,17 @@ func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint)
 			existingSurvey.Title = draftContent.BasicInfo.Title
 			existingSurvey.Description = draftContent.BasicInfo.Description
 			existingSurvey.IsSelfRecruitment = draftContent.BasicInfo.IsSelfRecruitment
-			existingSurvey.Status = "PUBLISHED"
+			if draftContent.BasicInfo.OpensAt != nil {
+				existingSurvey.OpensAt = draftContent.BasicInfo.OpensAt
+			}
+			if draftContent.BasicInfo.ClosesAt != nil {
+				existingSurvey.ClosesAt = draftContent.BasicInfo.ClosesAt
+			}
+			status, err := publishStatus(existingSurvey, time.Now())
+			if err != nil {
+				return 0, err
+			}
+			existingSurvey.Status = status
 			existingSurvey.UpdatedAt = time.Now()
 
 			if err := s.surveyRepo.UpdateWithTx(ctx, tx, existingSurvey); err != nil {
@@ -245,17 +519,23 @@ func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint)
 
 			surveyID = existingSurvey.SurveyID
 
-			// First delete options, then questions
-			if err := s.surveyRepo.DeleteOptionsWithTx(ctx, tx, surveyID); err != nil {
-				return 0, err
+			// Remember the questions being superseded, so edited questions keep
+			// their key and lineage
+			for _, q := range existingSurvey.Questions {
+				current[q.QuestionID] = q
+				if q.QuestionKey != "" {
+					currentByKey[q.QuestionKey] = q
+				}
 			}
 
-			if err := s.surveyRepo.DeleteQuestionsWithTx(ctx, tx, surveyID); err != nil {
+			// Retire the current questions instead of deleting them: earlier versions,
+			// in-flight sessions and stored answers still reference them
+			if err := s.versionRepo.RetireQuestionsWithTx(ctx, tx, surveyID, time.Now()); err != nil {
 				return 0, err
 			}
 
-			// Delete existing media files
-			if err := s.surveyRepo.DeleteMediaFilesWithTx(ctx, tx, surveyID); err != nil {
+			// The old rules live on in the previous version's snapshot
+			if err := s.ruleRepo.DeleteBySurveyIDWithTx(ctx, tx, surveyID); err != nil {
 				return 0, err
 			}
 		} else {
@@ -265,10 +545,17 @@ func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint)
 				Description:       draftContent.BasicInfo.Description,
 				IsSelfRecruitment: draftContent.BasicInfo.IsSelfRecruitment,
 				ConductorID:       draftContent.BasicInfo.ConductorID,
-				Status:            "PUBLISHED",
+				Status:            models.StatusDraft,
+				OpensAt:           draftContent.BasicInfo.OpensAt,
+				ClosesAt:          draftContent.BasicInfo.ClosesAt,
 				CreatedAt:         time.Now(),
 				UpdatedAt:         time.Now(),
 			}
+			status, err := publishStatus(&survey, time.Now())
+			if err != nil {
+				return 0, err
+			}
+			survey.Status = status
 
 			if err := s.surveyRepo.CreateWithTx(ctx, tx, &survey); err != nil {
 				return 0, err
@@ -277,73 +564,109 @@ func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint)
 			surveyID = survey.SurveyID
 		}
 
-		// Create a map to store question objects by ID for later reference
-		questionMap := make(map[uint]models.Question)
+		// Draft questions may only go on the survey's own pages
+		sections, err := s.sectionRepo.GetBySurveyIDWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return 0, err
+		}
+		pages := make(map[uint]bool)
+		for _, section := range sections {
+			for _, page := range section.Pages {
+				pages[page.PageID] = true
+			}
+		}
+
+		usedKeys := make(map[string]bool, len(draftContent.Questions))
+		for key := range draftKeys {
+			usedKeys[key] = true
+		}
 
-		// Add questions
+		blueprints := make([]questionBlueprint, 0, len(draftContent.Questions))
+		byDraftID := make(map[uint]int, len(draftContent.Questions))
+		previousOptions := make([][]models.Option, 0, len(draftContent.Questions))
 		for _, q := range draftContent.Questions {
-			question := models.Question{
-				SurveyID:       surveyID,
-				QuestionText:   q.QuestionText,
-				QuestionType:   q.QuestionType,
-				Mandatory:      q.Mandatory,
-				BranchingLogic: q.BranchingLogic,
-				CorrectAnswers: q.CorrectAnswers,
-				CreatedAt:      time.Now(),
-				UpdatedAt:      time.Now(),
+			// Keep the key of the question being edited unless the draft sets one
+			key, _ := NormalizeQuestionKey(q.QuestionKey)
+			if source, ok := current[q.SourceQuestion]; key == "" && ok && source.QuestionKey != "" && !usedKeys[source.QuestionKey] {
+				key = source.QuestionKey
 			}
+			if key == "" {
+				key = NewQuestionKey()
+			}
+			usedKeys[key] = true
 
-			if err := s.surveyRepo.CreateQuestionWithTx(ctx, tx, &question); err != nil {
-				return 0, err
+			// A question continues the lineage of the published question with the same
+			// key, or else of the one it says it edits
+			previous, ok := currentByKey[key]
+			if !ok {
+				previous = current[q.SourceQuestion]
+			}
+			lineage := previous.LineageID
+			if lineage == 0 {
+				lineage = previous.QuestionID
+			}
+
+			question := q.model()
+			question.QuestionKey = key
+			question.LineageID = lineage
+			if question.PageID == nil {
+				question.PageID = previous.PageID
+			} else if !pages[*question.PageID] {
+				return 0, fmt.Errorf("%w: question %d is on page %d, which is not in the survey", ErrInvalidLayout, q.QuestionID, *question.PageID)
+			}
+			if question.BankQuestionID == nil {
+				question.BankQuestionID = previous.BankQuestionID
 			}
 
-			// Store the created question with its new ID
-			questionMap[q.QuestionID] = question
+			byDraftID[q.QuestionID] = len(blueprints)
+			blueprints = append(blueprints, questionBlueprint{
+				SourceID: q.QuestionID,
+				Question: question,
+			})
+			previousOptions = append(previousOptions, previous.Options)
 		}
 
-		// Add options for multiple-choice questions
+		// Attach options and media files to their questions by draft question_id
 		for _, opt := range draftContent.Options {
-			// Get the created question using the original question_id
-			question, exists := questionMap[opt.QuestionID]
+			i, exists := byDraftID[opt.QuestionID]
 			if !exists {
 				// Skip if question doesn't exist (should not happen with valid data)
 				log.Printf("Warning: Option references non-existent question ID: %d", opt.QuestionID)
 				continue
 			}
-
-			option := models.Option{
-				QuestionID: question.QuestionID, // Use the new question ID
-				OptionText: opt.OptionText,
-				CreatedAt:  time.Now(),
-				UpdatedAt:  time.Now(),
-			}
-
-			if err := tx.Create(&option).Error; err != nil {
-				return 0, err
-			}
+			key, _ := NormalizeQuestionKey(opt.OptionKey)
+			blueprints[i].Options = append(blueprints[i].Options, models.Option{OptionKey: key, OptionText: opt.OptionText, MediaID: opt.MediaID, PinLast: opt.PinLast})
+		}
+		for i := range blueprints {
+			inheritOptionKeys(blueprints[i].Options, previousOptions[i])
 		}
-
-		// Add media files
 		for _, m := range draftContent.MediaFiles {
-			// Get the created question using the original question_id
-			question, exists := questionMap[m.QuestionID]
+			i, exists := byDraftID[m.QuestionID]
 			if !exists {
-				// Skip if question doesn't exist
 				log.Printf("Warning: Media file references non-existent question ID: %d", m.QuestionID)
 				continue
 			}
+			blueprints[i].Media = append(blueprints[i].Media, models.SurveyMediaFile{FileURL: m.FileURL, FileType: m.FileType})
+		}
 
-			mediaFile := models.SurveyMediaFile{
-				SurveyID:   surveyID,
-				QuestionID: question.QuestionID, // Use the new question ID
-				FileURL:    m.FileURL,
-				FileType:   m.FileType,
-				CreatedAt:  time.Now(),
-			}
+		if _, err := s.copyQuestionsWithTx(ctx, tx, surveyID, blueprints, draftKeys); err != nil {
+			return 0, err
+		}
 
-			if err := s.surveyRepo.CreateMediaFileWithTx(ctx, tx, &mediaFile); err != nil {
+		// A draft that lists requirements replaces the survey's; one without keeps them
+		if draftContent.Requirements != nil {
+			if err := s.surveyRepo.DeleteRequirementsWithTx(ctx, tx, surveyID); err != nil {
 				return 0, err
 			}
+			for _, requirement := range draftRequirements(draftContent, surveyID, time.Now()) {
+				if err := s.surveyRepo.CreateRequirementWithTx(ctx, tx, &requirement); err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		if _, err := s.createVersionWithTx(ctx, tx, surveyID); err != nil {
+			return 0, err
 		}
 
 		// Delete all drafts for this survey
@@ -355,6 +678,95 @@ func (s *surveyService) PublishDraftToSurvey(ctx context.Context, draftID uint)
 	})
 }
 
+// ValidateDraft reports branching problems in a draft without publishing it
+func (s *surveyService) ValidateDraft(ctx context.Context, draftID uint) (*BranchingReport, error) {
+	draft, err := s.surveyDraftRepo.GetByID(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	draftContent, err := parseDraftDocument(draft.DraftContent)
+	if err != nil {
+		return nil, err
+	}
+	return validateDraftBranching(draftContent)
+}
+
+// ExportFlow renders the survey's question flow (questions as nodes, branching rules as edges)
+func (s *surveyService) ExportFlow(ctx context.Context, surveyID uint, format string) (string, error) {
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return "", err
+	}
+	rules, err := s.ruleRepo.GetBySurveyID(ctx, surveyID)
+	if err != nil {
+		return "", err
+	}
+
+	engine, err := NewBranchingEngine(survey.Questions, rules)
+	if err != nil {
+		return "", err
+	}
+	return engine.RenderFlow(format)
+}
+
 func (s *surveyService) GetLatestDraft(ctx context.Context, surveyID uint) (*models.SurveyDraft, error) {
 	return s.surveyDraftRepo.GetLatestDraft(ctx, surveyID)
 }
+
+// ListVersions returns the published versions of a survey, newest first, without their snapshots
+func (s *surveyService) ListVersions(ctx context.Context, surveyID uint) ([]models.SurveyVersion, error) {
+	return s.versionRepo.ListBySurveyID(ctx, surveyID)
+}
+
+func (s *surveyService) GetVersion(ctx context.Context, surveyID uint, versionNumber int) (*models.SurveyVersion, error) {
+	return s.versionRepo.GetByNumber(ctx, surveyID, versionNumber)
+}
+
+// ChangeStatus moves a published survey through its lifecycle by hand
+func (s *surveyService) ChangeStatus(ctx context.Context, surveyID, conductorID uint, status string) (*models.Survey, error) {
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey.ConductorID != conductorID {
+		return nil, ErrSurveyNotAccessible
+	}
+	if err := checkTransition(survey.Status, status); err != nil {
+		return nil, err
+	}
+
+	// Conditional on the status we checked, so a concurrent change (e.g. by the scheduler) wins
+	updated, err := s.surveyRepo.UpdateStatus(ctx, surveyID, survey.Status, status)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: survey %d changed status concurrently", ErrInvalidStatusTransition, surveyID)
+	}
+	return s.surveyRepo.GetByID(ctx, surveyID)
+}
+
+// SetSchedule replaces the survey's opening and closing times; the scheduler applies them
+func (s *surveyService) SetSchedule(ctx context.Context, surveyID, conductorID uint, opensAt, closesAt *time.Time) (*models.Survey, error) {
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey.ConductorID != conductorID {
+		return nil, ErrSurveyNotAccessible
+	}
+
+	switch normalizeStatus(survey.Status) {
+	case models.StatusClosed, models.StatusArchived:
+		return nil, fmt.Errorf("%w: %s surveys cannot be rescheduled", ErrInvalidSchedule, survey.Status)
+	}
+	if err := validateSchedule(opensAt, closesAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.surveyRepo.UpdateSchedule(ctx, surveyID, opensAt, closesAt); err != nil {
+		return nil, err
+	}
+	return s.surveyRepo.GetByID(ctx, surveyID)
+}
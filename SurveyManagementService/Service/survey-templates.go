@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+var (
+	ErrInvalidTemplateScope  = errors.New("invalid template scope")
+	ErrNotATemplate          = errors.New("survey is not a template")
+	ErrTemplateNotAccessible = errors.New("template is not accessible to this conductor")
+	ErrSurveyNotAccessible   = errors.New("survey is not accessible to this conductor")
+)
+
+// CloneOptions customises the survey created by CloneSurvey and UseTemplate. An empty
+// Title falls back to the source survey's. ConductorID is the authenticated conductor
+// the new survey belongs to, and whose access to the source is checked.
+type CloneOptions struct {
+	Title       string
+	ConductorID uint
+}
+
+// CloneSurvey deep-copies a survey's current questions, options, media, requirements,
+// branching rules and translations into a new DRAFT survey. Question keys are kept; IDs,
+// versions and lineage start afresh. Conductors may clone their own surveys and the
+// templates they may use.
+func (s *surveyService) CloneSurvey(ctx context.Context, surveyID uint, opts CloneOptions) (*models.Survey, error) {
+	var clone *models.Survey
+	err := s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		source, err := s.surveyRepo.GetWithContentWithTx(ctx, tx, surveyID)
+		if err != nil {
+			return err
+		}
+		if opts.ConductorID == 0 || (source.ConductorID != opts.ConductorID && source.TemplateScope != models.TemplateScopeOrganisation) {
+			return ErrSurveyNotAccessible
+		}
+		if opts.Title == "" {
+			opts.Title = source.Title + " (copy)"
+		}
+		clone, err = s.cloneSurveyWithTx(ctx, tx, source, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.surveyRepo.GetByID(ctx, clone.SurveyID)
+}
+
+// UseTemplate creates a new DRAFT survey from a template the conductor may use
+func (s *surveyService) UseTemplate(ctx context.Context, templateID uint, opts CloneOptions) (*models.Survey, error) {
+	var clone *models.Survey
+	err := s.surveyRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		template, err := s.surveyRepo.GetWithContentWithTx(ctx, tx, templateID)
+		if err != nil {
+			return err
+		}
+		switch template.TemplateScope {
+		case models.TemplateScopeOrganisation:
+		case models.TemplateScopePersonal:
+			if opts.ConductorID == 0 || opts.ConductorID != template.ConductorID {
+				return ErrTemplateNotAccessible
+			}
+		default:
+			return ErrNotATemplate
+		}
+		clone, err = s.cloneSurveyWithTx(ctx, tx, template, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.surveyRepo.GetByID(ctx, clone.SurveyID)
+}
+
+// SetTemplate offers a survey as a template in the given scope, or withdraws it when
+// scope is empty
+func (s *surveyService) SetTemplate(ctx context.Context, surveyID uint, scope string) (*models.Survey, error) {
+	scope = strings.ToUpper(strings.TrimSpace(scope))
+	switch scope {
+	case "", models.TemplateScopePersonal, models.TemplateScopeOrganisation:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTemplateScope, scope)
+	}
+
+	if _, err := s.surveyRepo.GetByID(ctx, surveyID); err != nil {
+		return nil, err
+	}
+	if err := s.surveyRepo.SetTemplateScope(ctx, surveyID, scope); err != nil {
+		return nil, err
+	}
+	return s.surveyRepo.GetByID(ctx, surveyID)
+}
+
+// ListTemplates returns the templates a conductor can start a survey from
+func (s *surveyService) ListTemplates(ctx context.Context, conductorID uint) ([]models.Survey, error) {
+	return s.surveyRepo.ListTemplates(ctx, conductorID)
+}
+
+func (s *surveyService) cloneSurveyWithTx(ctx context.Context, tx *gorm.DB, source *models.Survey, opts CloneOptions) (*models.Survey, error) {
+	now := time.Now()
+	clone := &models.Survey{
+		ConductorID:       opts.ConductorID,
+		Title:             source.Title,
+		Description:       source.Description,
+		IsSelfRecruitment: source.IsSelfRecruitment,
+		Status:            models.StatusDraft,
+		SourceSurveyID:    source.SurveyID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+
+		DefaultLocale:    source.DefaultLocale,
+		SupportedLocales: source.SupportedLocales,
+	}
+	if opts.Title != "" {
+		clone.Title = opts.Title
+	}
+	if err := s.surveyRepo.CreateWithTx(ctx, tx, clone); err != nil {
+		return nil, err
+	}
+
+	questionIDs := make([]uint, len(source.Questions))
+	for i, q := range source.Questions {
+		questionIDs[i] = q.QuestionID
+	}
+	mediaFiles, err := s.surveyRepo.GetMediaFilesByQuestionIDsWithTx(ctx, tx, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	mediaByQuestion := make(map[uint][]models.SurveyMediaFile)
+	for _, m := range mediaFiles {
+		mediaByQuestion[m.QuestionID] = append(mediaByQuestion[m.QuestionID], m)
+	}
+
+	pageIDs, err := s.copySectionsWithTx(ctx, tx, source.SurveyID, clone.SurveyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := make([]models.Question, len(source.Questions))
+	copy(questions, source.Questions)
+	sortQuestions(questions)
+
+	keys := make(map[string]uint, len(questions))
+	blueprints := make([]questionBlueprint, 0, len(questions))
+	for _, q := range questions {
+		if q.QuestionKey != "" {
+			keys[q.QuestionKey] = q.QuestionID
+		}
+		var pageID *uint
+		if q.PageID != nil {
+			if id, ok := pageIDs[*q.PageID]; ok {
+				pageID = &id
+			}
+		}
+		blueprints = append(blueprints, questionBlueprint{
+			SourceID: q.QuestionID,
+			Question: models.Question{
+				QuestionKey:    q.QuestionKey,
+				QuestionText:   q.QuestionText,
+				QuestionType:   q.QuestionType,
+				Mandatory:      q.Mandatory,
+				BranchingLogic: q.BranchingLogic,
+				CorrectAnswers: q.CorrectAnswers,
+				MatrixRows:     q.MatrixRows,
+				MatrixColumns:  q.MatrixColumns,
+				MatrixMulti:    q.MatrixMulti,
+				CodeLanguage:   q.CodeLanguage,
+				StarterCode:    q.StarterCode,
+				CodeTests:      q.CodeTests,
+				PageID:         pageID,
+
+				QuestionConstraints: q.QuestionConstraints,
+				ValidationRules:     q.ValidationRules,
+				RandomizeOptions:    q.RandomizeOptions,
+				BankQuestionID:      q.BankQuestionID,
+			},
+			Options: q.Options,
+			Media:   mediaByQuestion[q.QuestionID],
+		})
+	}
+	if _, err := s.copyQuestionsWithTx(ctx, tx, clone.SurveyID, blueprints, keys); err != nil {
+		return nil, err
+	}
+	if err := s.translationRepo.CopyWithTx(ctx, tx, source.SurveyID, clone.SurveyID); err != nil {
+		return nil, err
+	}
+
+	for _, req := range source.Requirements {
+		requirement := models.SurveyRequirement{
+			SurveyID:            clone.SurveyID,
+			SkillName:           req.SkillName,
+			MinProficiencyLevel: req.MinProficiencyLevel,
+			ExperienceLevel:     req.ExperienceLevel,
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+		if err := s.surveyRepo.CreateRequirementWithTx(ctx, tx, &requirement); err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// copySectionsWithTx gives the clone its own copy of the source survey's sections and
+// pages. It returns the new page ID for each source page ID.
+func (s *surveyService) copySectionsWithTx(ctx context.Context, tx *gorm.DB, sourceID, cloneID uint, now time.Time) (map[uint]uint, error) {
+	sections, err := s.sectionRepo.GetBySurveyIDWithTx(ctx, tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	pageIDs := make(map[uint]uint)
+	for _, src := range sections {
+		section := models.Section{
+			SurveyID:    cloneID,
+			Title:       src.Title,
+			Description: src.Description,
+			Position:    src.Position,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+
+			RandomizeQuestions: src.RandomizeQuestions,
+		}
+		if err := s.sectionRepo.CreateSectionWithTx(ctx, tx, &section); err != nil {
+			return nil, err
+		}
+		for _, p := range src.Pages {
+			page := models.Page{
+				SectionID: section.SectionID,
+				SurveyID:  cloneID,
+				Title:     p.Title,
+				Position:  p.Position,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := s.sectionRepo.CreatePageWithTx(ctx, tx, &page); err != nil {
+				return nil, err
+			}
+			pageIDs[p.PageID] = page.PageID
+		}
+	}
+	return pageIDs, nil
+}
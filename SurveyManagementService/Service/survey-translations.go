@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+// ErrInvalidLocale means a locale is not a valid BCP 47 tag, or is not one the survey is
+// translated into
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// Translation states of a survey string in one locale
+const (
+	TranslationStateTranslated   = "TRANSLATED"
+	TranslationStateUntranslated = "UNTRANSLATED"
+	TranslationStateOutdated     = "OUTDATED" // Translated from a source text that has since changed
+)
+
+// SurveyLocales are the locales a survey can be taken in. DefaultLocale, the one it is
+// written in, is always among SupportedLocales; both empty means no translation.
+type SurveyLocales struct {
+	DefaultLocale    string   `json:"default_locale"`
+	SupportedLocales []string `json:"supported_locales"`
+}
+
+// TranslationUnit is one translatable string of a survey with its translation, if any
+type TranslationUnit struct {
+	ResourceID string `json:"resource_id"`
+	SourceText string `json:"source_text"`
+	Text       string `json:"text,omitempty"` // Current translation, or the outdated one
+	State      string `json:"state"`
+}
+
+// SurveyTranslations are a survey's strings in one locale
+type SurveyTranslations struct {
+	SurveyID     uint              `json:"survey_id"`
+	SourceLocale string            `json:"source_locale"`
+	Locale       string            `json:"locale"`
+	Units        []TranslationUnit `json:"units"`
+}
+
+// TranslationInput is a translation to save. SourceText is optional; when given it must
+// still be the string's source, so translations of changed text are not saved.
+type TranslationInput struct {
+	ResourceID string `json:"resource_id"`
+	SourceText string `json:"source_text,omitempty"`
+	Text       string `json:"text"`
+}
+
+// TranslationReport is the outcome of saving or importing translations
+type TranslationReport struct {
+	Locale  string               `json:"locale"`
+	Saved   int                  `json:"saved"`
+	Skipped []SkippedTranslation `json:"skipped,omitempty"`
+}
+
+type SkippedTranslation struct {
+	ResourceID string `json:"resource_id"`
+	Reason     string `json:"reason"`
+}
+
+type TranslationService interface {
+	SetLocales(ctx context.Context, surveyID uint, locales SurveyLocales) (*SurveyLocales, error)
+	GetTranslations(ctx context.Context, surveyID uint, locale string, untranslatedOnly bool) (*SurveyTranslations, error)
+	SaveTranslations(ctx context.Context, surveyID uint, locale string, inputs []TranslationInput) (*TranslationReport, error)
+	ExportTranslations(ctx context.Context, surveyID uint, locale, format string, all bool) ([]byte, error)
+	ImportTranslations(ctx context.Context, surveyID uint, locale, format string, data []byte) (*TranslationReport, error)
+}
+
+type translationService struct {
+	translationRepo repository.TranslationRepository
+	surveyRepo      repository.SurveyRepository
+}
+
+func NewTranslationService(translationRepo repository.TranslationRepository, surveyRepo repository.SurveyRepository) TranslationService {
+	return &translationService{
+		translationRepo: translationRepo,
+		surveyRepo:      surveyRepo,
+	}
+}
+
+// SetLocales sets the locales a survey can be taken in. Tags are stored in canonical
+// form; the default locale is added to the supported ones when missing.
+func (s *translationService) SetLocales(ctx context.Context, surveyID uint, locales SurveyLocales) (*SurveyLocales, error) {
+	if _, err := s.surveyRepo.GetByID(ctx, surveyID); err != nil {
+		return nil, err
+	}
+
+	result := SurveyLocales{SupportedLocales: []string{}}
+	if strings.TrimSpace(locales.DefaultLocale) == "" {
+		if len(locales.SupportedLocales) > 0 {
+			return nil, fmt.Errorf("%w: default_locale is required with supported_locales", ErrInvalidLocale)
+		}
+	} else {
+		tag, err := canonicalLocale(locales.DefaultLocale)
+		if err != nil {
+			return nil, err
+		}
+		result.DefaultLocale = tag
+		result.SupportedLocales = append(result.SupportedLocales, tag)
+	}
+	for _, locale := range locales.SupportedLocales {
+		tag, err := canonicalLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		if !containsLocale(result.SupportedLocales, tag) {
+			result.SupportedLocales = append(result.SupportedLocales, tag)
+		}
+	}
+
+	var supported models.JSONContent
+	if len(result.SupportedLocales) > 0 {
+		encoded, err := json.Marshal(result.SupportedLocales)
+		if err != nil {
+			return nil, err
+		}
+		supported = models.JSONContent(encoded)
+	}
+	if err := s.surveyRepo.SetLocales(ctx, surveyID, result.DefaultLocale, supported); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTranslations lists the survey's strings in a locale with their translation state
+func (s *translationService) GetTranslations(ctx context.Context, surveyID uint, locale string, untranslatedOnly bool) (*SurveyTranslations, error) {
+	survey, locale, err := s.translationTarget(ctx, surveyID, locale)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.translationRepo.GetBySurveyLocale(ctx, surveyID, locale)
+	if err != nil {
+		return nil, err
+	}
+	byResource := make(map[string]models.Translation, len(existing))
+	for _, t := range existing {
+		byResource[t.ResourceID] = t
+	}
+
+	result := &SurveyTranslations{SurveyID: surveyID, SourceLocale: survey.DefaultLocale, Locale: locale, Units: []TranslationUnit{}}
+	for _, r := range surveyResources(survey) {
+		unit := TranslationUnit{ResourceID: r.ID, SourceText: r.Source, State: TranslationStateUntranslated}
+		if t, ok := byResource[r.ID]; ok {
+			unit.Text = t.Text
+			unit.State = TranslationStateOutdated
+			if t.SourceText == r.Source {
+				unit.State = TranslationStateTranslated
+			}
+		}
+		if untranslatedOnly && unit.State == TranslationStateTranslated {
+			continue
+		}
+		result.Units = append(result.Units, unit)
+	}
+	return result, nil
+}
+
+// SaveTranslations saves translations of the survey's current strings into one locale.
+// Translations of unknown strings, of changed source text, empty ones and ones that do
+// not keep the source's answer placeholders are skipped and reported.
+func (s *translationService) SaveTranslations(ctx context.Context, surveyID uint, locale string, inputs []TranslationInput) (*TranslationReport, error) {
+	survey, locale, err := s.translationTarget(ctx, surveyID, locale)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string)
+	for _, r := range surveyResources(survey) {
+		sources[r.ID] = r.Source
+	}
+
+	report := &TranslationReport{Locale: locale}
+	skip := func(id, reason string) {
+		report.Skipped = append(report.Skipped, SkippedTranslation{ResourceID: id, Reason: reason})
+	}
+
+	now := time.Now()
+	var translations []models.Translation
+	index := make(map[string]int)
+	for _, input := range inputs {
+		source, ok := sources[input.ResourceID]
+		switch {
+		case !ok:
+			skip(input.ResourceID, "not a string of the survey")
+			continue
+		case input.SourceText != "" && input.SourceText != source:
+			skip(input.ResourceID, "source text has changed")
+			continue
+		case strings.TrimSpace(input.Text) == "":
+			skip(input.ResourceID, "translation is empty")
+			continue
+		case !samePipes(source, input.Text):
+			skip(input.ResourceID, "translation does not keep the source's answer placeholders")
+			continue
+		}
+
+		translation := models.Translation{
+			SurveyID:   surveyID,
+			Locale:     locale,
+			ResourceID: input.ResourceID,
+			SourceText: source,
+			Text:       input.Text,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		// A string translated twice keeps the last translation
+		if i, seen := index[input.ResourceID]; seen {
+			translations[i] = translation
+			continue
+		}
+		index[input.ResourceID] = len(translations)
+		translations = append(translations, translation)
+	}
+
+	if err := s.translationRepo.Save(ctx, translations); err != nil {
+		return nil, err
+	}
+	report.Saved = len(translations)
+	return report, nil
+}
+
+// ExportTranslations writes the survey's strings that need translating into a locale, or
+// all of them, as a file for translators
+func (s *translationService) ExportTranslations(ctx context.Context, surveyID uint, locale, format string, all bool) ([]byte, error) {
+	translations, err := s.GetTranslations(ctx, surveyID, locale, !all)
+	if err != nil {
+		return nil, err
+	}
+	return encodeTranslations(format, translations)
+}
+
+// ImportTranslations saves the translations in a file written by ExportTranslations.
+// XLIFF files name their target locale; locale may be empty for them, and must match
+// when given.
+func (s *translationService) ImportTranslations(ctx context.Context, surveyID uint, locale, format string, data []byte) (*TranslationReport, error) {
+	fileLocale, inputs, err := decodeTranslations(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if fileLocale != "" {
+		tag, err := canonicalLocale(fileLocale)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTranslationFile, err)
+		}
+		if locale != "" {
+			if requested, err := canonicalLocale(locale); err != nil || requested != tag {
+				return nil, fmt.Errorf("%w: file is for locale %s, not %s", ErrInvalidTranslationFile, tag, locale)
+			}
+		}
+		locale = tag
+	}
+	if locale == "" {
+		return nil, fmt.Errorf("%w: the file does not name its locale; pass one", ErrInvalidLocale)
+	}
+	return s.SaveTranslations(ctx, surveyID, locale, inputs)
+}
+
+// translationTarget loads the survey and checks locale is one it is translated into,
+// rather than the one it is written in
+func (s *translationService) translationTarget(ctx context.Context, surveyID uint, locale string) (*models.Survey, string, error) {
+	survey, err := s.surveyRepo.GetByID(ctx, surveyID)
+	if err != nil {
+		return nil, "", err
+	}
+	tag, err := canonicalLocale(locale)
+	if err != nil {
+		return nil, "", err
+	}
+	if tag == survey.DefaultLocale {
+		return nil, "", fmt.Errorf("%w: %s is the locale the survey is written in", ErrInvalidLocale, tag)
+	}
+	if !containsLocale(supportedLocales(survey), tag) {
+		return nil, "", fmt.Errorf("%w: the survey does not support %s", ErrInvalidLocale, tag)
+	}
+	return survey, tag, nil
+}
+
+// translationResource is a translatable string of a survey
+type translationResource struct {
+	ID     string
+	Source string
+}
+
+// surveyResources lists the survey's translatable strings in survey order: its title and
+// description, then each current question's text and its options' texts. Resource IDs
+// use question and option keys, so they survive reordering, republishing and copying.
+func surveyResources(survey *models.Survey) []translationResource {
+	var resources []translationResource
+	add := func(id, source string) {
+		if strings.TrimSpace(source) != "" {
+			resources = append(resources, translationResource{ID: id, Source: source})
+		}
+	}
+	add("survey.title", survey.Title)
+	add("survey.description", survey.Description)
+	for _, q := range survey.Questions {
+		prefix := "question." + questionResourceKey(q)
+		add(prefix+".text", q.QuestionText)
+		for _, option := range q.Options {
+			add(prefix+".option."+optionResourceKey(option)+".text", option.OptionText)
+		}
+	}
+	return resources
+}
+
+// questionResourceKey names a question in resource IDs: by its key, or by ID for
+// questions from before keys. Keys are lower-case, so the two cannot collide.
+func questionResourceKey(q models.Question) string {
+	if q.QuestionKey != "" {
+		return q.QuestionKey
+	}
+	return "ID" + strconv.FormatUint(uint64(q.QuestionID), 10)
+}
+
+// optionResourceKey names an option in resource IDs like questionResourceKey does
+func optionResourceKey(option models.Option) string {
+	if option.OptionKey != "" {
+		return option.OptionKey
+	}
+	return "ID" + strconv.FormatUint(uint64(option.OptionID), 10)
+}
+
+// samePipes reports whether a translation pipes in the same answers as its source
+func samePipes(source, translation string) bool {
+	want := pipedKeys(source)
+	got := pipedKeys(translation)
+	if len(want) != len(got) {
+		return false
+	}
+	for _, key := range want {
+		if !containsLocale(got, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalLocale parses a BCP 47 tag and returns it in canonical form, e.g. "pt-BR"
+func canonicalLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil || tag == language.Und {
+		return "", fmt.Errorf("%w: %q is not a BCP 47 language tag", ErrInvalidLocale, locale)
+	}
+	return tag.String(), nil
+}
+
+// supportedLocales reads the survey's supported locales
+func supportedLocales(survey *models.Survey) []string {
+	var locales []string
+	if len(survey.SupportedLocales) > 0 {
+		_ = json.Unmarshal(survey.SupportedLocales, &locales)
+	}
+	return locales
+}
+
+func containsLocale(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
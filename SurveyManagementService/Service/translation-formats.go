@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTranslationFile means an imported translation file could not be read
+var ErrInvalidTranslationFile = errors.New("invalid translation file")
+
+// Formats translations are exported and imported in
+const (
+	TranslationFormatXLIFF = "xliff"
+	TranslationFormatCSV   = "csv"
+)
+
+// TranslationContentType returns the media type of an exported translation file
+func TranslationContentType(format string) string {
+	if format == TranslationFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/xliff+xml"
+}
+
+// XLIFF 2.0 documents, as read and written by translation tools. Each string is a unit
+// with one segment; an outdated translation goes in a note rather than the target, so
+// importing an untouched file does not save it against the new source.
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version string      `xml:"version,attr"`
+	SrcLang string      `xml:"srcLang,attr"`
+	TrgLang string      `xml:"trgLang,attr,omitempty"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	ID    string      `xml:"id,attr"`
+	Units []xliffUnit `xml:"unit"`
+}
+
+type xliffUnit struct {
+	ID      string       `xml:"id,attr"`
+	Notes   *xliffNotes  `xml:"notes,omitempty"`
+	Segment xliffSegment `xml:"segment"`
+}
+
+type xliffNotes struct {
+	Notes []xliffNote `xml:"note"`
+}
+
+type xliffNote struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type xliffSegment struct {
+	State  string  `xml:"state,attr,omitempty"`
+	Source string  `xml:"source"`
+	Target *string `xml:"target"`
+}
+
+// csvHeader are the columns of exported CSV files; imports need id and target, and
+// check source when present
+var csvHeader = []string{"id", "source", "target", "previous_target"}
+
+func encodeTranslations(format string, translations *SurveyTranslations) ([]byte, error) {
+	switch format {
+	case TranslationFormatXLIFF, "":
+		return encodeXLIFF(translations)
+	case TranslationFormatCSV:
+		return encodeCSV(translations)
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTranslationFile, format)
+}
+
+// decodeTranslations reads a translation file, returning the locale it names, if any
+func decodeTranslations(format string, data []byte) (string, []TranslationInput, error) {
+	switch format {
+	case TranslationFormatXLIFF, "":
+		return decodeXLIFF(data)
+	case TranslationFormatCSV:
+		inputs, err := decodeCSV(data)
+		return "", inputs, err
+	}
+	return "", nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTranslationFile, format)
+}
+
+func encodeXLIFF(translations *SurveyTranslations) ([]byte, error) {
+	file := xliffFile{ID: "survey-" + strconv.FormatUint(uint64(translations.SurveyID), 10), Units: []xliffUnit{}}
+	for _, unit := range translations.Units {
+		u := xliffUnit{ID: unit.ResourceID, Segment: xliffSegment{State: "initial", Source: unit.SourceText}}
+		switch unit.State {
+		case TranslationStateTranslated:
+			text := unit.Text
+			u.Segment.State = "translated"
+			u.Segment.Target = &text
+		case TranslationStateOutdated:
+			u.Notes = &xliffNotes{Notes: []xliffNote{{Category: "previous-translation", Text: unit.Text}}}
+		}
+		file.Units = append(file.Units, u)
+	}
+
+	doc := xliffDocument{
+		Version: "2.0",
+		SrcLang: translations.SourceLocale,
+		TrgLang: translations.Locale,
+		Files:   []xliffFile{file},
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// decodeXLIFF reads the units of an XLIFF 2.0 file. Units without a target are left out:
+// they have not been translated yet.
+func decodeXLIFF(data []byte) (string, []TranslationInput, error) {
+	var doc xliffDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidTranslationFile, err)
+	}
+	if !strings.HasPrefix(doc.Version, "2.") {
+		return "", nil, fmt.Errorf("%w: XLIFF version %q is not supported, use 2.0", ErrInvalidTranslationFile, doc.Version)
+	}
+
+	var inputs []TranslationInput
+	for _, file := range doc.Files {
+		for _, unit := range file.Units {
+			if unit.Segment.Target == nil {
+				continue
+			}
+			inputs = append(inputs, TranslationInput{
+				ResourceID: unit.ID,
+				SourceText: unit.Segment.Source,
+				Text:       *unit.Segment.Target,
+			})
+		}
+	}
+	return doc.TrgLang, inputs, nil
+}
+
+func encodeCSV(translations *SurveyTranslations) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, unit := range translations.Units {
+		var target, previous string
+		switch unit.State {
+		case TranslationStateTranslated:
+			target = unit.Text
+		case TranslationStateOutdated:
+			previous = unit.Text
+		}
+		if err := writer.Write([]string{unit.ResourceID, unit.SourceText, target, previous}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// decodeCSV reads a CSV file by its header, so translators may reorder or add columns.
+// Rows with an empty target are left out.
+func decodeCSV(data []byte) ([]TranslationInput, error) {
+	// Spreadsheet programs may save a byte order mark
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header row", ErrInvalidTranslationFile)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idColumn, hasID := columns["id"]
+	targetColumn, hasTarget := columns["target"]
+	if !hasID || !hasTarget {
+		return nil, fmt.Errorf("%w: header must have id and target columns", ErrInvalidTranslationFile)
+	}
+	sourceColumn, hasSource := columns["source"]
+
+	field := func(record []string, i int) string {
+		if i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var inputs []TranslationInput
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTranslationFile, err)
+		}
+		input := TranslationInput{
+			ResourceID: strings.TrimSpace(field(record, idColumn)),
+			Text:       field(record, targetColumn),
+		}
+		if input.ResourceID == "" || input.Text == "" {
+			continue
+		}
+		if hasSource {
+			input.SourceText = field(record, sourceColumn)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
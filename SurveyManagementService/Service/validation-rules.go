@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+)
+
+// AnswerError is an answer that does not fit its question, with a message meant to be
+// shown next to the question
+type AnswerError struct {
+	QuestionID uint   `json:"question_id"`
+	Message    string `json:"message"`
+}
+
+func (e *AnswerError) Error() string {
+	return fmt.Sprintf("%s to question %d: %s", ErrInvalidAnswer, e.QuestionID, e.Message)
+}
+
+func (e *AnswerError) Unwrap() error {
+	return ErrInvalidAnswer
+}
+
+// answerFormats are the named patterns of ValidationRules.Format
+var answerFormats = map[string]func(string) bool{
+	"email": regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`).MatchString,
+	"url": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	},
+	"phone": regexp.MustCompile(`^\+?[0-9][0-9 ()\-.]{5,18}[0-9]$`).MatchString,
+}
+
+// validateValidationRules checks that a question's rules can be applied
+func validateValidationRules(rules *models.ValidationRules) error {
+	if rules == nil {
+		return nil
+	}
+	if rules.Pattern != "" {
+		if _, err := regexp.Compile(rules.Pattern); err != nil {
+			return fmt.Errorf("%w: pattern: %v", ErrInvalidQuestionConfig, err)
+		}
+	}
+	if rules.Format != "" {
+		if _, ok := answerFormats[rules.Format]; !ok {
+			return fmt.Errorf("%w: format must be email, url or phone", ErrInvalidQuestionConfig)
+		}
+	}
+	if err := validateBounds("length", rules.MinLength, rules.MaxLength); err != nil {
+		return err
+	}
+	return validateBounds("selections", rules.MinSelections, rules.MaxSelections)
+}
+
+func validateBounds(name string, min, max *int) error {
+	if (min != nil && *min < 0) || (max != nil && *max < 0) {
+		return fmt.Errorf("%w: min_%s and max_%s cannot be negative", ErrInvalidQuestionConfig, name, name)
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("%w: min_%s is greater than max_%s", ErrInvalidQuestionConfig, name, name)
+	}
+	return nil
+}
+
+// compileAnswerPattern anchors a pattern so that it must match the whole answer
+func compileAnswerPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// checkValidationRules applies a question's rules to an answer that already fits the
+// question's type. Skipped answers (null, "" or []) pass.
+func checkValidationRules(rules *models.ValidationRules, data []byte) error {
+	if rules == nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: the answer is not valid JSON", ErrInvalidAnswer)
+	}
+
+	var message string
+	switch v := value.(type) {
+	case string:
+		message = checkTextRules(rules, v)
+	case float64:
+		if rules.Integer && v != math.Trunc(v) {
+			message = "must be a whole number"
+		}
+	case []interface{}:
+		message = checkSelectionRules(rules, len(v))
+	}
+	if message == "" {
+		return nil
+	}
+	if rules.Message != "" {
+		message = rules.Message
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidAnswer, message)
+}
+
+func checkTextRules(rules *models.ValidationRules, text string) string {
+	if text == "" {
+		return ""
+	}
+	length := utf8.RuneCountInString(text)
+	if rules.MinLength != nil && length < *rules.MinLength {
+		return fmt.Sprintf("must be at least %d characters", *rules.MinLength)
+	}
+	if rules.MaxLength != nil && length > *rules.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", *rules.MaxLength)
+	}
+	if rules.Integer {
+		if _, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err != nil {
+			return "must be a whole number"
+		}
+	}
+	if rules.Format != "" {
+		if matches, ok := answerFormats[rules.Format]; ok && !matches(strings.TrimSpace(text)) {
+			return "must be a valid " + rules.Format
+		}
+	}
+	if rules.Pattern != "" {
+		if pattern, err := compileAnswerPattern(rules.Pattern); err == nil && !pattern.MatchString(text) {
+			return "is not in the expected format"
+		}
+	}
+	return ""
+}
+
+func checkSelectionRules(rules *models.ValidationRules, count int) string {
+	if count == 0 {
+		return ""
+	}
+	min, max := rules.MinSelections, rules.MaxSelections
+	switch {
+	case min != nil && max != nil && (count < *min || count > *max):
+		if *min == *max {
+			return fmt.Sprintf("choose exactly %d", *min)
+		}
+		return fmt.Sprintf("choose between %d and %d", *min, *max)
+	case min != nil && count < *min:
+		return fmt.Sprintf("choose at least %d", *min)
+	case max != nil && count > *max:
+		return fmt.Sprintf("choose at most %d", *max)
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+)
+
+func TestCheckValidationRules(t *testing.T) {
+	two, three := 2, 3
+
+	tests := []struct {
+		name    string
+		rules   *models.ValidationRules
+		data    string
+		wantMsg string // empty when the answer passes
+	}{
+		{name: "no rules", rules: nil, data: `"anything"`},
+		{name: "email", rules: &models.ValidationRules{Format: "email"}, data: `"a@example.com"`},
+		{name: "bad email", rules: &models.ValidationRules{Format: "email"}, data: `"a@"`, wantMsg: "must be a valid email"},
+		{name: "url", rules: &models.ValidationRules{Format: "url"}, data: `"ftp://example.com"`, wantMsg: "must be a valid url"},
+		{name: "pattern matches in full", rules: &models.ValidationRules{Pattern: `[A-Z]{3}`}, data: `"ABCD"`, wantMsg: "is not in the expected format"},
+		{name: "pattern", rules: &models.ValidationRules{Pattern: `[A-Z]{3}`}, data: `"ABC"`},
+		{name: "too short", rules: &models.ValidationRules{MinLength: &three}, data: `"ab"`, wantMsg: "must be at least 3 characters"},
+		{name: "length counts characters", rules: &models.ValidationRules{MaxLength: &two}, data: `"éé"`},
+		{name: "too long", rules: &models.ValidationRules{MaxLength: &two}, data: `"abc"`, wantMsg: "must be at most 2 characters"},
+		{name: "skipped text", rules: &models.ValidationRules{MinLength: &three}, data: `""`},
+		{name: "integer number", rules: &models.ValidationRules{Integer: true}, data: `4.5`, wantMsg: "must be a whole number"},
+		{name: "integer text", rules: &models.ValidationRules{Integer: true}, data: `" 42 "`},
+		{name: "exact selections", rules: &models.ValidationRules{MinSelections: &two, MaxSelections: &two}, data: `[1, 2, 3]`, wantMsg: "choose exactly 2"},
+		{name: "selection range", rules: &models.ValidationRules{MinSelections: &two, MaxSelections: &three}, data: `[1]`, wantMsg: "choose between 2 and 3"},
+		{name: "skipped selection", rules: &models.ValidationRules{MinSelections: &two}, data: `[]`},
+		{name: "custom message", rules: &models.ValidationRules{MinLength: &three, Message: "Too short!"}, data: `"ab"`, wantMsg: "Too short!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkValidationRules(tt.rules, []byte(tt.data))
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("checkValidationRules(%s) = %v, want nil", tt.data, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidAnswer) || !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Fatalf("checkValidationRules(%s) = %v, want %q", tt.data, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+// Only the methods ValidateSubmission uses are implemented; the rest panic
+type stubSessionRepo struct {
+	repository.SurveySessionRepository
+	sessions map[uint]*models.SurveySession
+}
+
+func (r stubSessionRepo) GetByID(ctx context.Context, id uint) (*models.SurveySession, error) {
+	if session, ok := r.sessions[id]; ok {
+		return session, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubVersionRepo struct {
+	repository.SurveyVersionRepository
+	versions map[uint]*models.SurveyVersion
+}
+
+func (r stubVersionRepo) GetByID(ctx context.Context, id uint) (*models.SurveyVersion, error) {
+	if version, ok := r.versions[id]; ok {
+		return version, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestValidateSubmission(t *testing.T) {
+	five := 5
+	snapshot, err := json.Marshal(models.SurveySnapshot{
+		SurveyID: 1,
+		Questions: []models.Question{
+			{QuestionID: 1, Position: 1, QuestionType: QuestionTypeText, Mandatory: true, ValidationRules: &models.ValidationRules{Format: "email"}},
+			{QuestionID: 2, Position: 2, QuestionType: QuestionTypeNPS, Mandatory: true},
+			{QuestionID: 3, Position: 3, QuestionType: QuestionTypeText, Mandatory: true, ValidationRules: &models.ValidationRules{MaxLength: &five}},
+		},
+		// Promoters skip the follow-up question
+		BranchingRules: []models.BranchingRule{
+			{RuleID: 1, SourceQuestionID: 2, TargetQuestionID: 0, Condition: `{"op": "greater_than", "question_id": 2, "value": 8}`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := stubSessionRepo{sessions: map[uint]*models.SurveySession{
+		7: {SessionID: 7, SurveyID: 1, SurveyVersionID: 3},
+	}}
+	versions := stubVersionRepo{versions: map[uint]*models.SurveyVersion{
+		3: {VersionID: 3, SurveyID: 1, Snapshot: models.JSONContent(snapshot)},
+	}}
+	branching := NewBranchingService(nil, nil, nil, sessions, versions)
+	answers := NewAnswerService(nil, nil, sessions, branching, nil)
+
+	type answer struct {
+		questionID uint
+		data       string
+	}
+	tests := []struct {
+		name    string
+		answers []answer
+		want    []AnswerError
+	}{
+		{name: "promoter path", answers: []answer{{1, `"a@example.com"`}, {2, `9`}}},
+		{name: "detractor path", answers: []answer{{1, `"a@example.com"`}, {2, `3`}, {3, `"slow"`}}},
+		{
+			name:    "follow-up required on its path",
+			answers: []answer{{1, `"a@example.com"`}, {2, `3`}},
+			want:    []AnswerError{{QuestionID: 3, Message: "is required"}},
+		},
+		{
+			name:    "rules and types",
+			answers: []answer{{1, `"nope"`}, {2, `11`}, {3, `"far too long"`}},
+			want: []AnswerError{
+				{QuestionID: 1, Message: "must be a valid email"},
+				{QuestionID: 2, Message: "a score must be a whole number from 0 to 10"},
+				{QuestionID: 3, Message: "must be at most 5 characters"},
+			},
+		},
+		{
+			name:    "question not in the version",
+			answers: []answer{{1, `"a@example.com"`}, {2, `9`}, {4, `"x"`}},
+			want:    []AnswerError{{QuestionID: 4, Message: "is not a question of this survey"}},
+		},
+		{
+			name:    "nothing answered",
+			answers: nil,
+			want:    []AnswerError{{QuestionID: 1, Message: "is required"}, {QuestionID: 2, Message: "is required"}, {QuestionID: 3, Message: "is required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submitted := make([]models.Answer, 0, len(tt.answers))
+			for _, a := range tt.answers {
+				submitted = append(submitted, models.Answer{SessionID: 7, QuestionID: a.questionID, ResponseData: a.data})
+			}
+			err := answers.ValidateSubmission(context.Background(), 7, submitted)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateSubmission = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *SubmissionError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("ValidateSubmission = %v, want *SubmissionError", err)
+			}
+			got := make([]AnswerError, 0, len(invalid.Errors))
+			for _, e := range invalid.Errors {
+				got = append(got, *e)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateSubmission errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if err := answers.ValidateSubmission(context.Background(), 8, nil); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("ValidateSubmission for unknown session = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
 )
 
 type AnswerHandler struct {
@@ -23,6 +27,16 @@ type CreateAnswerRequest struct {
 	ResponseData string `json:"response_data" validate:"required"`
 }
 
+// ValidateSubmissionRequest carries a session's final answers, each response_data as the
+// JSON value the participant gave
+type ValidateSubmissionRequest struct {
+	SessionID uint `json:"session_id"`
+	Answers   []struct {
+		QuestionID   uint            `json:"question_id"`
+		ResponseData json.RawMessage `json:"response_data"`
+	} `json:"answers"`
+}
+
 type BulkAnswerRequest struct {
 	SessionID uint                  `json:"session_id" validate:"required"`
 	Answers   []CreateAnswerRequest `json:"answers" validate:"required,dive"`
@@ -41,12 +55,62 @@ func (h *AnswerHandler) CreateAnswer(c *fiber.Ctx) error {
 	}
 
 	if err := h.answerService.CreateAnswer(c.Context(), answer); err != nil {
+		var invalid *service.AnswerError
+		if errors.As(err, &invalid) {
+			return response.Error(c, invalid.Message, "INVALID_ANSWER", fiber.StatusBadRequest, []*service.AnswerError{invalid})
+		}
+		if errors.Is(err, service.ErrInvalidAnswer) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, service.ErrSandboxUnavailable) {
+			return response.Error(c, "Code answers cannot be evaluated right now", "SANDBOX_UNAVAILABLE", fiber.StatusServiceUnavailable, nil)
+		}
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return response.NotFound(c, "Session not found")
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Question not found")
+		}
 		return response.InternalServerError(c, "Failed to create answer: "+err.Error())
 	}
 
 	return response.Success(c, answer, "Answer created successfully", fiber.StatusCreated)
 }
 
+// ValidateSubmission checks a session's final answers without storing them; the
+// Participants service calls it before it stores a submission
+func (h *AnswerHandler) ValidateSubmission(c *fiber.Ctx) error {
+	var req ValidateSubmissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if req.SessionID == 0 {
+		return response.BadRequest(c, "session_id is required")
+	}
+
+	answers := make([]models.Answer, 0, len(req.Answers))
+	for _, a := range req.Answers {
+		data := string(a.ResponseData)
+		if data == "" {
+			data = "null"
+		}
+		answers = append(answers, models.Answer{SessionID: req.SessionID, QuestionID: a.QuestionID, ResponseData: data})
+	}
+
+	if err := h.answerService.ValidateSubmission(c.Context(), req.SessionID, answers); err != nil {
+		var invalid *service.SubmissionError
+		if errors.As(err, &invalid) {
+			return response.Error(c, "Some answers are invalid", "INVALID_ANSWER", fiber.StatusBadRequest, invalid.Errors)
+		}
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return response.NotFound(c, "Session not found")
+		}
+		return response.InternalServerError(c, "Failed to validate answers: "+err.Error())
+	}
+
+	return response.Success(c, nil, "Answers are valid")
+}
+
 func (h *AnswerHandler) GetAnswersBySession(c *fiber.Ctx) error {
 	sessionID, err := c.ParamsInt("session_id")
 	if err != nil {
@@ -75,6 +139,39 @@ func (h *AnswerHandler) GetAnswersByQuestion(c *fiber.Ctx) error {
 	return response.Success(c, answers, "Answers retrieved successfully")
 }
 
+func (h *AnswerHandler) GetAnswersAcrossVersions(c *fiber.Ctx) error {
+	questionID, err := c.ParamsInt("question_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid question ID")
+	}
+
+	answers, err := h.answerService.GetAnswersAcrossVersions(c.Context(), uint(questionID))
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get answers: "+err.Error())
+	}
+
+	return response.Success(c, answers, "Answers retrieved successfully")
+}
+
+// GetQuestionResults summarises the answers to a question, e.g. per-row column counts
+// for a MATRIX question
+func (h *AnswerHandler) GetQuestionResults(c *fiber.Ctx) error {
+	questionID, err := c.ParamsInt("question_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid question ID")
+	}
+
+	results, err := h.answerService.GetQuestionResults(c.Context(), uint(questionID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Question not found")
+		}
+		return response.InternalServerError(c, "Failed to get results: "+err.Error())
+	}
+
+	return response.Success(c, results, "Results retrieved successfully")
+}
+
 func (h *AnswerHandler) SubmitBulkAnswers(c *fiber.Ctx) error {
 	var req BulkAnswerRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+)
+
+type BranchingHandler struct {
+	branchingService *service.BranchingService
+}
+
+func NewBranchingHandler(branchingService *service.BranchingService) *BranchingHandler {
+	return &BranchingHandler{
+		branchingService: branchingService,
+	}
+}
+
+type SetBranchingLogicRequest struct {
+	Rules []service.BranchingRule `json:"rules"`
+}
+
+type NextQuestionRequest struct {
+	// SessionID, if set, evaluates the rules of the survey version the session is pinned to
+	SessionID         uint                   `json:"session_id"`
+	CurrentQuestionID uint                   `json:"current_question_id"`
+	Answers           map[string]interface{} `json:"answers"`
+}
+
+type SimulateRequest struct {
+	Answers map[string]interface{} `json:"answers"`
+}
+
+func (h *BranchingHandler) SetBranchingLogic(c *fiber.Ctx) error {
+	questionID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid question ID")
+	}
+
+	var req SetBranchingLogicRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if err := h.branchingService.SetBranchingLogic(c.Context(), uint(questionID), req.Rules); err != nil {
+		if errors.Is(err, service.ErrInvalidCondition) || errors.Is(err, service.ErrInvalidQuestionKey) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to set branching logic: "+err.Error())
+	}
+
+	return response.Success(c, nil, "Branching logic saved successfully")
+}
+
+func (h *BranchingHandler) GetBranchingRules(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	rules, err := h.branchingService.GetRules(c.Context(), uint(surveyID))
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get branching rules: "+err.Error())
+	}
+
+	return response.Success(c, rules, "Branching rules retrieved successfully")
+}
+
+func (h *BranchingHandler) NextQuestion(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req NextQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	result, err := h.branchingService.NextQuestion(c.Context(), uint(surveyID), req.SessionID, service.SessionAnswersFromDraft(req.Answers), req.CurrentQuestionID)
+	if err != nil {
+		if errors.Is(err, service.ErrQuestionNotInSurvey) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return response.NotFound(c, "Session not found")
+		}
+		return response.InternalServerError(c, "Failed to evaluate branching: "+err.Error())
+	}
+
+	return response.Success(c, result, "Next question evaluated successfully")
+}
+
+func (h *BranchingHandler) ValidateBranching(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	report, err := h.branchingService.ValidateSurvey(c.Context(), uint(surveyID))
+	if err != nil {
+		return response.InternalServerError(c, "Failed to validate branching: "+err.Error())
+	}
+
+	return response.Success(c, report, "Branching validated successfully")
+}
+
+func (h *BranchingHandler) Simulate(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req SimulateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	result, err := h.branchingService.Simulate(c.Context(), uint(surveyID), service.SessionAnswersFromDraft(req.Answers))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCondition) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to simulate survey: "+err.Error())
+	}
+
+	return response.Success(c, result, "Survey simulated successfully")
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
+)
+
+const (
+	collabPingInterval = 30 * time.Second
+	collabReadTimeout  = 75 * time.Second // Longer than the ping interval, so a live peer always answers in time
+	collabWriteTimeout = 10 * time.Second
+)
+
+type CollaborationHandler struct {
+	surveyService service.SurveyService
+	hub           *service.CollaborationHub
+}
+
+func NewCollaborationHandler(surveyService service.SurveyService, hub *service.CollaborationHub) *CollaborationHandler {
+	return &CollaborationHandler{
+		surveyService: surveyService,
+		hub:           hub,
+	}
+}
+
+// UpgradeDraftChannel checks a request for a draft's collaboration channel before it is
+// upgraded to a WebSocket, so a missing draft is an ordinary 404
+func (h *CollaborationHandler) UpgradeDraftChannel(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return response.Error(c, "WebSocket upgrade required", "UPGRADE_REQUIRED", http.StatusUpgradeRequired, nil)
+	}
+
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+	if _, err := h.surveyService.GetDraft(c.Context(), uint(draftID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft not found")
+		}
+		return response.InternalServerError(c, "Failed to get draft: "+err.Error())
+	}
+
+	c.Locals("draft_id", uint(draftID))
+	return c.Next()
+}
+
+// DraftChannel serves one connection to a draft's collaboration channel. Messages are
+// JSON service.CollabMessage values in both directions.
+func (h *CollaborationHandler) DraftChannel(conn *websocket.Conn) {
+	draftID, _ := conn.Locals("draft_id").(uint)
+	userID, _ := conn.Locals("user_id").(uint)
+	ctx := context.Background()
+
+	client, err := h.hub.Join(ctx, draftID, userID)
+	if err != nil {
+		_ = conn.WriteJSON(service.CollabMessage{Type: service.CollabError, Code: service.CollabCodeInternal, Message: "Failed to join draft: " + err.Error()})
+		return
+	}
+	log.Printf("User %d joined draft %d as %s", userID, draftID, client.ID)
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		writeCollabMessages(conn, client)
+	}()
+
+	_ = conn.SetReadDeadline(time.Now().Add(collabReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabReadTimeout))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var msg service.CollabMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			h.hub.Send(client, service.CollabMessage{Type: service.CollabError, Code: service.CollabCodeBadMessage, Message: "Message is not valid JSON"})
+			continue
+		}
+		h.hub.Handle(ctx, client, msg)
+	}
+
+	// Leaving closes the outbox, which ends the writer
+	h.hub.Leave(client)
+	<-written
+	log.Printf("User %d left draft %d (%s)", userID, draftID, client.ID)
+}
+
+// writeCollabMessages writes the client's outbox to the connection and keeps it alive
+// with pings. It is the only writer of the connection.
+func writeCollabMessages(conn *websocket.Conn, client *service.CollabClient) {
+	ticker := time.NewTicker(collabPingInterval)
+	defer ticker.Stop()
+	defer conn.Close()
+
+	for {
+		select {
+		case msg, ok := <-client.Outbox():
+			_ = conn.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+// draftETag is the entity tag of a draft at the given revision
+func draftETag(revision int) string {
+	return `"` + strconv.Itoa(revision) + `"`
+}
+
+// ifMatchRevision reads the draft revision a write is based on from If-Match. present is
+// false when the header is missing; "*" yields service.AnyRevision. Weak tags are accepted.
+func ifMatchRevision(c *fiber.Ctx) (revision int, present bool, err error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, false, nil
+	}
+	if header == "*" {
+		return service.AnyRevision, true, nil
+	}
+
+	// A list of tags can only be satisfied by one revision; use the first
+	tag := strings.TrimSpace(strings.Split(header, ",")[0])
+	tag = strings.TrimPrefix(tag, "W/")
+	tag = strings.Trim(tag, `"`)
+	revision, err = strconv.Atoi(tag)
+	if err != nil || revision < 1 {
+		return 0, true, errInvalidIfMatch
+	}
+	return revision, true, nil
+}
+
+func ifMatchRequired(c *fiber.Ctx) error {
+	return response.Error(c, "If-Match header with the draft's ETag is required", "PRECONDITION_REQUIRED", http.StatusPreconditionRequired, nil)
+}
+
+// draftConflict answers a write based on a stale revision with 409 and the current ETag
+func draftConflict(c *fiber.Ctx, conflict *service.DraftConflictError) error {
+	c.Set(fiber.HeaderETag, draftETag(conflict.Current))
+	return response.Error(c, conflict.Error(), "DRAFT_REVISION_CONFLICT", http.StatusConflict, fiber.Map{
+		"current_revision": conflict.Current,
+	})
+}
+
+// questionLocked refuses a write that changes a question another editor has locked
+func questionLocked(c *fiber.Ctx, locked *service.QuestionLockedError) error {
+	return response.Error(c, locked.Error(), service.CollabCodeLocked, http.StatusConflict, fiber.Map{
+		"question_id": locked.Lock.QuestionID,
+		"user_id":     locked.Lock.UserID,
+	})
+}
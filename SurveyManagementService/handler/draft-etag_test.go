@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+)
+
+func TestIfMatchRevision(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantRev     int
+		wantPresent bool
+		wantErr     bool
+	}{
+		{name: "missing", header: ""},
+		{name: "strong tag", header: `"4"`, wantRev: 4, wantPresent: true},
+		{name: "weak tag", header: `W/"4"`, wantRev: 4, wantPresent: true},
+		{name: "first of a list", header: `"4", "5"`, wantRev: 4, wantPresent: true},
+		{name: "any", header: "*", wantRev: service.AnyRevision, wantPresent: true},
+		{name: "not a number", header: `"abc"`, wantPresent: true, wantErr: true},
+		{name: "zero", header: `"0"`, wantPresent: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				revision int
+				present  bool
+				err      error
+			)
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				revision, present, err = ifMatchRevision(c)
+				return nil
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.header)
+			}
+			if _, testErr := app.Test(req); testErr != nil {
+				t.Fatalf("app.Test: %v", testErr)
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ifMatchRevision error = %v, want error %v", err, tt.wantErr)
+			}
+			if present != tt.wantPresent {
+				t.Errorf("present = %v, want %v", present, tt.wantPresent)
+			}
+			if !tt.wantErr && revision != tt.wantRev {
+				t.Errorf("revision = %d, want %d", revision, tt.wantRev)
+			}
+		})
+	}
+}
+
+func TestDraftETagRoundTrip(t *testing.T) {
+	for _, revision := range []int{1, 7, 120} {
+		app := fiber.New()
+		var got int
+		app.Get("/", func(c *fiber.Ctx) error {
+			got, _, _ = ifMatchRevision(c)
+			return nil
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(fiber.HeaderIfMatch, draftETag(revision))
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		if got != revision {
+			t.Errorf("If-Match %s read as revision %d, want %d", draftETag(revision), got, revision)
+		}
+	}
+}
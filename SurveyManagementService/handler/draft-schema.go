@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+)
+
+// GetDraftSchema serves the JSON Schema draft content is validated against
+func (h *SurveyHandler) GetDraftSchema(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "application/schema+json")
+	return c.Send(service.DraftSchema())
+}
+
+// draftInvalid answers a draft whose content breaks the draft schema with 422 and the
+// path of every offending field
+func draftInvalid(c *fiber.Ctx, invalid *service.DraftValidationError) error {
+	return response.ValidationError(c, fiber.Map{
+		"schema": service.DraftSchemaID,
+		"errors": invalid.Errors,
+	})
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
 )
 
 type OptionHandler struct {
@@ -20,12 +23,18 @@ func NewOptionHandler(optionService service.OptionService) *OptionHandler {
 type CreateOptionRequest struct {
 	QuestionID uint   `json:"question_id" validate:"required"`
 	OptionText string `json:"option_text" validate:"required"`
+	MediaID    *uint  `json:"media_id"` // Optional; required for IMAGE_RANKING questions
+	PinLast    bool   `json:"pin_last"` // Kept at the end when the question's options are shuffled
 }
 
 type BatchCreateOptionsRequest struct {
 	Options []CreateOptionRequest `json:"options" validate:"required,dive"`
 }
 
+type ReorderOptionsRequest struct {
+	OptionIDs []uint `json:"option_ids" validate:"required"` // Every option of the question, in the new order
+}
+
 func (h *OptionHandler) CreateOption(c *fiber.Ctx) error {
 	var req CreateOptionRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -35,9 +44,14 @@ func (h *OptionHandler) CreateOption(c *fiber.Ctx) error {
 	option := &models.Option{
 		QuestionID: req.QuestionID,
 		OptionText: req.OptionText,
+		MediaID:    req.MediaID,
+		PinLast:    req.PinLast,
 	}
 
 	if err := h.optionService.CreateOption(c.Context(), option); err != nil {
+		if errors.Is(err, service.ErrInvalidQuestionConfig) {
+			return response.BadRequest(c, err.Error())
+		}
 		return response.InternalServerError(c, "Failed to create option: "+err.Error())
 	}
 
@@ -87,9 +101,14 @@ func (h *OptionHandler) UpdateOption(c *fiber.Ctx) error {
 		OptionID:   uint(optionID),
 		QuestionID: req.QuestionID,
 		OptionText: req.OptionText,
+		MediaID:    req.MediaID,
+		PinLast:    req.PinLast,
 	}
 
 	if err := h.optionService.UpdateOption(c.Context(), option); err != nil {
+		if errors.Is(err, service.ErrInvalidQuestionConfig) {
+			return response.BadRequest(c, err.Error())
+		}
 		return response.InternalServerError(c, "Failed to update option: "+err.Error())
 	}
 
@@ -125,12 +144,42 @@ func (h *OptionHandler) BatchCreateOptions(c *fiber.Ctx) error {
 		options[i] = models.Option{
 			QuestionID: opt.QuestionID,
 			OptionText: opt.OptionText,
+			MediaID:    opt.MediaID,
+			PinLast:    opt.PinLast,
 		}
 	}
 
 	if err := h.optionService.BatchCreateOptions(c.Context(), options); err != nil {
+		if errors.Is(err, service.ErrInvalidQuestionConfig) {
+			return response.BadRequest(c, err.Error())
+		}
 		return response.InternalServerError(c, "Failed to create options: "+err.Error())
 	}
 
 	return response.Success(c, options, "Options created successfully", fiber.StatusCreated)
 }
+
+func (h *OptionHandler) ReorderOptions(c *fiber.Ctx) error {
+	questionID, err := c.ParamsInt("question_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid question ID")
+	}
+
+	var req ReorderOptionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	options, err := h.optionService.ReorderOptions(c.Context(), uint(questionID), req.OptionIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLayout) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Question not found")
+		}
+		return response.InternalServerError(c, "Failed to reorder options: "+err.Error())
+	}
+
+	return response.Success(c, options, "Options reordered successfully")
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/repository"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
+)
+
+type QuestionBankHandler struct {
+	bankService   service.QuestionBankService
+	collaboration *service.CollaborationHub
+}
+
+func NewQuestionBankHandler(bankService service.QuestionBankService, collaboration *service.CollaborationHub) *QuestionBankHandler {
+	return &QuestionBankHandler{
+		bankService:   bankService,
+		collaboration: collaboration,
+	}
+}
+
+type InsertBankQuestionRequest struct {
+	BankQuestionID uint  `json:"bank_question_id"`
+	PageID         *uint `json:"page_id"` // Page of the survey to show the question on, if any
+}
+
+// bankError answers the errors the question bank shares between its endpoints
+func bankError(c *fiber.Ctx, err error, action string) error {
+	var invalid *service.DraftValidationError
+	if errors.As(err, &invalid) {
+		return draftInvalid(c, invalid)
+	}
+	if errors.Is(err, service.ErrInvalidBankQuestion) || errors.Is(err, service.ErrInvalidTemplateScope) {
+		return response.BadRequest(c, err.Error())
+	}
+	if errors.Is(err, service.ErrBankQuestionNotAccessible) {
+		return response.Forbidden(c, err.Error())
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response.NotFound(c, "Bank question not found")
+	}
+	return response.InternalServerError(c, "Failed to "+action+": "+err.Error())
+}
+
+// SaveQuestion adds a question to the bank, from a survey question (question_id) or
+// from content in the draft format
+func (h *QuestionBankHandler) SaveQuestion(c *fiber.Ctx) error {
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	var req service.BankQuestionInput
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	req.ConductorID = conductorID
+
+	question, err := h.bankService.SaveQuestion(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Question not found")
+		}
+		return bankError(c, err, "save bank question")
+	}
+
+	return response.Success(c, question, "Question saved to bank successfully", fiber.StatusCreated)
+}
+
+// SearchQuestions lists the conductor's personal bank questions and all organisation
+// ones, filtered by ?scope=, ?tags=a,b (all must match), ?type= and ?q=
+func (h *QuestionBankHandler) SearchQuestions(c *fiber.Ctx) error {
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	filter := repository.BankQuestionFilter{
+		ConductorID:  conductorID,
+		Scope:        c.Query("scope"),
+		QuestionType: c.Query("type"),
+		Search:       c.Query("q"),
+		Limit:        c.QueryInt("limit"),
+		Offset:       c.QueryInt("offset"),
+	}
+	if tags := c.Query("tags"); tags != "" {
+		filter.Tags = strings.Split(tags, ",")
+	}
+
+	questions, err := h.bankService.SearchQuestions(c.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTemplateScope) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to search bank questions: "+err.Error())
+	}
+
+	return response.Success(c, questions, "Bank questions retrieved successfully")
+}
+
+func (h *QuestionBankHandler) GetQuestion(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid bank question ID")
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	question, err := h.bankService.GetQuestion(c.Context(), uint(id), conductorID)
+	if err != nil {
+		return bankError(c, err, "get bank question")
+	}
+
+	return response.Success(c, question, "Bank question retrieved successfully")
+}
+
+// UpdateQuestion changes a bank question's scope, tags and, when given, content
+func (h *QuestionBankHandler) UpdateQuestion(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid bank question ID")
+	}
+
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	var req service.BankQuestionInput
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	req.ConductorID = conductorID
+
+	question, err := h.bankService.UpdateQuestion(c.Context(), uint(id), req)
+	if err != nil {
+		return bankError(c, err, "update bank question")
+	}
+
+	return response.Success(c, question, "Bank question updated successfully")
+}
+
+func (h *QuestionBankHandler) DeleteQuestion(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid bank question ID")
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	if err := h.bankService.DeleteQuestion(c.Context(), uint(id), conductorID); err != nil {
+		return bankError(c, err, "delete bank question")
+	}
+
+	return response.Success(c, nil, "Bank question deleted successfully")
+}
+
+// CompareResults returns the results of every published question inserted from a bank
+// question, so answers to the same standard question can be compared across surveys
+func (h *QuestionBankHandler) CompareResults(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid bank question ID")
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	results, err := h.bankService.CompareResults(c.Context(), uint(id), conductorID)
+	if err != nil {
+		return bankError(c, err, "compare bank question results")
+	}
+
+	return response.Success(c, results, "Bank question results retrieved successfully")
+}
+
+// InsertIntoDraft appends a bank question to a draft. If-Match is optional: like a
+// restore, inserting is an explicit choice rather than an autosave.
+func (h *QuestionBankHandler) InsertIntoDraft(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+
+	revision, present, err := ifMatchRevision(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	if !present {
+		revision = service.AnyRevision
+	}
+
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	var req InsertBankQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+	if req.BankQuestionID == 0 {
+		return response.BadRequest(c, "bank_question_id is required")
+	}
+
+	result, err := h.bankService.InsertIntoDraft(c.Context(), req.BankQuestionID, conductorID, uint(draftID), req.PageID, conductorID, revision)
+	if err != nil {
+		var conflict *service.DraftConflictError
+		if errors.As(err, &conflict) {
+			return draftConflict(c, conflict)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft or bank question not found")
+		}
+		return bankError(c, err, "insert bank question")
+	}
+
+	h.collaboration.DraftSaved(result.Draft, conductorID)
+	c.Set(fiber.HeaderETag, draftETag(result.Draft.Revision))
+	return response.Success(c, fiber.Map{
+		"draftId":    result.Draft.DraftID,
+		"revision":   result.Draft.Revision,
+		"lastSaved":  result.Draft.LastSaved,
+		"questionId": result.QuestionID,
+	}, "Bank question inserted successfully", fiber.StatusCreated)
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
 )
 
 type QuestionHandler struct {
@@ -18,13 +21,29 @@ func NewQuestionHandler(questionService service.QuestionService) *QuestionHandle
 }
 
 type CreateQuestionRequest struct {
-	SurveyID       uint            `json:"survey_id" validate:"required"`
-	QuestionText   string          `json:"question_text" validate:"required"`
-	QuestionType   string          `json:"question_type" validate:"required"`
-	Mandatory      bool            `json:"mandatory"`
-	BranchingLogic string          `json:"branching_logic"`
-	CorrectAnswers string          `json:"correct_answers"`
-	Options        []models.Option `json:"options"`
+	SurveyID       uint                  `json:"survey_id" validate:"required"`
+	QuestionKey    string                `json:"question_key"` // Optional; generated when empty
+	QuestionText   string                `json:"question_text" validate:"required"`
+	QuestionType   string                `json:"question_type" validate:"required"`
+	Mandatory      bool                  `json:"mandatory"`
+	BranchingLogic string                `json:"branching_logic"`
+	CorrectAnswers string                `json:"correct_answers"`
+	Options        []models.Option       `json:"options"`
+	MatrixRows     []models.MatrixRow    `json:"matrix_rows"`    // MATRIX only
+	MatrixColumns  []models.MatrixColumn `json:"matrix_columns"` // MATRIX only
+	MatrixMulti    bool                  `json:"matrix_multi"`   // MATRIX only; several columns per row
+	CodeLanguage   string                `json:"code_language"`  // CODE only
+	StarterCode    string                `json:"starter_code"`   // CODE only
+	CodeTests      []models.CodeTest     `json:"code_tests"`     // CODE only
+
+	// min_value, max_value, step and unit of NUMBER and SLIDER; earliest and latest of
+	// DATE, TIME and DATETIME
+	models.QuestionConstraints
+	ValidationRules *models.ValidationRules `json:"validation_rules"`
+	PageID          *uint                   `json:"page_id"` // Optional, create only; the survey layout moves questions
+
+	// Shuffle the options for each participant
+	RandomizeOptions bool `json:"randomize_options"`
 }
 
 func (h *QuestionHandler) CreateQuestion(c *fiber.Ctx) error {
@@ -36,22 +55,40 @@ func (h *QuestionHandler) CreateQuestion(c *fiber.Ctx) error {
 	// Create a new question model from the request
 	question := &models.Question{
 		SurveyID:       req.SurveyID,
+		QuestionKey:    req.QuestionKey,
 		QuestionText:   req.QuestionText,
 		QuestionType:   req.QuestionType,
 		Mandatory:      req.Mandatory,
 		BranchingLogic: req.BranchingLogic,
 		CorrectAnswers: req.CorrectAnswers,
+		MatrixRows:     req.MatrixRows,
+		MatrixColumns:  req.MatrixColumns,
+		MatrixMulti:    req.MatrixMulti,
+		CodeLanguage:   req.CodeLanguage,
+		StarterCode:    req.StarterCode,
+		CodeTests:      req.CodeTests,
+
+		QuestionConstraints: req.QuestionConstraints,
+		ValidationRules:     req.ValidationRules,
+		RandomizeOptions:    req.RandomizeOptions,
+		PageID:              req.PageID,
 	}
 
-	// If we have options and the question type is multiple choice or single choice
+	// If we have options and the question type is answered from them (choice or ranking)
 	// use the CreateQuestionWithOptions method
-	if (req.QuestionType == "MULTIPLE_CHOICE" || req.QuestionType == "SINGLE_CHOICE") && len(req.Options) > 0 {
+	if service.TakesOptions(req.QuestionType) && len(req.Options) > 0 {
 		if err := h.questionService.CreateQuestionWithOptions(c.Context(), question, req.Options); err != nil {
+			if errors.Is(err, service.ErrInvalidQuestionKey) || errors.Is(err, service.ErrInvalidQuestionConfig) || errors.Is(err, service.ErrInvalidLayout) {
+				return response.BadRequest(c, err.Error())
+			}
 			return response.InternalServerError(c, "Failed to create question with options: "+err.Error())
 		}
 	} else {
 		// Otherwise just create the question
 		if err := h.questionService.CreateQuestion(c.Context(), question); err != nil {
+			if errors.Is(err, service.ErrInvalidQuestionKey) || errors.Is(err, service.ErrInvalidQuestionConfig) || errors.Is(err, service.ErrInvalidLayout) {
+				return response.BadRequest(c, err.Error())
+			}
 			return response.InternalServerError(c, "Failed to create question: "+err.Error())
 		}
 	}
@@ -69,6 +106,7 @@ func (h *QuestionHandler) GetQuestion(c *fiber.Ctx) error {
 	if err != nil {
 		return response.InternalServerError(c, "Failed to get question: "+err.Error())
 	}
+	hideCodeTests(c, question)
 
 	return response.Success(c, question, "Question retrieved successfully")
 }
@@ -83,10 +121,60 @@ func (h *QuestionHandler) GetQuestionsBySurvey(c *fiber.Ctx) error {
 	if err != nil {
 		return response.InternalServerError(c, "Failed to get questions: "+err.Error())
 	}
+	for i := range questions {
+		hideCodeTests(c, &questions[i])
+	}
 
 	return response.Success(c, questions, "Questions retrieved successfully")
 }
 
+// GetQuestionByKey looks up a survey's current question by its stable key
+func (h *QuestionHandler) GetQuestionByKey(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("survey_id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	question, err := h.questionService.GetQuestionByKey(c.Context(), uint(surveyID), c.Params("key"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuestionKey) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Question not found")
+		}
+		return response.InternalServerError(c, "Failed to get question: "+err.Error())
+	}
+	hideCodeTests(c, question)
+
+	return response.Success(c, question, "Question retrieved successfully")
+}
+
+// hideCodeTests removes a CODE question's hidden tests unless the caller conducts
+// surveys, so participants only see the sample tests
+func hideCodeTests(c *fiber.Ctx, question *models.Question) {
+	if len(question.CodeTests) == 0 || isConductor(c) {
+		return
+	}
+	visible := question.CodeTests[:0]
+	for _, test := range question.CodeTests {
+		if !test.Hidden {
+			visible = append(visible, test)
+		}
+	}
+	question.CodeTests = visible
+}
+
+func isConductor(c *fiber.Ctx) bool {
+	roles, _ := c.Locals("roles").([]string)
+	for _, role := range roles {
+		if role == "Conducting" {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *QuestionHandler) UpdateQuestion(c *fiber.Ctx) error {
 	questionID, err := c.ParamsInt("id")
 	if err != nil {
@@ -101,14 +189,28 @@ func (h *QuestionHandler) UpdateQuestion(c *fiber.Ctx) error {
 	question := &models.Question{
 		QuestionID:     uint(questionID),
 		SurveyID:       req.SurveyID,
+		QuestionKey:    req.QuestionKey,
 		QuestionText:   req.QuestionText,
 		QuestionType:   req.QuestionType,
 		Mandatory:      req.Mandatory,
 		BranchingLogic: req.BranchingLogic,
 		CorrectAnswers: req.CorrectAnswers,
+		MatrixRows:     req.MatrixRows,
+		MatrixColumns:  req.MatrixColumns,
+		MatrixMulti:    req.MatrixMulti,
+		CodeLanguage:   req.CodeLanguage,
+		StarterCode:    req.StarterCode,
+		CodeTests:      req.CodeTests,
+
+		QuestionConstraints: req.QuestionConstraints,
+		ValidationRules:     req.ValidationRules,
+		RandomizeOptions:    req.RandomizeOptions,
 	}
 
 	if err := h.questionService.UpdateQuestion(c.Context(), question); err != nil {
+		if errors.Is(err, service.ErrInvalidQuestionKey) || errors.Is(err, service.ErrInvalidQuestionConfig) {
+			return response.BadRequest(c, err.Error())
+		}
 		return response.InternalServerError(c, "Failed to update question: "+err.Error())
 	}
 
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
+)
+
+type SectionHandler struct {
+	sectionService service.SectionService
+}
+
+func NewSectionHandler(sectionService service.SectionService) *SectionHandler {
+	return &SectionHandler{
+		sectionService: sectionService,
+	}
+}
+
+type SectionRequest struct {
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	RandomizeQuestions bool   `json:"randomize_questions"` // Shuffle questions within each page for each participant
+}
+
+type PageRequest struct {
+	Title string `json:"title"`
+}
+
+func (h *SectionHandler) CreateSection(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req SectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	section := &models.Section{
+		SurveyID:    uint(surveyID),
+		Title:       req.Title,
+		Description: req.Description,
+
+		RandomizeQuestions: req.RandomizeQuestions,
+	}
+	if err := h.sectionService.CreateSection(c.Context(), section); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		return response.InternalServerError(c, "Failed to create section: "+err.Error())
+	}
+
+	return response.Success(c, section, "Section created successfully", fiber.StatusCreated)
+}
+
+func (h *SectionHandler) GetSection(c *fiber.Ctx) error {
+	sectionID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid section ID")
+	}
+
+	section, err := h.sectionService.GetSection(c.Context(), uint(sectionID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Section not found")
+		}
+		return response.InternalServerError(c, "Failed to get section: "+err.Error())
+	}
+
+	return response.Success(c, section, "Section retrieved successfully")
+}
+
+func (h *SectionHandler) UpdateSection(c *fiber.Ctx) error {
+	sectionID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid section ID")
+	}
+
+	var req SectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	section := &models.Section{
+		SectionID:   uint(sectionID),
+		Title:       req.Title,
+		Description: req.Description,
+
+		RandomizeQuestions: req.RandomizeQuestions,
+	}
+	if err := h.sectionService.UpdateSection(c.Context(), section); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Section not found")
+		}
+		return response.InternalServerError(c, "Failed to update section: "+err.Error())
+	}
+
+	return response.Success(c, section, "Section updated successfully")
+}
+
+func (h *SectionHandler) DeleteSection(c *fiber.Ctx) error {
+	sectionID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid section ID")
+	}
+
+	if err := h.sectionService.DeleteSection(c.Context(), uint(sectionID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Section not found")
+		}
+		return response.InternalServerError(c, "Failed to delete section: "+err.Error())
+	}
+
+	return response.Success(c, nil, "Section deleted successfully")
+}
+
+func (h *SectionHandler) CreatePage(c *fiber.Ctx) error {
+	sectionID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid section ID")
+	}
+
+	var req PageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	page := &models.Page{
+		SectionID: uint(sectionID),
+		Title:     req.Title,
+	}
+	if err := h.sectionService.CreatePage(c.Context(), page); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Section not found")
+		}
+		return response.InternalServerError(c, "Failed to create page: "+err.Error())
+	}
+
+	return response.Success(c, page, "Page created successfully", fiber.StatusCreated)
+}
+
+func (h *SectionHandler) UpdatePage(c *fiber.Ctx) error {
+	pageID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid page ID")
+	}
+
+	var req PageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	page := &models.Page{
+		PageID: uint(pageID),
+		Title:  req.Title,
+	}
+	if err := h.sectionService.UpdatePage(c.Context(), page); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Page not found")
+		}
+		return response.InternalServerError(c, "Failed to update page: "+err.Error())
+	}
+
+	return response.Success(c, page, "Page updated successfully")
+}
+
+func (h *SectionHandler) DeletePage(c *fiber.Ctx) error {
+	pageID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid page ID")
+	}
+
+	if err := h.sectionService.DeletePage(c.Context(), uint(pageID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Page not found")
+		}
+		return response.InternalServerError(c, "Failed to delete page: "+err.Error())
+	}
+
+	return response.Success(c, nil, "Page deleted successfully")
+}
+
+func (h *SectionHandler) GetLayout(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	layout, err := h.sectionService.GetLayout(c.Context(), uint(surveyID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		return response.InternalServerError(c, "Failed to get survey layout: "+err.Error())
+	}
+
+	return response.Success(c, layout, "Survey layout retrieved successfully")
+}
+
+// SetLayout reorders a survey's sections, pages and questions and moves questions
+// between pages in one step
+func (h *SectionHandler) SetLayout(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req service.SurveyLayout
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	layout, err := h.sectionService.SetLayout(c.Context(), uint(surveyID), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLayout) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		return response.InternalServerError(c, "Failed to save survey layout: "+err.Error())
+	}
+
+	return response.Success(c, layout, "Survey layout saved successfully")
+}
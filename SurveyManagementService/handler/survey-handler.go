@@ -1,22 +1,29 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/models"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
 	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
 )
 
 type SurveyHandler struct {
 	surveyService service.SurveyService
+	collaboration *service.CollaborationHub // Told about draft saves, so live editors stay in sync
 }
 
-func NewSurveyHandler(surveyService service.SurveyService) *SurveyHandler {
+func NewSurveyHandler(surveyService service.SurveyService, collaboration *service.CollaborationHub) *SurveyHandler {
 	return &SurveyHandler{
 		surveyService: surveyService,
+		collaboration: collaboration,
 	}
 }
 
@@ -28,6 +35,23 @@ type CreateSurveyRequest struct {
 }
 
 
+type ChangeStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type ScheduleRequest struct {
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+type CloneSurveyRequest struct {
+	Title string `json:"title"`
+}
+
+type TemplateRequest struct {
+	Scope string `json:"scope"` // PERSONAL, ORGANISATION or empty to withdraw
+}
+
 type CreateDraftRequest struct {
 	SurveyID           uint               `json:"survey_id"`
 	DraftContent       models.JSONContent `json:"draft_content"`
@@ -43,6 +67,16 @@ func (h *SurveyHandler) PublishSurvey(c *fiber.Ctx) error {
 
 	err = h.surveyService.PublishSurvey(c.Context(), uint(surveyID))
 	if err != nil {
+		var validationErr *service.BranchingValidationError
+		if errors.As(err, &validationErr) {
+			return response.ValidationError(c, validationErr.Report)
+		}
+		if errors.Is(err, service.ErrInvalidStatusTransition) {
+			return statusConflict(c, err)
+		}
+		if errors.Is(err, service.ErrInvalidSchedule) {
+			return response.BadRequest(c, err.Error())
+		}
 		return response.InternalServerError(c, "Failed to publish survey")
 	}
 
@@ -83,17 +117,21 @@ func (h *SurveyHandler) CreateDraft(c *fiber.Ctx) error {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	// Log the request in a readable format
-	draftContentJSON, _ := json.MarshalIndent(json.RawMessage(req.DraftContent), "", "  ")
-	log.Printf("Creating draft with content: %s", string(draftContentJSON))
+	log.Printf("Creating draft for survey %d (%d bytes)", req.SurveyID, len(req.DraftContent))
 
-	draft, err := h.surveyService.CreateDraft(c.Context(), req.SurveyID, req.DraftContent, req.LastEditedQuestion)
+	draft, err := h.surveyService.CreateDraft(c.Context(), req.SurveyID, req.DraftContent, req.LastEditedQuestion, currentUserID(c))
 	if err != nil {
+		var invalid *service.DraftValidationError
+		if errors.As(err, &invalid) {
+			return draftInvalid(c, invalid)
+		}
 		return response.InternalServerError(c, "Failed to save draft")
 	}
 
+	c.Set(fiber.HeaderETag, draftETag(draft.Revision))
 	return response.Success(c, fiber.Map{
 		"draftId":   draft.DraftID,
+		"revision":  draft.Revision,
 		"lastSaved": draft.LastSaved,
 	}, "Draft saved successfully", fiber.StatusCreated)
 }
@@ -105,25 +143,137 @@ func (h *SurveyHandler) UpdateDraft(c *fiber.Ctx) error {
 		return response.BadRequest(c, "Invalid draft ID")
 	}
 
+	// The save must be based on the draft's current revision
+	revision, present, err := ifMatchRevision(c)
+	if !present {
+		return ifMatchRequired(c)
+	}
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
 	// Parse the request body
 	var req CreateDraftRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "Invalid request body")
 	}
 
-	// Log the request in a readable format
-	draftContentJSON, _ := json.MarshalIndent(json.RawMessage(req.DraftContent), "", "  ")
-	log.Printf("Updating draft %d with content: %s", draftID, string(draftContentJSON))
+	log.Printf("Updating draft %d (%d bytes)", draftID, len(req.DraftContent))
 
-	// Update the draft using service
-	draft, err := h.surveyService.UpdateDraft(c.Context(), uint(draftID), req.DraftContent, req.LastEditedQuestion)
+	// Update the draft using service, unless it changes a question someone else has locked
+	var draft *models.SurveyDraft
+	changed := func(current *models.SurveyDraft) ([]uint, error) {
+		return service.ChangedQuestions(current.DraftContent, req.DraftContent)
+	}
+	err = h.collaboration.GuardWrite(c.Context(), uint(draftID), currentUserID(c), changed, func() (err error) {
+		draft, err = h.surveyService.UpdateDraft(c.Context(), uint(draftID), req.DraftContent, req.LastEditedQuestion, currentUserID(c), revision)
+		return err
+	})
 	if err != nil {
+		var locked *service.QuestionLockedError
+		if errors.As(err, &locked) {
+			return questionLocked(c, locked)
+		}
+		var conflict *service.DraftConflictError
+		if errors.As(err, &conflict) {
+			return draftConflict(c, conflict)
+		}
+		var invalid *service.DraftValidationError
+		if errors.As(err, &invalid) {
+			return draftInvalid(c, invalid)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft not found")
+		}
 		return response.InternalServerError(c, "Failed to update draft")
 	}
 
+	h.collaboration.DraftSaved(draft, currentUserID(c))
+
 	// Return the updated draft
+	c.Set(fiber.HeaderETag, draftETag(draft.Revision))
+	return response.Success(c, fiber.Map{
+		"draftId":   draft.DraftID,
+		"revision":  draft.Revision,
+		"lastSaved": draft.LastSaved,
+	}, "Draft updated successfully")
+}
+
+// PatchDraft applies a JSON Patch (application/json-patch+json) or JSON Merge Patch
+// (application/merge-patch+json) to the draft's content, so autosaves can send only
+// what changed. Like PUT, it requires If-Match.
+func (h *SurveyHandler) PatchDraft(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+
+	var format string
+	switch mediaType := strings.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0])); mediaType {
+	case "application/json-patch+json":
+		format = service.PatchFormatJSONPatch
+	case "application/merge-patch+json":
+		format = service.PatchFormatMergePatch
+	default:
+		return response.Error(c, "Content-Type must be application/json-patch+json or application/merge-patch+json", "UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, nil)
+	}
+
+	revision, present, err := ifMatchRevision(c)
+	if !present {
+		return ifMatchRequired(c)
+	}
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	var lastEditedQuestion *uint
+	if raw := c.Query("last_edited_question"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return response.BadRequest(c, "Invalid last_edited_question")
+		}
+		question := uint(id)
+		lastEditedQuestion = &question
+	}
+
+	var draft *models.SurveyDraft
+	patched := func(current *models.SurveyDraft) ([]uint, error) {
+		return service.PatchedQuestions(format, current.DraftContent, c.Body())
+	}
+	err = h.collaboration.GuardWrite(c.Context(), uint(draftID), currentUserID(c), patched, func() (err error) {
+		draft, err = h.surveyService.PatchDraft(c.Context(), uint(draftID), format, c.Body(), lastEditedQuestion, currentUserID(c), revision)
+		return err
+	})
+	if err != nil {
+		var locked *service.QuestionLockedError
+		if errors.As(err, &locked) {
+			return questionLocked(c, locked)
+		}
+		var conflict *service.DraftConflictError
+		if errors.As(err, &conflict) {
+			return draftConflict(c, conflict)
+		}
+		var invalid *service.DraftValidationError
+		if errors.As(err, &invalid) {
+			return draftInvalid(c, invalid)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft not found")
+		}
+		if errors.Is(err, service.ErrInvalidPatch) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, service.ErrPatchFailed) {
+			return response.Error(c, err.Error(), "PATCH_FAILED", http.StatusUnprocessableEntity, nil)
+		}
+		return response.InternalServerError(c, "Failed to patch draft: "+err.Error())
+	}
+
+	h.collaboration.DraftSaved(draft, currentUserID(c))
+	c.Set(fiber.HeaderETag, draftETag(draft.Revision))
 	return response.Success(c, fiber.Map{
 		"draftId":   draft.DraftID,
+		"revision":  draft.Revision,
 		"lastSaved": draft.LastSaved,
 	}, "Draft updated successfully")
 }
@@ -139,6 +289,7 @@ func (h *SurveyHandler) GetDraft(c *fiber.Ctx) error {
 		return response.InternalServerError(c, "Failed to get draft")
 	}
 
+	c.Set(fiber.HeaderETag, draftETag(draft.Revision))
 	return response.Success(c, draft, "Draft retrieved successfully")
 }
 
@@ -149,6 +300,15 @@ func (h *SurveyHandler) PublishDraft(c *fiber.Ctx) error {
 		return response.BadRequest(c, "Invalid draft ID")
 	}
 
+	// Publishing must be based on the draft's current revision
+	revision, present, err := ifMatchRevision(c)
+	if !present {
+		return ifMatchRequired(c)
+	}
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
 	// Get the requested draft
 	draft, err := h.surveyService.GetDraft(c.Context(), uint(draftID))
 	if err != nil {
@@ -164,13 +324,557 @@ func (h *SurveyHandler) PublishDraft(c *fiber.Ctx) error {
 	}
 
 	// Publish the draft using service
-	surveyID, err := h.surveyService.PublishDraftToSurvey(c.Context(), uint(draftID))
+	surveyID, err := h.surveyService.PublishDraftToSurvey(c.Context(), uint(draftID), revision)
 	if err != nil {
+		var validationErr *service.BranchingValidationError
+		if errors.As(err, &validationErr) {
+			return response.ValidationError(c, validationErr.Report)
+		}
+		var conflict *service.DraftConflictError
+		if errors.As(err, &conflict) {
+			return draftConflict(c, conflict)
+		}
+		var invalid *service.DraftValidationError
+		if errors.As(err, &invalid) {
+			return draftInvalid(c, invalid)
+		}
+		if errors.Is(err, service.ErrInvalidCondition) || errors.Is(err, service.ErrInvalidQuestionKey) || errors.Is(err, service.ErrInvalidQuestionConfig) || errors.Is(err, service.ErrInvalidSchedule) || errors.Is(err, service.ErrInvalidLayout) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, service.ErrInvalidStatusTransition) {
+			return statusConflict(c, err)
+		}
 		return response.InternalServerError(c, "Failed to publish survey: "+err.Error())
 	}
 
+	h.collaboration.DraftClosed(uint(draftID), "published")
+
 	// Return success with the survey ID
 	return response.Success(c, fiber.Map{
 		"surveyId": surveyID,
 	}, "Survey published successfully")
 }
+
+func (h *SurveyHandler) ValidateDraft(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+
+	report, err := h.surveyService.ValidateDraft(c.Context(), uint(draftID))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCondition) || errors.Is(err, service.ErrInvalidQuestionKey) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to validate draft: "+err.Error())
+	}
+
+	return response.Success(c, report, "Draft validated successfully")
+}
+
+// ExportFlow returns the survey's question flow as Graphviz DOT (default) or Mermaid text
+func (h *SurveyHandler) ExportFlow(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	format := c.Query("format", service.FlowFormatDOT)
+	flow, err := h.surveyService.ExportFlow(c.Context(), uint(surveyID), format)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedFlowFormat) || errors.Is(err, service.ErrInvalidCondition) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to export survey flow: "+err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.SendString(flow)
+}
+
+func (h *SurveyHandler) ListVersions(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	versions, err := h.surveyService.ListVersions(c.Context(), uint(surveyID))
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get survey versions: "+err.Error())
+	}
+
+	return response.Success(c, versions, "Survey versions retrieved successfully")
+}
+
+// GetVersion returns one published version of a survey, including its full snapshot
+func (h *SurveyHandler) GetVersion(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+	versionNumber, err := c.ParamsInt("version")
+	if err != nil || versionNumber < 1 {
+		return response.BadRequest(c, "Invalid version number")
+	}
+
+	version, err := h.surveyService.GetVersion(c.Context(), uint(surveyID), versionNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey version not found")
+		}
+		return response.InternalServerError(c, "Failed to get survey version: "+err.Error())
+	}
+
+	return response.Success(c, version, "Survey version retrieved successfully")
+}
+
+// statusConflict reports a lifecycle violation as 409 Conflict
+func statusConflict(c *fiber.Ctx, err error) error {
+	return response.Error(c, err.Error(), "INVALID_STATUS_TRANSITION", http.StatusConflict, nil)
+}
+
+// ChangeStatus moves a survey to another lifecycle status (OPEN, PAUSED, CLOSED, ARCHIVED)
+func (h *SurveyHandler) ChangeStatus(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	var req ChangeStatusRequest
+	if err := c.BodyParser(&req); err != nil || req.Status == "" {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	survey, err := h.surveyService.ChangeStatus(c.Context(), uint(surveyID), conductorID, req.Status)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		if errors.Is(err, service.ErrSurveyNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		if errors.Is(err, service.ErrInvalidStatusTransition) {
+			return statusConflict(c, err)
+		}
+		return response.InternalServerError(c, "Failed to change survey status: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey status changed successfully")
+}
+
+// SetSchedule replaces a survey's opens_at/closes_at window. Omitted times are cleared.
+func (h *SurveyHandler) SetSchedule(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	var req ScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	survey, err := h.surveyService.SetSchedule(c.Context(), uint(surveyID), conductorID, req.OpensAt, req.ClosesAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		if errors.Is(err, service.ErrSurveyNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		if errors.Is(err, service.ErrInvalidSchedule) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to schedule survey: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey schedule updated successfully")
+}
+
+// CloneSurvey copies a survey's questions, options, media, requirements and branching
+// rules into a new DRAFT survey
+func (h *SurveyHandler) CloneSurvey(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req CloneSurveyRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	// The copy belongs to the authenticated conductor, never to one named in the request
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	survey, err := h.surveyService.CloneSurvey(c.Context(), uint(surveyID), service.CloneOptions{
+		Title:       req.Title,
+		ConductorID: conductorID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		if errors.Is(err, service.ErrSurveyNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to clone survey: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey cloned successfully", fiber.StatusCreated)
+}
+
+// SetTemplate offers a survey as a personal or organisation-wide template
+func (h *SurveyHandler) SetTemplate(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	survey, err := h.surveyService.SetTemplate(c.Context(), uint(surveyID), req.Scope)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		if errors.Is(err, service.ErrInvalidTemplateScope) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to update template: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey template updated successfully")
+}
+
+// ListTemplates returns the authenticated conductor's personal templates and all
+// organisation templates
+func (h *SurveyHandler) ListTemplates(c *fiber.Ctx) error {
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	templates, err := h.surveyService.ListTemplates(c.Context(), conductorID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to list templates: "+err.Error())
+	}
+
+	return response.Success(c, templates, "Templates retrieved successfully")
+}
+
+// UseTemplate creates a new DRAFT survey from a template
+func (h *SurveyHandler) UseTemplate(c *fiber.Ctx) error {
+	templateID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid template ID")
+	}
+
+	var req CloneSurveyRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.BadRequest(c, "Invalid request body")
+		}
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	survey, err := h.surveyService.UseTemplate(c.Context(), uint(templateID), service.CloneOptions{
+		Title:       req.Title,
+		ConductorID: conductorID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Template not found")
+		}
+		if errors.Is(err, service.ErrNotATemplate) {
+			return response.BadRequest(c, err.Error())
+		}
+		if errors.Is(err, service.ErrTemplateNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to create survey from template: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey created from template successfully", fiber.StatusCreated)
+}
+
+// DeleteSurvey soft-deletes a survey. With ?hard=true it is removed permanently with all
+// its data; surveys with completed sessions additionally need ?force=true.
+func (h *SurveyHandler) DeleteSurvey(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	if !c.QueryBool("hard") {
+		if err := h.surveyService.DeleteSurvey(c.Context(), uint(surveyID), conductorID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return response.NotFound(c, "Survey not found")
+			}
+			if errors.Is(err, service.ErrSurveyNotAccessible) {
+				return response.Forbidden(c, err.Error())
+			}
+			return response.InternalServerError(c, "Failed to delete survey: "+err.Error())
+		}
+		return response.Success(c, nil, "Survey deleted successfully")
+	}
+
+	if err := h.surveyService.HardDeleteSurvey(c.Context(), uint(surveyID), conductorID, c.QueryBool("force")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Survey not found")
+		}
+		if errors.Is(err, service.ErrSurveyNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		if errors.Is(err, service.ErrSurveyHasResponses) {
+			return response.Error(c, err.Error(), "SURVEY_HAS_RESPONSES", http.StatusConflict, nil)
+		}
+		return response.InternalServerError(c, "Failed to delete survey: "+err.Error())
+	}
+	return response.Success(c, nil, "Survey permanently deleted")
+}
+
+// RestoreSurvey undoes a soft delete within the restore window
+func (h *SurveyHandler) RestoreSurvey(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	survey, err := h.surveyService.RestoreSurvey(c.Context(), uint(surveyID), conductorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Deleted survey not found")
+		}
+		if errors.Is(err, service.ErrSurveyNotAccessible) {
+			return response.Forbidden(c, err.Error())
+		}
+		if errors.Is(err, service.ErrRestoreWindowExpired) {
+			return response.Error(c, err.Error(), "RESTORE_WINDOW_EXPIRED", http.StatusGone, nil)
+		}
+		return response.InternalServerError(c, "Failed to restore survey: "+err.Error())
+	}
+
+	return response.Success(c, survey, "Survey restored successfully")
+}
+
+// ListDeletedSurveys returns the authenticated conductor's soft-deleted surveys
+func (h *SurveyHandler) ListDeletedSurveys(c *fiber.Ctx) error {
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	surveys, err := h.surveyService.ListDeletedSurveys(c.Context(), conductorID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to list deleted surveys: "+err.Error())
+	}
+
+	return response.Success(c, surveys, "Deleted surveys retrieved successfully")
+}
+
+// currentUserID returns the authenticated user's ID, 0 if the request carries none
+func currentUserID(c *fiber.Ctx) uint {
+	userID, _ := c.Locals("user_id").(uint)
+	return userID
+}
+
+// ListDraftRevisions returns a draft's save history, newest first
+func (h *SurveyHandler) ListDraftRevisions(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+
+	revisions, err := h.surveyService.ListDraftRevisions(c.Context(), uint(draftID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft not found")
+		}
+		return response.InternalServerError(c, "Failed to list draft revisions: "+err.Error())
+	}
+
+	return response.Success(c, revisions, "Draft revisions retrieved successfully")
+}
+
+// GetDraftRevision returns one revision of a draft, including its content
+func (h *SurveyHandler) GetDraftRevision(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+	number, err := c.ParamsInt("revision")
+	if err != nil || number < 1 {
+		return response.BadRequest(c, "Invalid revision number")
+	}
+
+	revision, err := h.surveyService.GetDraftRevision(c.Context(), uint(draftID), number)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft revision not found")
+		}
+		return response.InternalServerError(c, "Failed to get draft revision: "+err.Error())
+	}
+
+	return response.Success(c, revision, "Draft revision retrieved successfully")
+}
+
+// DiffDraftRevisions compares two revisions of a draft (?from=&to=)
+func (h *SurveyHandler) DiffDraftRevisions(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+	from, to := c.QueryInt("from"), c.QueryInt("to")
+	if from < 1 || to < 1 {
+		return response.BadRequest(c, "from and to must be revision numbers")
+	}
+
+	diff, err := h.surveyService.DiffDraftRevisions(c.Context(), uint(draftID), from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft revision not found")
+		}
+		return response.InternalServerError(c, "Failed to diff draft revisions: "+err.Error())
+	}
+
+	return response.Success(c, diff, "Draft revisions compared successfully")
+}
+
+// RestoreDraftRevision makes an earlier revision the draft's current content
+func (h *SurveyHandler) RestoreDraftRevision(c *fiber.Ctx) error {
+	draftID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid draft ID")
+	}
+	number, err := c.ParamsInt("revision")
+	if err != nil || number < 1 {
+		return response.BadRequest(c, "Invalid revision number")
+	}
+
+	// If-Match is optional here: restoring is an explicit choice, not an autosave
+	revision, present, err := ifMatchRevision(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	if !present {
+		revision = service.AnyRevision
+	}
+
+	var draft *models.SurveyDraft
+	restored := func(current *models.SurveyDraft) ([]uint, error) {
+		earlier, err := h.surveyService.GetDraftRevision(c.Context(), uint(draftID), number)
+		if err != nil {
+			return nil, err
+		}
+		return service.ChangedQuestions(current.DraftContent, earlier.DraftContent)
+	}
+	err = h.collaboration.GuardWrite(c.Context(), uint(draftID), currentUserID(c), restored, func() (err error) {
+		draft, err = h.surveyService.RestoreDraftRevision(c.Context(), uint(draftID), number, currentUserID(c), revision)
+		return err
+	})
+	if err != nil {
+		var locked *service.QuestionLockedError
+		if errors.As(err, &locked) {
+			return questionLocked(c, locked)
+		}
+		var conflict *service.DraftConflictError
+		if errors.As(err, &conflict) {
+			return draftConflict(c, conflict)
+		}
+		var invalid *service.DraftValidationError
+		if errors.As(err, &invalid) {
+			return draftInvalid(c, invalid)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Draft revision not found")
+		}
+		return response.InternalServerError(c, "Failed to restore draft revision: "+err.Error())
+	}
+
+	h.collaboration.DraftSaved(draft, currentUserID(c))
+	c.Set(fiber.HeaderETag, draftETag(draft.Revision))
+	return response.Success(c, draft, "Draft revision restored successfully")
+}
+
+// ListSurveys returns a page of the authenticated conductor's surveys, filtered, searched and sorted
+// by query parameters. Follow next_cursor to fetch the next page.
+func (h *SurveyHandler) ListSurveys(c *fiber.Ctx) error {
+	// Conductors only ever list their own surveys
+	conductorID := currentUserID(c)
+	if conductorID == 0 {
+		return response.Unauthorized(c, "Authenticated conductor required")
+	}
+
+	query := service.SurveyListQuery{
+		ConductorID: conductorID,
+		Search:      c.Query("q"),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+		Limit:       c.QueryInt("limit"),
+		Cursor:      c.Query("cursor"),
+	}
+	if statuses := c.Query("status"); statuses != "" {
+		query.Statuses = strings.Split(statuses, ",")
+	}
+
+	dates := []struct {
+		param  string
+		target **time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+	}
+	for _, date := range dates {
+		raw := c.Query(date.param)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return response.BadRequest(c, "Invalid "+date.param+": expected an RFC 3339 timestamp")
+		}
+		*date.target = &parsed
+	}
+
+	page, err := h.surveyService.ListSurveys(c.Context(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSurveyQuery) {
+			return response.BadRequest(c, err.Error())
+		}
+		return response.InternalServerError(c, "Failed to list surveys: "+err.Error())
+	}
+
+	return response.Success(c, page, "Surveys retrieved successfully")
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/service"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/utils/response"
+	"gorm.io/gorm"
+)
+
+type TranslationHandler struct {
+	translationService service.TranslationService
+}
+
+func NewTranslationHandler(translationService service.TranslationService) *TranslationHandler {
+	return &TranslationHandler{
+		translationService: translationService,
+	}
+}
+
+type SaveTranslationsRequest struct {
+	Locale       string                     `json:"locale"`
+	Translations []service.TranslationInput `json:"translations"`
+}
+
+// translationError answers the errors the translation endpoints share
+func translationError(c *fiber.Ctx, err error, action string) error {
+	if errors.Is(err, service.ErrInvalidLocale) || errors.Is(err, service.ErrInvalidTranslationFile) {
+		return response.BadRequest(c, err.Error())
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response.NotFound(c, "Survey not found")
+	}
+	return response.InternalServerError(c, "Failed to "+action+": "+err.Error())
+}
+
+// SetLocales sets the survey's default locale, the one it is written in, and the
+// locales participants can take it in
+func (h *TranslationHandler) SetLocales(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req service.SurveyLocales
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	locales, err := h.translationService.SetLocales(c.Context(), uint(surveyID), req)
+	if err != nil {
+		return translationError(c, err, "set survey locales")
+	}
+
+	return response.Success(c, locales, "Survey locales updated successfully")
+}
+
+// GetTranslations lists the survey's strings in ?locale= with their translation state;
+// ?untranslated=true leaves out the ones already translated
+func (h *TranslationHandler) GetTranslations(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	translations, err := h.translationService.GetTranslations(c.Context(), uint(surveyID), c.Query("locale"), c.QueryBool("untranslated"))
+	if err != nil {
+		return translationError(c, err, "get translations")
+	}
+
+	return response.Success(c, translations, "Translations retrieved successfully")
+}
+
+func (h *TranslationHandler) SaveTranslations(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	var req SaveTranslationsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	report, err := h.translationService.SaveTranslations(c.Context(), uint(surveyID), req.Locale, req.Translations)
+	if err != nil {
+		return translationError(c, err, "save translations")
+	}
+
+	return response.Success(c, report, "Translations saved successfully")
+}
+
+// ExportTranslations downloads the survey's strings that still need translating into
+// ?locale= as ?format=xliff (the default) or csv; ?all=true exports every string
+func (h *TranslationHandler) ExportTranslations(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	format := c.Query("format", service.TranslationFormatXLIFF)
+	data, err := h.translationService.ExportTranslations(c.Context(), uint(surveyID), c.Query("locale"), format, c.QueryBool("all"))
+	if err != nil {
+		return translationError(c, err, "export translations")
+	}
+
+	extension := "xlf"
+	if format == service.TranslationFormatCSV {
+		extension = "csv"
+	}
+	c.Set(fiber.HeaderContentType, service.TranslationContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="survey-%d-%s.%s"`, surveyID, c.Query("locale"), extension))
+	return c.Send(data)
+}
+
+// ImportTranslations saves a translated file from ExportTranslations, sent as the
+// request body or as the "file" field of a multipart form
+func (h *TranslationHandler) ImportTranslations(c *fiber.Ctx) error {
+	surveyID, err := c.ParamsInt("id")
+	if err != nil {
+		return response.BadRequest(c, "Invalid survey ID")
+	}
+
+	data := c.Body()
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return response.BadRequest(c, "Failed to read uploaded file")
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return response.BadRequest(c, "Failed to read uploaded file")
+		}
+	}
+	if len(data) == 0 {
+		return response.BadRequest(c, "Translation file is required")
+	}
+
+	format := c.Query("format", service.TranslationFormatXLIFF)
+	report, err := h.translationService.ImportTranslations(c.Context(), uint(surveyID), c.Query("locale"), format, data)
+	if err != nil {
+		return translationError(c, err, "import translations")
+	}
+
+	return response.Success(c, report, "Translations imported successfully")
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -44,17 +46,56 @@ func setupDatabase() (*gorm.DB, error) {
 		&models.Survey{},
 		&models.Question{},
 		&models.Option{},
+		&models.MatrixRow{},
+		&models.MatrixColumn{},
+		&models.CodeTest{},
 		&models.SurveyRequirement{},
 		&models.Answer{},
 		&models.SurveySession{},
 		&models.SurveyMediaFile{},
 		&models.SurveyDraft{},
 		&models.BranchingRule{},
+		&models.SurveyVersion{},
+		&models.SurveyDraftRevision{},
+		&models.Section{},
+		&models.Page{},
+		&models.BankQuestion{},
+		&models.Translation{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Full-text index for survey search; the expression must match repository.SurveySearchVector
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_surveys_search ON surveys USING GIN (" + repository.SurveySearchVector + ")").Error; err != nil {
+		return nil, err
+	}
+
+	// Full-text index for question bank search; the expression must match repository.BankQuestionSearchVector
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_bank_questions_search ON bank_questions USING GIN (" + repository.BankQuestionSearchVector + ")").Error; err != nil {
+		return nil, err
+	}
+
+	// Question keys are unique among a survey's current questions
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS " + repository.QuestionKeyIndex + " ON questions " + repository.QuestionKeyIndexDefinition).Error; err != nil {
+		return nil, err
+	}
+
+	// Option.Media used to be read as has-one, which put a foreign key from
+	// survey_media_files.media_id to options; media are now loaded by Option.MediaID
+	if err := db.Exec("ALTER TABLE survey_media_files DROP CONSTRAINT IF EXISTS fk_options_media").Error; err != nil {
+		return nil, err
+	}
+
+	// Option translations used to be named by option position; options from before
+	// option keys are named by ID instead
+	if err := db.Exec("UPDATE translations t SET resource_id = 'question.' || CASE WHEN q.question_key <> '' THEN q.question_key ELSE 'ID' || q.question_id END || '.option.ID' || o.option_id || '.text' " +
+		"FROM options o JOIN questions q ON q.question_id = o.question_id " +
+		"WHERE t.survey_id = q.survey_id AND q.retired_at IS NULL AND COALESCE(o.option_key, '') = '' " +
+		"AND t.resource_id = 'question.' || CASE WHEN q.question_key <> '' THEN q.question_key ELSE 'ID' || q.question_id END || '.option.' || o.position || '.text'").Error; err != nil {
+		return nil, err
+	}
+
 	log.Println("Database migration completed successfully!")
 	return db, nil
 }
@@ -66,20 +107,37 @@ type AllRepositories struct {
 	OptionRepo      repository.OptionRepository
 	AnswerRepo      repository.AnswerRepository
 	SessionRepo     repository.SurveySessionRepository
+	BranchingRepo   repository.BranchingRuleRepository
+	VersionRepo     repository.SurveyVersionRepository
+	RevisionRepo    repository.DraftRevisionRepository
+	SectionRepo     repository.SectionRepository
+	BankRepo        repository.QuestionBankRepository
+	TranslationRepo repository.TranslationRepository
 }
 
 type AllServices struct {
-	SurveyService   service.SurveyService
-	QuestionService service.QuestionService
-	OptionService   service.OptionService
-	AnswerService   service.AnswerService
+	SurveyService    service.SurveyService
+	QuestionService  service.QuestionService
+	OptionService    service.OptionService
+	AnswerService    service.AnswerService
+	BranchingService *service.BranchingService
+	SectionService   service.SectionService
+	BankService      service.QuestionBankService
+	CollaborationHub *service.CollaborationHub
+	TransService     service.TranslationService
+	CodeScorer       *service.CodeAnswerScorer
 }
 
 type AllHandlers struct {
-	SurveyHandler   *handler.SurveyHandler
-	QuestionHandler *handler.QuestionHandler
-	OptionHandler   *handler.OptionHandler
-	AnswerHandler   *handler.AnswerHandler
+	SurveyHandler    *handler.SurveyHandler
+	QuestionHandler  *handler.QuestionHandler
+	OptionHandler    *handler.OptionHandler
+	AnswerHandler    *handler.AnswerHandler
+	BranchingHandler *handler.BranchingHandler
+	CollabHandler    *handler.CollaborationHandler
+	SectionHandler   *handler.SectionHandler
+	BankHandler      *handler.QuestionBankHandler
+	TransHandler     *handler.TranslationHandler
 }
 
 func setupRepositories(db *gorm.DB) AllRepositories {
@@ -90,24 +148,74 @@ func setupRepositories(db *gorm.DB) AllRepositories {
 		OptionRepo:      repository.NewOptionRepository(db),
 		AnswerRepo:      repository.NewAnswerRepository(db),
 		SessionRepo:     repository.NewSurveySessionRepository(db),
+		BranchingRepo:   repository.NewBranchingRuleRepository(db),
+		VersionRepo:     repository.NewSurveyVersionRepository(db),
+		RevisionRepo:    repository.NewDraftRevisionRepository(db),
+		SectionRepo:     repository.NewSectionRepository(db),
+		BankRepo:        repository.NewQuestionBankRepository(db),
+		TranslationRepo: repository.NewTranslationRepository(db),
 	}
 }
 
 func setupServices(repos AllRepositories) AllServices {
+	// How long soft-deleted surveys stay restorable
+	restoreWindow, err := time.ParseDuration(os.Getenv("SURVEY_RESTORE_WINDOW"))
+	if err != nil {
+		restoreWindow = service.DefaultRestoreWindow
+	}
+
+	// Limits for running CODE submissions; zero values fall back to the defaults
+	sandboxTimeLimit, _ := time.ParseDuration(os.Getenv("CODE_SANDBOX_TIME_LIMIT"))
+	sandboxMemoryMB, _ := strconv.Atoi(os.Getenv("CODE_SANDBOX_MEMORY_MB"))
+	sandboxConcurrency, _ := strconv.Atoi(os.Getenv("CODE_SANDBOX_CONCURRENCY"))
+	sandboxProcesses, _ := strconv.Atoi(os.Getenv("CODE_SANDBOX_PROCESSES"))
+	// Runs are refused until a dedicated sandbox user is configured
+	sandboxUID, _ := strconv.ParseUint(os.Getenv("CODE_SANDBOX_UID"), 10, 32)
+	sandboxGID, _ := strconv.ParseUint(os.Getenv("CODE_SANDBOX_GID"), 10, 32)
+	codeSandbox := service.NewCodeSandbox(service.SandboxConfig{
+		TimeLimit:   sandboxTimeLimit,
+		MemoryMB:    sandboxMemoryMB,
+		Concurrency: sandboxConcurrency,
+		Processes:   sandboxProcesses,
+		UID:         uint32(sandboxUID),
+		GID:         uint32(sandboxGID),
+	})
+
+	surveyService := service.NewSurveyService(repos.SurveyRepo, repos.SurveyDraftRepo, repos.BranchingRepo, repos.VersionRepo, repos.RevisionRepo, repos.SectionRepo, repos.TranslationRepo, restoreWindow)
+	branchingService := service.NewBranchingService(repos.QuestionRepo, repos.SurveyRepo, repos.BranchingRepo, repos.SessionRepo, repos.VersionRepo)
+	answerService := service.NewAnswerService(repos.AnswerRepo, repos.QuestionRepo, repos.SessionRepo, branchingService, codeSandbox)
+
+	// How often CODE answers stored by the Participants service are scored
+	codeScorerInterval, err := time.ParseDuration(os.Getenv("CODE_SCORER_INTERVAL"))
+	if err != nil {
+		codeScorerInterval = time.Minute
+	}
+
 	return AllServices{
-		SurveyService:   service.NewSurveyService(repos.SurveyRepo, repos.SurveyDraftRepo),
-		QuestionService: service.NewQuestionService(repos.QuestionRepo, repos.OptionRepo, repos.SurveyRepo),
-		OptionService:   service.NewOptionService(repos.OptionRepo),
-		AnswerService:   service.NewAnswerService(repos.AnswerRepo, repos.QuestionRepo, repos.SessionRepo),
+		SurveyService:    surveyService,
+		QuestionService:  service.NewQuestionService(repos.QuestionRepo, repos.OptionRepo, repos.SurveyRepo, repos.SectionRepo),
+		OptionService:    service.NewOptionService(repos.OptionRepo, repos.QuestionRepo),
+		AnswerService:    answerService,
+		BranchingService: branchingService,
+		SectionService:   service.NewSectionService(repos.SectionRepo, repos.SurveyRepo),
+		BankService:      service.NewQuestionBankService(repos.BankRepo, repos.QuestionRepo, surveyService, answerService),
+		CollaborationHub: service.NewCollaborationHub(surveyService),
+		TransService:     service.NewTranslationService(repos.TranslationRepo, repos.SurveyRepo),
+		CodeScorer:       service.NewCodeAnswerScorer(repos.AnswerRepo, repos.QuestionRepo, codeSandbox, codeScorerInterval),
 	}
 }
 
 func setupHandlers(services AllServices) AllHandlers {
 	return AllHandlers{
-		SurveyHandler:   handler.NewSurveyHandler(services.SurveyService),
-		QuestionHandler: handler.NewQuestionHandler(services.QuestionService),
-		OptionHandler:   handler.NewOptionHandler(services.OptionService),
-		AnswerHandler:   handler.NewAnswerHandler(services.AnswerService),
+		SurveyHandler:    handler.NewSurveyHandler(services.SurveyService, services.CollaborationHub),
+		QuestionHandler:  handler.NewQuestionHandler(services.QuestionService),
+		OptionHandler:    handler.NewOptionHandler(services.OptionService),
+		AnswerHandler:    handler.NewAnswerHandler(services.AnswerService),
+		BranchingHandler: handler.NewBranchingHandler(services.BranchingService),
+		CollabHandler:    handler.NewCollaborationHandler(services.SurveyService, services.CollaborationHub),
+		SectionHandler:   handler.NewSectionHandler(services.SectionService),
+		BankHandler:      handler.NewQuestionBankHandler(services.BankService, services.CollaborationHub),
+		TransHandler:     handler.NewTranslationHandler(services.TransService),
 	}
 }
 
@@ -121,6 +229,16 @@ func main() {
 	services := setupServices(repos)
 	handlers := setupHandlers(services)
 
+	// Open and close scheduled surveys in the background
+	schedulerInterval, err := time.ParseDuration(os.Getenv("SURVEY_SCHEDULER_INTERVAL"))
+	if err != nil {
+		schedulerInterval = time.Minute
+	}
+	go service.NewSurveyScheduler(repos.SurveyRepo, schedulerInterval).Run(context.Background())
+
+	// Score CODE answers submitted through the Participants service
+	go services.CodeScorer.Run(context.Background())
+
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			log.Printf("Error: %v", err)
@@ -132,7 +250,8 @@ func main() {
 		},
 	})
 
-	app.Use(cors.New())
+	// Draft writes are conditional on the ETag, so the builder must be able to read it
+	app.Use(cors.New(cors.Config{ExposeHeaders: fiber.HeaderETag}))
 
 	// Custom logger middleware to properly log request bodies
 	app.Use(func(c *fiber.Ctx) error {
@@ -258,6 +377,11 @@ func main() {
 	routes.SetupQuestionRoutes(api, handlers.QuestionHandler)
 	routes.SetupOptionRoutes(api, handlers.OptionHandler)
 	routes.SetupAnswerRoutes(api, handlers.AnswerHandler)
+	routes.SetupBranchingRoutes(api, handlers.BranchingHandler)
+	routes.SetupCollaborationRoutes(api, handlers.CollabHandler)
+	routes.SetupSectionRoutes(api, handlers.SectionHandler)
+	routes.SetupQuestionBankRoutes(api, handlers.BankHandler)
+	routes.SetupTranslationRoutes(api, handlers.TransHandler)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -1,17 +1,20 @@
-
 package models
 
 import (
 	"time"
-	
 )
 
+// BranchingRule routes a participant from SourceQuestionID to TargetQuestionID
+// when Condition (a JSON-encoded condition tree) matches the session answers.
+// A TargetQuestionID of 0 ends the survey. Rules sharing a source question are
+// evaluated in ascending Priority, then RuleID, and the first match wins.
 type BranchingRule struct {
-    RuleID           uint      `json:"id" gorm:"primaryKey"`
-    SurveyID         uint      `json:"survey_id"`
-    SourceQuestionID uint      `json:"source_question_id"`
-    TargetQuestionID uint      `json:"target_question_id"`
-    Condition        string    `json:"condition"`
-    CreatedAt        time.Time `json:"created_at"`
-    UpdatedAt        time.Time `json:"updated_at"`
-}
\ No newline at end of file
+	RuleID           uint      `json:"id" gorm:"primaryKey"`
+	SurveyID         uint      `json:"survey_id" gorm:"index"`
+	SourceQuestionID uint      `json:"source_question_id" gorm:"index"`
+	TargetQuestionID uint      `json:"target_question_id"`
+	Condition        string    `json:"condition"`
+	Priority         int       `json:"priority"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
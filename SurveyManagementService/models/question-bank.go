@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BankQuestion is a question saved to a conductor's question bank so it can be inserted
+// into any draft. Scope works as for templates: a PERSONAL bank question is offered only
+// to the conductor who saved it, an ORGANISATION one to every conductor. Questions
+// inserted from the bank keep its ID in Question.BankQuestionID.
+type BankQuestion struct {
+	BankQuestionID   uint           `json:"id" gorm:"primaryKey"`
+	ConductorID      uint           `json:"conductor_id" gorm:"index"`
+	Scope            string         `json:"scope" gorm:"index"`           // PERSONAL or ORGANISATION
+	QuestionText     string         `json:"question_text"`                // Copied from Content for listing and search
+	QuestionType     string         `json:"question_type" gorm:"index"`   // Copied from Content for filtering
+	Tags             JSONContent    `json:"tags" gorm:"type:jsonb"`       // Lower-case tags as a JSON array, e.g. ["demographics"]
+	Content          JSONContent    `json:"content" gorm:"type:jsonb"`    // {"question": {...}, "options": [...]} in the draft format
+	SourceQuestionID uint           `json:"source_question_id,omitempty"` // Survey question it was saved from, if any
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // Inserted questions keep pointing at deleted bank questions
+}
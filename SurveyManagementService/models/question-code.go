@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CodeTest is one test case of a CODE question. A submission passes it when, given Input
+// on stdin, it prints ExpectedOutput. Hidden tests are never shown to participants.
+type CodeTest struct {
+	TestID         uint      `json:"id" gorm:"primaryKey"`
+	QuestionID     uint      `json:"question_id" gorm:"index"`
+	Name           string    `json:"name"`
+	Input          string    `json:"input"`
+	ExpectedOutput string    `json:"expected_output"`
+	Hidden         bool      `json:"hidden"`
+	Position       int       `json:"position"` // Run order within the question, from 0
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// QuestionConstraints are the settings of NUMBER, SLIDER, DATE, TIME and DATETIME
+// questions. Settings that do not apply to a question's type are ignored.
+type QuestionConstraints struct {
+	MinValue *float64 `json:"min_value,omitempty"` // NUMBER, SLIDER; required for SLIDER
+	MaxValue *float64 `json:"max_value,omitempty"` // NUMBER, SLIDER; required for SLIDER
+	Step     *float64 `json:"step,omitempty"`      // NUMBER, SLIDER; answers are MinValue plus a multiple of Step
+	Unit     string   `json:"unit,omitempty"`      // NUMBER, SLIDER; shown with the value, e.g. "kg"
+	Earliest string   `json:"earliest,omitempty"`  // DATE, TIME, DATETIME; earliest allowed answer, in the type's format
+	Latest   string   `json:"latest,omitempty"`    // DATE, TIME, DATETIME; latest allowed answer, in the type's format
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// MatrixRow is one statement of a MATRIX question. Participants answer every row on the
+// question's shared column scale.
+type MatrixRow struct {
+	RowID      uint      `json:"id" gorm:"primaryKey"`
+	QuestionID uint      `json:"question_id" gorm:"index"`
+	RowText    string    `json:"row_text"`
+	Position   int       `json:"position"` // Display order within the question, from 0
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// MatrixColumn is one point of a MATRIX question's scale, e.g. "Agree"
+type MatrixColumn struct {
+	ColumnID   uint      `json:"id" gorm:"primaryKey"`
+	QuestionID uint      `json:"question_id" gorm:"index"`
+	ColumnText string    `json:"column_text"`
+	Value      *float64  `json:"value,omitempty"` // Score used for row means in results; nil when the scale is not numeric
+	Position   int       `json:"position"`        // Display order within the question, from 0
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ValidationRules are conductor-set checks on a question's answers, on top of what the
+// question type itself requires. Each rule applies to the answers it makes sense for:
+// text rules to string answers, selection rules to list answers. Skipped answers are
+// not checked; Mandatory covers those.
+type ValidationRules struct {
+	Pattern       string `json:"pattern,omitempty"`        // Regular expression (RE2) a text answer must match in full
+	Format        string `json:"format,omitempty"`         // Named pattern a text answer must match: email, url or phone
+	MinLength     *int   `json:"min_length,omitempty"`     // Characters in a text answer
+	MaxLength     *int   `json:"max_length,omitempty"`     // Characters in a text answer
+	MinSelections *int   `json:"min_selections,omitempty"` // Items in a list answer, e.g. the choices of a MULTIPLE_CHOICE
+	MaxSelections *int   `json:"max_selections,omitempty"` // Items in a list answer
+	Integer       bool   `json:"integer,omitempty"`        // A number, or a text answer read as one, must be whole
+	Message       string `json:"message,omitempty"`        // Shown instead of the generated message when a rule fails
+}
+
+// Value implements the driver.Valuer interface
+func (r ValidationRules) Value() (driver.Value, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (r *ValidationRules) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*r = ValidationRules{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+	return json.Unmarshal(data, r)
+}
@@ -63,6 +63,7 @@ type SurveyDraft struct {
 	SurveyID           uint        `json:"survey_id"`
 	DraftContent       JSONContent `json:"draft_content" gorm:"type:jsonb"` // Use jsonb type after migration
 	LastEditedQuestion uint        `json:"last_edited_question"`
+	Revision           int         `json:"revision" gorm:"not null;default:1"` // Incremented by every save; served as the draft's ETag
 	LastSaved          time.Time   `json:"last_saved"`
 	CreatedAt          time.Time   `json:"created_at"`
 	UpdatedAt          time.Time   `json:"updated_at"`
@@ -84,3 +85,17 @@ func (s SurveyDraft) String() string {
 	return fmt.Sprintf("SurveyDraft{ID: %d, SurveyID: %d, LastEdited: %d, LastSaved: %s}",
 		s.DraftID, s.SurveyID, s.LastEditedQuestion, s.LastSaved)
 }
+
+// SurveyDraftRevision is an immutable copy of a draft's content as of one save. A
+// revision is written whenever a save changes the content, so earlier builder state can
+// be compared and restored.
+type SurveyDraftRevision struct {
+	RevisionID         uint        `json:"id" gorm:"primaryKey"`
+	DraftID            uint        `json:"draft_id" gorm:"uniqueIndex:idx_draft_revision"`
+	RevisionNumber     int         `json:"revision_number" gorm:"uniqueIndex:idx_draft_revision"` // 1 for the first save, then increasing
+	AuthorID           uint        `json:"author_id"`                                             // User who saved it, 0 if unknown
+	ContentHash        string      `json:"content_hash" gorm:"size:64"`                           // SHA-256 of the canonical JSON content
+	DraftContent       JSONContent `json:"draft_content,omitempty" gorm:"type:jsonb"`
+	LastEditedQuestion uint        `json:"last_edited_question"`
+	CreatedAt          time.Time   `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Section groups the pages of a survey, e.g. "About you" and "Your experience"
+type Section struct {
+	SectionID   uint      `json:"id" gorm:"primaryKey"`
+	SurveyID    uint      `json:"survey_id" gorm:"index"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Position    int       `json:"position"` // Order within the survey, from 0
+	Pages       []Page    `json:"pages,omitempty" gorm:"foreignKey:SectionID"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Questions are shuffled within each of the section's pages for each participant
+	RandomizeQuestions bool `json:"randomize_questions,omitempty"`
+}
+
+// Page is a set of questions shown to participants together. Questions join a page
+// through Question.PageID.
+type Page struct {
+	PageID    uint      `json:"id" gorm:"primaryKey"`
+	SectionID uint      `json:"section_id" gorm:"index"`
+	SurveyID  uint      `json:"survey_id" gorm:"index"`
+	Title     string    `json:"title"`
+	Position  int       `json:"position"` // Order within the section, from 0
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Translation is one string of a survey translated into one of its locales. ResourceID
+// names the string, e.g. "question.age.text"; SourceText is the text it was translated
+// from, so a translation whose source has changed since is known to be outdated.
+type Translation struct {
+	TranslationID uint      `json:"id" gorm:"primaryKey"`
+	SurveyID      uint      `json:"survey_id" gorm:"uniqueIndex:idx_translation_resource"`
+	Locale        string    `json:"locale" gorm:"size:35;uniqueIndex:idx_translation_resource"`
+	ResourceID    string    `json:"resource_id" gorm:"size:255;uniqueIndex:idx_translation_resource"`
+	SourceText    string    `json:"source_text"`
+	Text          string    `json:"text"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
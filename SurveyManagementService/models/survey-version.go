@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrImmutableSurveyVersion = errors.New("survey versions are immutable")
+
+// SurveyVersion is an immutable snapshot of a survey, written every time it is published.
+// Sessions pin the version they started on, so later publishes never change what an
+// in-flight participant sees.
+type SurveyVersion struct {
+	VersionID     uint        `json:"id" gorm:"primaryKey"`
+	SurveyID      uint        `json:"survey_id" gorm:"uniqueIndex:idx_survey_version_number"`
+	VersionNumber int         `json:"version_number" gorm:"uniqueIndex:idx_survey_version_number"`
+	Snapshot      JSONContent `json:"snapshot,omitempty" gorm:"type:jsonb"`
+	PublishedAt   time.Time   `json:"published_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// BeforeUpdate rejects any attempt to modify a published version
+func (SurveyVersion) BeforeUpdate(tx *gorm.DB) error {
+	return ErrImmutableSurveyVersion
+}
+
+// SurveySnapshot is the document stored in SurveyVersion.Snapshot
+type SurveySnapshot struct {
+	SurveyID          uint                `json:"survey_id"`
+	Title             string              `json:"title"`
+	Description       string              `json:"description"`
+	IsSelfRecruitment bool                `json:"is_self_recruitment"`
+	Questions         []Question          `json:"questions"`
+	Requirements      []SurveyRequirement `json:"requirements"`
+	MediaFiles        []SurveyMediaFile   `json:"media_files"`
+	BranchingRules    []BranchingRule     `json:"branching_rules"`
+	Sections          []Section           `json:"sections,omitempty"` // Questions name their page with page_id
+}
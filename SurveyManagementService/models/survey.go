@@ -3,6 +3,27 @@ package models
 import (
 	"context"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Survey lifecycle states. StatusPublished is the pre-lifecycle name for an open
+// survey; the scheduler rewrites it to StatusOpen on startup.
+const (
+	StatusDraft     = "DRAFT"
+	StatusScheduled = "SCHEDULED"
+	StatusOpen      = "OPEN"
+	StatusPaused    = "PAUSED"
+	StatusClosed    = "CLOSED"
+	StatusArchived  = "ARCHIVED"
+	StatusPublished = "PUBLISHED"
+)
+
+// Template scopes. A PERSONAL template is offered only to the conductor who owns it,
+// an ORGANISATION template to every conductor.
+const (
+	TemplateScopePersonal     = "PERSONAL"
+	TemplateScopeOrganisation = "ORGANISATION"
 )
 
 type Survey struct {
@@ -12,32 +33,74 @@ type Survey struct {
 	Description       string              `json:"description"`
 	IsSelfRecruitment bool                `json:"is_self_recruitment"`
 	Status            string              `json:"status"`
+	OpensAt           *time.Time          `json:"opens_at,omitempty"`                    // A SCHEDULED survey opens at this time
+	ClosesAt          *time.Time          `json:"closes_at,omitempty"`                   // An OPEN or PAUSED survey closes at this time
+	CurrentVersionID  uint                `json:"current_version_id"`                    // Latest published SurveyVersion, 0 if never published
+	TemplateScope     string              `json:"template_scope,omitempty" gorm:"index"` // Empty unless the survey is offered as a template
+	SourceSurveyID    uint                `json:"source_survey_id,omitempty"`            // Survey or template this one was cloned from
 	Questions         []Question          `json:"questions,omitempty" gorm:"foreignKey:SurveyID"`
 	Requirements      []SurveyRequirement `json:"requirements,omitempty" gorm:"foreignKey:SurveyID"`
 	CreatedAt         time.Time           `json:"created_at"`
 	UpdatedAt         time.Time           `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt      `json:"deleted_at,omitempty" gorm:"index"` // Soft-deleted; restorable within the restore window
+
+	// Locales participants can take the survey in, as a JSON array of BCP 47 tags, and
+	// the one it is written in; a survey without locales is not translated
+	DefaultLocale    string      `json:"default_locale,omitempty" gorm:"size:35"`
+	SupportedLocales JSONContent `json:"supported_locales,omitempty" gorm:"type:jsonb"`
 }
 
 type Question struct {
-	QuestionID     uint      `json:"id" gorm:"primaryKey"`
-	SurveyID       uint      `json:"survey_id"`
-	QuestionText   string    `json:"question_text"`
-	QuestionType   string    `json:"question_type"`   // Enum: Text, MultipleChoice, etc.
-	Options        []Option  `json:"options,omitempty" gorm:"foreignKey:QuestionID"` // For multiple-choice questions
-	CorrectAnswers string    `json:"correct_answers"` // Comma-separated IDs or JSON string for multiple correct answers
-	BranchingLogic string    `json:"branching_logic"` // JSON string or nullable field
-	Mandatory      bool      `json:"mandatory"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-}
+	QuestionID      uint           `json:"id" gorm:"primaryKey"`
+	SurveyID        uint           `json:"survey_id"`
+	QuestionKey     string         `json:"question_key" gorm:"size:64;index"` // Stable conductor-visible key, kept across publishes
+	QuestionText    string         `json:"question_text"`
+	QuestionType    string         `json:"question_type"`                                         // Enum: Text, MultipleChoice, etc.
+	Options         []Option       `json:"options,omitempty" gorm:"foreignKey:QuestionID"`        // For multiple-choice questions
+	MatrixRows      []MatrixRow    `json:"matrix_rows,omitempty" gorm:"foreignKey:QuestionID"`    // Statements of a MATRIX question
+	MatrixColumns   []MatrixColumn `json:"matrix_columns,omitempty" gorm:"foreignKey:QuestionID"` // Scale of a MATRIX question
+	MatrixMulti     bool           `json:"matrix_multi,omitempty"`                                // A MATRIX row accepts several columns instead of one
+	CodeLanguage    string         `json:"code_language,omitempty"`                               // Language of a CODE question's submissions
+	StarterCode     string         `json:"starter_code,omitempty"`                                // Code a CODE question's editor starts with
+	CodeTests       []CodeTest     `json:"code_tests,omitempty" gorm:"foreignKey:QuestionID"`     // Tests a CODE submission is evaluated against
+	CorrectAnswers  string         `json:"correct_answers"`                                       // Comma-separated IDs or JSON string for multiple correct answers
+	BranchingLogic  string         `json:"branching_logic"`                                       // JSON string or nullable field
+	Mandatory       bool           `json:"mandatory"`
+	SurveyVersionID uint           `json:"survey_version_id" gorm:"index"`    // Version this row was published in
+	LineageID       uint           `json:"lineage_id" gorm:"index"`           // Shared by every published version of the same question
+	RetiredAt       *time.Time     `json:"retired_at,omitempty" gorm:"index"` // Set when a republish supersedes this row; its answers keep pointing at it
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
 
+	// Range, step and unit of numeric and date/time questions
+	QuestionConstraints `gorm:"embedded"`
+	// Extra checks on answers, e.g. a pattern or selection counts
+	ValidationRules *ValidationRules `json:"validation_rules,omitempty" gorm:"type:jsonb"`
+	// Place in the survey: the page the question is shown on, if any, and its order among
+	// the survey's current questions, from 0. Sections, then pages, then questions on a
+	// page are in order; questions on no page come first.
+	PageID   *uint `json:"page_id,omitempty" gorm:"index"`
+	Page     *Page `json:"-" gorm:"foreignKey:PageID;constraint:OnDelete:SET NULL"`
+	Position int   `json:"position"`
+	// Options are shuffled for each participant; options with PinLast keep their place
+	// at the end
+	RandomizeOptions bool `json:"randomize_options,omitempty"`
+	// Question bank entry the question was inserted from, kept across publishes and
+	// clones so answers to the same standard question can be compared across surveys
+	BankQuestionID *uint `json:"bank_question_id,omitempty" gorm:"index"`
+}
 
 type Option struct {
-	OptionID    uint      `json:"id" gorm:"primaryKey"`
-	QuestionID  uint      `json:"question_id"`
-	OptionText  string    `json:"option_text"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	OptionID   uint             `json:"id" gorm:"primaryKey"`
+	QuestionID uint             `json:"question_id"`
+	OptionKey  string           `json:"option_key,omitempty" gorm:"size:64"` // Names the option in translations; kept across reordering, republishing and copies
+	OptionText string           `json:"option_text"`
+	Position   int              `json:"position"`                        // Display order within the question, from 0
+	PinLast    bool             `json:"pin_last,omitempty"`              // Kept at the end when options are shuffled, e.g. "Other" or "None"
+	MediaID    *uint            `json:"media_id,omitempty" gorm:"index"` // Image or other media shown with the option, e.g. for IMAGE_RANKING
+	Media      *SurveyMediaFile `json:"media,omitempty" gorm:"-"`        // Loaded by MediaID with the option where it is shown
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
 }
 
 type SurveyRequirement struct {
@@ -51,22 +114,34 @@ type SurveyRequirement struct {
 }
 
 type Answer struct {
-    AnswerID    uint      `json:"id" gorm:"primaryKey"`
-    SessionID   uint      `json:"session_id"`
-    QuestionID  uint      `json:"question_id"`
-    ResponseData string   `json:"response_data"` // JSON string with appropriate structure for each question type
-    CreatedAt   time.Time `json:"created_at"`
-    UpdatedAt   time.Time `json:"updated_at"`
+	AnswerID     uint      `json:"id" gorm:"primaryKey"`
+	SessionID    uint      `json:"session_id"`
+	QuestionID   uint      `json:"question_id"`
+	ResponseData string    `json:"response_data"`        // JSON string with appropriate structure for each question type
+	Evaluation   string    `json:"evaluation,omitempty"` // JSON test results of a CODE answer
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Locale the question was shown in when it was answered
+	Locale string `json:"locale,omitempty" gorm:"size:35"`
 }
 
 type SurveySession struct {
-	SessionID      uint      `json:"id" gorm:"primaryKey"`
-	SurveyID       uint      `json:"survey_id"`
-	ParticipantID  uint      `json:"participant_id"`
-	LastQuestionID uint      `json:"last_question_id"` // Nullable, tracks progress
-	SessionStatus  string    `json:"session_status"`   // Enum: NOT_STARTED, IN_PROGRESS, COMPLETED, ABANDONED
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	SessionID       uint      `json:"id" gorm:"primaryKey"`
+	SurveyID        uint      `json:"survey_id"`
+	ParticipantID   uint      `json:"participant_id"`
+	SurveyVersionID uint      `json:"survey_version_id"` // Version pinned when the session started
+	LastQuestionID  uint      `json:"last_question_id"`  // Nullable, tracks progress
+	SessionStatus   string    `json:"session_status"`    // Enum: NOT_STARTED, IN_PROGRESS, COMPLETED, ABANDONED
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Seed of the session's question and option shuffles, and the order they produced;
+	// set by the Participants service when the session starts
+	RandomSeed        int64       `json:"random_seed"`
+	PresentationOrder JSONContent `json:"presentation_order,omitempty" gorm:"type:jsonb"`
+	// Locale the participant takes the survey in; set by the Participants service
+	Locale string `json:"locale,omitempty" gorm:"size:35"`
 }
 
 type SurveyMediaFile struct {
@@ -9,6 +9,9 @@ func SetupAnswerRoutes(router fiber.Router, h *handler.AnswerHandler) {
 	answerGroup := router.Group("/answers")
 	answerGroup.Post("/", h.CreateAnswer)
 	answerGroup.Post("/bulk", h.SubmitBulkAnswers)
+	answerGroup.Post("/validate", h.ValidateSubmission) // Used by the Participants service before it stores a submission
 	answerGroup.Get("/session/:session_id", h.GetAnswersBySession)
 	answerGroup.Get("/question/:question_id", h.GetAnswersByQuestion)
+	answerGroup.Get("/question/:question_id/all-versions", h.GetAnswersAcrossVersions)
+	answerGroup.Get("/question/:question_id/results", h.GetQuestionResults)
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	middlewares "github.com/rovin99/Survey-Platform/SurveyManagementService/Middlewares"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/handler"
+)
+
+func SetupBranchingRoutes(router fiber.Router, h *handler.BranchingHandler) {
+	router.Put("/questions/:id/branching", middlewares.ConductorRoleMiddleware(), h.SetBranchingLogic)
+	router.Get("/surveys/:id/branching", h.GetBranchingRules) // Allow any authenticated user to view survey branching
+	router.Get("/surveys/:id/branching/validate", h.ValidateBranching)
+	router.Post("/surveys/:id/simulate", middlewares.ConductorRoleMiddleware(), h.Simulate)
+	router.Post("/surveys/:id/next-question", h.NextQuestion) // Used by the participant runtime to follow the survey path
+}
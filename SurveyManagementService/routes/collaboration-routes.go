@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	middlewares "github.com/rovin99/Survey-Platform/SurveyManagementService/Middlewares"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/handler"
+)
+
+// SetupCollaborationRoutes registers the WebSocket channel for editing a draft together
+func SetupCollaborationRoutes(router fiber.Router, h *handler.CollaborationHandler) {
+	router.Get("/drafts/:id/live", middlewares.ConductorRoleMiddleware(), h.UpgradeDraftChannel, websocket.New(h.DraftChannel))
+}
@@ -14,6 +14,7 @@ func SetupOptionRoutes(router fiber.Router, h *handler.OptionHandler) {
 	optionGroup.Post("/batch", middlewares.ConductorRoleMiddleware(), h.BatchCreateOptions)
 	optionGroup.Get("/:id", h.GetOption)                              // Allow any authenticated user to view options
 	optionGroup.Get("/question/:question_id", h.GetOptionsByQuestion) // Allow any authenticated user to view question options
+	optionGroup.Put("/question/:question_id/order", middlewares.ConductorRoleMiddleware(), h.ReorderOptions)
 	optionGroup.Put("/:id", middlewares.ConductorRoleMiddleware(), h.UpdateOption)
 	optionGroup.Delete("/:id", middlewares.ConductorRoleMiddleware(), h.DeleteOption)
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	middlewares "github.com/rovin99/Survey-Platform/SurveyManagementService/Middlewares"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/handler"
+)
+
+func SetupQuestionBankRoutes(router fiber.Router, h *handler.QuestionBankHandler) {
+	bank := router.Group("/question-bank", middlewares.ConductorRoleMiddleware())
+	bank.Post("/", h.SaveQuestion)
+	bank.Get("/", h.SearchQuestions) // ?scope=&tags=&type=&q=&limit=&offset=
+	bank.Get("/:id", h.GetQuestion)
+	bank.Put("/:id", h.UpdateQuestion)
+	bank.Delete("/:id", h.DeleteQuestion)
+	bank.Get("/:id/results", h.CompareResults) // The caller's own surveys only
+
+	router.Post("/drafts/:id/bank-questions", middlewares.ConductorRoleMiddleware(), h.InsertIntoDraft)
+}
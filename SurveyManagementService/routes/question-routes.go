@@ -13,6 +13,7 @@ func SetupQuestionRoutes(router fiber.Router, h *handler.QuestionHandler) {
 	questions.Post("/", middlewares.ConductorRoleMiddleware(), h.CreateQuestion)
 	questions.Get("/:id", h.GetQuestion)                        // Allow any authenticated user to view questions
 	questions.Get("/survey/:survey_id", h.GetQuestionsBySurvey) // Allow any authenticated user to view survey questions
+	questions.Get("/survey/:survey_id/key/:key", h.GetQuestionByKey)
 	questions.Put("/:id", middlewares.ConductorRoleMiddleware(), h.UpdateQuestion)
 	questions.Delete("/:id", middlewares.ConductorRoleMiddleware(), h.DeleteQuestion)
 }
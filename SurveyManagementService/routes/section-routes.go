@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	middlewares "github.com/rovin99/Survey-Platform/SurveyManagementService/Middlewares"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/handler"
+)
+
+func SetupSectionRoutes(router fiber.Router, h *handler.SectionHandler) {
+	router.Get("/surveys/:id/layout", h.GetLayout) // Allow any authenticated user to view the survey layout
+	router.Put("/surveys/:id/layout", middlewares.ConductorRoleMiddleware(), h.SetLayout)
+	router.Post("/surveys/:id/sections", middlewares.ConductorRoleMiddleware(), h.CreateSection)
+	router.Get("/sections/:id", h.GetSection)
+	router.Put("/sections/:id", middlewares.ConductorRoleMiddleware(), h.UpdateSection)
+	router.Delete("/sections/:id", middlewares.ConductorRoleMiddleware(), h.DeleteSection)
+	router.Post("/sections/:id/pages", middlewares.ConductorRoleMiddleware(), h.CreatePage)
+	router.Put("/pages/:id", middlewares.ConductorRoleMiddleware(), h.UpdatePage)
+	router.Delete("/pages/:id", middlewares.ConductorRoleMiddleware(), h.DeletePage)
+}
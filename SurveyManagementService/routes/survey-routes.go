@@ -10,8 +10,21 @@ func SetupSurveyRoutes(router fiber.Router, h *handler.SurveyHandler) {
 	survey := router.Group("/surveys")
 	
 	// Apply conductor role middleware to survey management endpoints
+	survey.Get("/", middlewares.ConductorRoleMiddleware(), h.ListSurveys) // ?status=&q=&sort=&order=&limit=&cursor=
+	survey.Get("/templates", middlewares.ConductorRoleMiddleware(), h.ListTemplates)
+	survey.Post("/templates/:id/use", middlewares.ConductorRoleMiddleware(), h.UseTemplate)
+	survey.Get("/deleted", middlewares.ConductorRoleMiddleware(), h.ListDeletedSurveys)
+	survey.Delete("/:id", middlewares.ConductorRoleMiddleware(), h.DeleteSurvey)        // ?hard=true[&force=true]
+	survey.Post("/:id/restore", middlewares.ConductorRoleMiddleware(), h.RestoreSurvey)
+	survey.Post("/:id/clone", middlewares.ConductorRoleMiddleware(), h.CloneSurvey)
+	survey.Put("/:id/template", middlewares.ConductorRoleMiddleware(), h.SetTemplate)
 	survey.Post("/:id/publish", middlewares.ConductorRoleMiddleware(), h.PublishSurvey)
+	survey.Post("/:id/status", middlewares.ConductorRoleMiddleware(), h.ChangeStatus)
+	survey.Put("/:id/schedule", middlewares.ConductorRoleMiddleware(), h.SetSchedule)
 	survey.Get("/:id/progress", h.GetProgress) // Allow any authenticated user to check progress
+	survey.Get("/:id/flow", h.ExportFlow)       // ?format=dot|mermaid
+	survey.Get("/:id/versions", middlewares.ConductorRoleMiddleware(), h.ListVersions)
+	survey.Get("/:id/versions/:version", middlewares.ConductorRoleMiddleware(), h.GetVersion) // Snapshots hold correct answers and branching rules
 	survey.Get("/:id", h.GetSurvey) // Allow any authenticated user to view surveys
 }
 
@@ -21,7 +34,14 @@ func SetupDraftRoutes(router fiber.Router, h *handler.SurveyHandler) {
 	
 	// Apply conductor role middleware to draft creation/modification endpoints
 	drafts.Post("/", middlewares.ConductorRoleMiddleware(), h.CreateDraft)
+	drafts.Get("/schema", h.GetDraftSchema) // JSON Schema of draft content
 	drafts.Get("/:id", h.GetDraft) // Allow any authenticated user to view drafts
 	drafts.Put("/:id", middlewares.ConductorRoleMiddleware(), h.UpdateDraft)
+	drafts.Patch("/:id", middlewares.ConductorRoleMiddleware(), h.PatchDraft) // JSON Patch or JSON Merge Patch
+	drafts.Get("/:id/validate", h.ValidateDraft)
+	drafts.Get("/:id/revisions", middlewares.ConductorRoleMiddleware(), h.ListDraftRevisions)
+	drafts.Get("/:id/revisions/diff", middlewares.ConductorRoleMiddleware(), h.DiffDraftRevisions) // ?from=&to=
+	drafts.Get("/:id/revisions/:revision", middlewares.ConductorRoleMiddleware(), h.GetDraftRevision)
+	drafts.Post("/:id/revisions/:revision/restore", middlewares.ConductorRoleMiddleware(), h.RestoreDraftRevision)
 	drafts.Post("/:id/publish", middlewares.ConductorRoleMiddleware(), h.PublishDraft)
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	middlewares "github.com/rovin99/Survey-Platform/SurveyManagementService/Middlewares"
+	"github.com/rovin99/Survey-Platform/SurveyManagementService/handler"
+)
+
+func SetupTranslationRoutes(router fiber.Router, h *handler.TranslationHandler) {
+	router.Put("/surveys/:id/locales", middlewares.ConductorRoleMiddleware(), h.SetLocales)
+	router.Get("/surveys/:id/translations", middlewares.ConductorRoleMiddleware(), h.GetTranslations) // ?locale=&untranslated=true
+	router.Put("/surveys/:id/translations", middlewares.ConductorRoleMiddleware(), h.SaveTranslations)
+	router.Get("/surveys/:id/translations/export", middlewares.ConductorRoleMiddleware(), h.ExportTranslations)  // ?locale=&format=xliff|csv&all=true
+	router.Post("/surveys/:id/translations/import", middlewares.ConductorRoleMiddleware(), h.ImportTranslations) // ?locale=&format=xliff|csv
+}
@@ -22,6 +22,7 @@ import { toast } from "sonner";
 import { useRouter } from "next/navigation";
 import { debounce } from 'perfect-debounce';
 import { useAuth } from "@/context/AuthContext";
+import { draftIfMatch } from "@/services/surveyApi";
 
 // Type definitions
 interface Question {
@@ -70,6 +71,7 @@ interface DraftOption {
 interface SurveyDraft {
     draftId?: number;
     surveyId?: number;
+    revision?: number; // Server revision the local copy is based on; sent as If-Match
     draftContent: {
         basicInfo: {
             title: string;
@@ -115,6 +117,7 @@ interface ServerResponse {
     data?: {
         draftId?: number;
         surveyId?: number;
+        revision?: number;
     };
     draftId?: number;
     surveyId?: number;
@@ -122,6 +125,7 @@ interface ServerResponse {
 }
 
 const STORAGE_KEY = 'currentSurveyDraft';
+
 const BACKUP_KEY = `${STORAGE_KEY}-backup`;
 const API_BASE_URL = 'http://localhost:3001'; // Backend API URL
 
@@ -337,7 +341,9 @@ export default function SurveyCreatePage() {
             
             const response = await fetch(endpoint, {
                 method: method,
-                headers: { 'Content-Type': 'application/json' },
+                headers: method === 'PUT'
+                    ? { 'Content-Type': 'application/json', 'If-Match': await draftIfMatch(validDraftId, draftData.revision, API_BASE_URL) }
+                    : { 'Content-Type': 'application/json' },
                 body: JSON.stringify(requestBody)
             });
 
@@ -382,6 +388,7 @@ export default function SurveyCreatePage() {
                 const updatedDraft = {
                     ...draftData,
                     draftId: draftIdFromResponse,
+                    revision: result.data?.revision ?? draftData.revision,
                     lastSaved: new Date().toISOString()
                 };
                 
@@ -411,6 +418,7 @@ export default function SurveyCreatePage() {
             const updatedDraft = {
                 ...draftData,
                 draftId: draftIdFromResponse || draftData.draftId,
+                revision: result.data?.revision ?? draftData.revision,
                 lastSaved: new Date().toISOString()
             };
             
@@ -440,7 +448,9 @@ export default function SurveyCreatePage() {
                         
                     const retryResponse = await fetch(retryEndpoint, {
                         method: retryMethod,
-                        headers: { 'Content-Type': 'application/json' },
+                        headers: retryMethod === 'PUT'
+                            ? { 'Content-Type': 'application/json', 'If-Match': await draftIfMatch(draftData.draftId!, draftData.revision, API_BASE_URL) }
+                            : { 'Content-Type': 'application/json' },
                         body: JSON.stringify({
                             survey_id: draftData.draftContent.basicInfo.conductor_id,
                             draft_content: transformedContent,
@@ -479,6 +489,7 @@ export default function SurveyCreatePage() {
                         const updatedDraft = {
                             ...draftData,
                             draftId: retryDraftId || draftData.draftId,
+                            revision: retryResult.data?.revision ?? draftData.revision,
                             lastSaved: new Date().toISOString()
                         };
                         
@@ -991,7 +1002,7 @@ export default function SurveyCreatePage() {
                             
                             const response = await fetch(publishUrl, {
                                 method: 'POST',
-                                headers: { 'Content-Type': 'application/json' },
+                                headers: { 'Content-Type': 'application/json', 'If-Match': await draftIfMatch(draftId, parsed.revision, API_BASE_URL) },
                                 body: JSON.stringify({
                                     normalizeQuestionIds: true // Add flag to tell backend to normalize question IDs
                                 })
@@ -1063,7 +1074,7 @@ export default function SurveyCreatePage() {
             
             const response = await fetch(publishUrl, {
                 method: 'POST',
-                headers: { 'Content-Type': 'application/json' },
+                headers: { 'Content-Type': 'application/json', 'If-Match': await draftIfMatch(draft.draftId, draft.revision, API_BASE_URL) },
                 body: JSON.stringify({
                     normalizeQuestionIds: true // Add flag to tell backend to normalize question IDs
                 })
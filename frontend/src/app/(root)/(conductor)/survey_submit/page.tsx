@@ -303,7 +303,15 @@ function SurveyComponentInner() {
 			setHasSubmitted(true);
 			toast.success("Survey submitted successfully!");
 		} catch (error: any) {
-            if (error?.response?.status === 409 || 
+            if (error?.response?.status === 422 && Array.isArray(error?.response?.data?.details)) {
+                // Show each invalid answer's message next to its question
+                const invalid: Record<string, string> = {};
+                for (const detail of error.response.data.details) {
+                    invalid[String(detail.question_id)] = detail.message;
+                }
+                setErrors((prevErrors) => ({ ...prevErrors, ...invalid }));
+                toast.error("Some answers need attention");
+            } else if (error?.response?.status === 409 || 
                 error?.response?.data?.error === "survey session is not in progress") {
                  toast.error("This survey has already been submitted or is no longer active.");
                  setHasSubmitted(true); // Treat as submitted to prevent further action
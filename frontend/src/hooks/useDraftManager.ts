@@ -136,6 +136,7 @@ export function useDraftManager(conductorId: number) {
         const updatedDraft = {
           ...draftData,
           draftId: draftIdFromResponse,
+          revision: result.data?.revision ?? draftData.revision,
           lastSaved: new Date().toISOString()
         };
         
@@ -381,20 +382,22 @@ export function useDraftManager(conductorId: number) {
 
   // Publish draft
   const publishDraft = useCallback(async (): Promise<void> => {
-    if (!draft.draftId) {
+    let current = draft;
+    if (!current.draftId) {
       const savedDraft = await manualSave();
       if (!savedDraft?.draftId) {
         throw new Error("Could not obtain a draft ID for publishing");
       }
+      current = savedDraft;
     }
 
-    const result = await surveyApi.publishDraft(draft.draftId!);
+    const result = await surveyApi.publishDraft(current.draftId!, current.revision);
     
     localStorage.removeItem(STORAGE_KEYS.CURRENT_DRAFT);
     localStorage.removeItem(STORAGE_KEYS.BACKUP_DRAFT);
     
     return result;
-  }, [draft.draftId, manualSave]);
+  }, [draft, manualSave]);
 
   // Setup backup interval
   useEffect(() => {
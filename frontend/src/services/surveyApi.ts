@@ -1,6 +1,35 @@
 import { API_ENDPOINTS, LIMITS } from '@/constants/survey';
 import type { SurveyDraft, ServerResponse } from '@/types/survey';
 
+/**
+ * If-Match value for a draft write: the revision the local copy is based on. Drafts
+ * saved before revisions were tracked locally have none, so the draft's current
+ * revision is fetched instead; writes are never sent unconditionally.
+ */
+export async function draftIfMatch(
+  draftId: number,
+  revision?: number,
+  baseUrl: string = API_ENDPOINTS.BASE_URL
+): Promise<string> {
+  if (revision) {
+    return `"${revision}"`;
+  }
+
+  const response = await fetch(`${baseUrl}${API_ENDPOINTS.DRAFTS}/${draftId}`, { method: 'GET' });
+  if (!response.ok) {
+    throw new Error(`Failed to fetch draft revision: ${response.status}`);
+  }
+  const etag = response.headers.get('ETag');
+  if (etag) {
+    return etag;
+  }
+  const body = await response.json();
+  if (!body?.data?.revision) {
+    throw new Error('Draft revision is unknown');
+  }
+  return `"${body.data.revision}"`;
+}
+
 export class SurveyApiService {
   private baseUrl: string;
 
@@ -50,9 +79,14 @@ export class SurveyApiService {
       draft_id: draft.draftId || undefined
     };
 
+    const headers: Record<string, string> = { 'Content-Type': 'application/json' };
+    if (method === 'PUT') {
+      headers['If-Match'] = await draftIfMatch(draft.draftId!, draft.revision, this.baseUrl);
+    }
+
     const response = await fetch(endpoint, {
       method,
-      headers: { 'Content-Type': 'application/json' },
+      headers,
       body: JSON.stringify(requestBody)
     });
 
@@ -94,10 +128,10 @@ export class SurveyApiService {
   /**
    * Publish a draft
    */
-  async publishDraft(draftId: number): Promise<ServerResponse> {
+  async publishDraft(draftId: number, revision?: number): Promise<ServerResponse> {
     const response = await fetch(`${this.baseUrl}${API_ENDPOINTS.DRAFTS}/${draftId}/publish`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', 'If-Match': await draftIfMatch(draftId, revision, this.baseUrl) },
       body: JSON.stringify({
         normalizeQuestionIds: true
       })
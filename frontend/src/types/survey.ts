@@ -73,6 +73,7 @@ export interface SurveyBasicInfo {
 export interface SurveyDraft {
     draftId?: number;
     surveyId?: number;
+    revision?: number; // Server revision the local copy is based on; sent as If-Match
     draftContent: {
         basicInfo: SurveyBasicInfo;
         questions: DraftQuestion[];
@@ -107,6 +108,7 @@ export interface ServerResponse {
     data?: {
         draftId?: number;
         surveyId?: number;
+        revision?: number;
     };
     draftId?: number;
     surveyId?: number;